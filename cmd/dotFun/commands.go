@@ -0,0 +1,378 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/ast/printer"
+	"dotFun/internal/bytecode"
+	"dotFun/internal/cst"
+	"dotFun/internal/diagnostics"
+	"dotFun/internal/lexer"
+	"dotFun/internal/parser"
+	"dotFun/internal/resolver"
+	"dotFun/internal/vm"
+)
+
+func (c *cli) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// singleFile parses the flags of a command that takes exactly one file.
+func (c *cli) singleFile(fs *flag.FlagSet, usage string, args []string) (string, int) {
+	if err := fs.Parse(args); err != nil {
+		return "", c.usageError(usage, "%s", err)
+	}
+	if fs.NArg() != 1 {
+		return "", c.usageError(usage, "expected exactly one file")
+	}
+	return fs.Arg(0), exitOK
+}
+
+func (c *cli) runCommand(args []string) int {
+	const usage = "run [--vm] <file>"
+	fs := c.flags("run")
+	useVM := fs.Bool("vm", false, "run on the bytecode VM instead of the interpreter")
+	path, code := c.singleFile(fs, usage, args)
+	if code != exitOK {
+		return code
+	}
+
+	src, code := c.load(path)
+	if code != exitOK {
+		return code
+	}
+	reporter := diagnostics.NewReporter(path)
+
+	if *useVM || src.compiled {
+		fn, ok := c.compile(src, reporter)
+		if !ok {
+			c.printDiagnostics(src, reporter)
+			return exitData
+		}
+		if err := vm.NewVM().Run(fn); err != nil {
+			reporter.ReportError(diagnostics.PhaseRuntime, err)
+			c.printDiagnostics(src, reporter)
+			return exitRuntime
+		}
+		return exitOK
+	}
+
+	statements, ok := c.frontend(src, reporter)
+	if !ok {
+		c.printDiagnostics(src, reporter)
+		return exitData
+	}
+	if err := resolver.NewInterpreter().Interpret(statements); err != nil {
+		reporter.ReportError(diagnostics.PhaseRuntime, err)
+		c.printDiagnostics(src, reporter)
+		return exitRuntime
+	}
+	return exitOK
+}
+
+func (c *cli) checkCommand(args []string) int {
+	const usage = "check <file>..."
+	fs := c.flags("check")
+	if err := fs.Parse(args); err != nil {
+		return c.usageError(usage, "%s", err)
+	}
+	if fs.NArg() == 0 {
+		return c.usageError(usage, "expected at least one file")
+	}
+
+	result := exitOK
+	for _, path := range fs.Args() {
+		src, code := c.load(path)
+		if code != exitOK {
+			result = code
+			continue
+		}
+		reporter := diagnostics.NewReporter(path)
+		if _, ok := c.frontend(src, reporter); !ok && result == exitOK {
+			result = exitData
+		}
+		c.printDiagnostics(src, reporter)
+	}
+	return result
+}
+
+func (c *cli) buildCommand(args []string) int {
+	const usage = "build [-o <out>] <file>"
+	fs := c.flags("build")
+	out := fs.String("o", "", "output path")
+	path, code := c.singleFile(fs, usage, args)
+	if code != exitOK {
+		return code
+	}
+
+	src, code := c.load(path)
+	if code != exitOK {
+		return code
+	}
+	reporter := diagnostics.NewReporter(path)
+	fn, ok := c.compile(src, reporter)
+	if !ok {
+		c.printDiagnostics(src, reporter)
+		return exitData
+	}
+
+	if *out == "" {
+		*out = strings.TrimSuffix(path, filepath.Ext(path)) + ".dfc"
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		c.reportIOError(err)
+		return exitIO
+	}
+	if err := bytecode.Encode(f, fn); err != nil {
+		f.Close()
+		c.reportIOError(err)
+		return exitIO
+	}
+	if err := f.Close(); err != nil {
+		c.reportIOError(err)
+		return exitIO
+	}
+	return exitOK
+}
+
+func (c *cli) tokensCommand(args []string) int {
+	const usage = "tokens [--trivia] [--json] <file>"
+	fs := c.flags("tokens")
+	trivia := fs.Bool("trivia", false, "print the whitespace and comments around each token")
+	asJSON := fs.Bool("json", false, "print the tokens as JSON")
+	path, code := c.singleFile(fs, usage, args)
+	if code != exitOK {
+		return code
+	}
+
+	src, code := c.load(path)
+	if code != exitOK {
+		return code
+	}
+	reporter := diagnostics.NewReporter(path)
+	var mode lexer.Mode
+	if *trivia {
+		mode = lexer.KeepTrivia
+	}
+	tokens, ok := c.lex(src, reporter, mode)
+	if *asJSON {
+		if err := lexer.EncodeJSON(c.stdout, path, tokens); err != nil {
+			c.reportIOError(err)
+			return exitIO
+		}
+	} else {
+		for _, tok := range tokens {
+			for _, t := range tok.Leading {
+				fmt.Fprintf(c.stdout, "  leading %s %q\n", t.Kind, t.Text)
+			}
+			fmt.Fprintln(c.stdout, tok)
+			for _, t := range tok.Trailing {
+				fmt.Fprintf(c.stdout, "  trailing %s %q\n", t.Kind, t.Text)
+			}
+		}
+	}
+	if !ok {
+		c.printDiagnostics(src, reporter)
+		return exitData
+	}
+	return exitOK
+}
+
+func (c *cli) astCommand(args []string) int {
+	const usage = "ast [--json] <file>"
+	fs := c.flags("ast")
+	asJSON := fs.Bool("json", false, "print the syntax tree as JSON")
+	path, code := c.singleFile(fs, usage, args)
+	if code != exitOK {
+		return code
+	}
+
+	src, code := c.load(path)
+	if code != exitOK {
+		return code
+	}
+	reporter := diagnostics.NewReporter(path)
+	statements, ok := c.parse(src, reporter)
+	if !ok {
+		c.printDiagnostics(src, reporter)
+		return exitData
+	}
+	var err error
+	if *asJSON {
+		err = ast.EncodeJSON(c.stdout, path, statements)
+	} else {
+		err = ast.Fprint(c.stdout, statements)
+	}
+	if err != nil {
+		c.reportIOError(err)
+		return exitIO
+	}
+	return exitOK
+}
+
+// cstCommand prints the concrete syntax tree. The tree is lossless even
+// for a file with syntax errors, so it is printed before they are
+// reported.
+func (c *cli) cstCommand(args []string) int {
+	const usage = "cst <file>"
+	path, code := c.singleFile(c.flags("cst"), usage, args)
+	if code != exitOK {
+		return code
+	}
+
+	src, code := c.load(path)
+	if code != exitOK {
+		return code
+	}
+	reporter := diagnostics.NewReporter(path)
+	tokens, ok := c.lex(src, reporter, lexer.KeepTrivia)
+	p := parser.NewParser(tokens)
+	root, _, err := p.ParseTree()
+	if err != nil {
+		diagnostics.ReportErrors(reporter, diagnostics.PhaseParser, p.Errors())
+	}
+	if err := cst.Fprint(c.stdout, root); err != nil {
+		c.reportIOError(err)
+		return exitIO
+	}
+	if !ok || err != nil {
+		c.printDiagnostics(src, reporter)
+		return exitData
+	}
+	return exitOK
+}
+
+// completeCommand prints what is being written at a byte offset, as
+// ContextAt sees it. Syntax errors are expected there and not reported.
+func (c *cli) completeCommand(args []string) int {
+	const usage = "complete --offset <n> <file>"
+	fs := c.flags("complete")
+	offset := fs.Int("offset", -1, "byte offset of the cursor")
+	path, code := c.singleFile(fs, usage, args)
+	if code != exitOK {
+		return code
+	}
+
+	src, code := c.load(path)
+	if code != exitOK {
+		return code
+	}
+	if *offset < 0 || *offset > len(src.text) {
+		return c.usageError(usage, "offset must be between 0 and %d", len(src.text))
+	}
+	l := lexer.NewFileLexer(path, src.text)
+	l.SetMode(lexer.KeepTrivia)
+	tokens, _ := l.Lex()
+	ctx := parser.ContextAt(tokens, *offset)
+
+	w := c.stdout
+	fmt.Fprintf(w, "kind: %s\n", ctx.Kind)
+	fmt.Fprintf(w, "prefix: %q at %d\n", ctx.Prefix, ctx.PrefixOffset)
+	if ctx.Receiver != nil {
+		fmt.Fprintf(w, "receiver: %s\n", printer.String(ctx.Receiver))
+	}
+	if ctx.Callee != nil {
+		callee := printer.String(ctx.Callee)
+		if ctx.New {
+			callee = "new " + callee
+		}
+		fmt.Fprintf(w, "call: %s, argument %d\n", callee, ctx.Argument)
+	}
+	fmt.Fprintln(w, "scopes:")
+	for s := ctx.Scope; s != nil; s = s.Parent {
+		kind := "file"
+		if s.Node != nil {
+			kind = strings.TrimPrefix(fmt.Sprintf("%T", s.Node), "*ast.")
+		}
+		names := make([]string, len(s.Names))
+		for i, n := range s.Names {
+			names[i] = n.Name
+		}
+		fmt.Fprintf(w, "  %s: %s\n", kind, strings.Join(names, ", "))
+	}
+	return exitOK
+}
+
+func (c *cli) fmtCommand(args []string) int {
+	const usage = "fmt [--check] [-d|--diff] <file>..."
+	fs := c.flags("fmt")
+	check := fs.Bool("check", false, "list files that are not formatted instead of rewriting them")
+	diff := fs.Bool("diff", false, "print the changes instead of rewriting files")
+	fs.BoolVar(diff, "d", false, "short for --diff")
+	if err := fs.Parse(args); err != nil {
+		return c.usageError(usage, "%s", err)
+	}
+	if fs.NArg() == 0 {
+		return c.usageError(usage, "expected at least one file")
+	}
+
+	result := exitOK
+	for _, path := range fs.Args() {
+		src, code := c.load(path)
+		if code != exitOK {
+			result = code
+			continue
+		}
+		reporter := diagnostics.NewReporter(path)
+		formatted, ok := c.format(src, reporter)
+		if !ok {
+			c.printDiagnostics(src, reporter)
+			if result == exitOK {
+				result = exitData
+			}
+			continue
+		}
+		if formatted == src.text {
+			continue
+		}
+
+		switch {
+		case *check || *diff:
+			if *check {
+				fmt.Fprintln(c.stdout, path)
+			}
+			if *diff {
+				writeDiff(c.stdout, path, src.text, formatted)
+			}
+			if *check && result == exitOK {
+				result = exitData
+			}
+		default:
+			if err := os.WriteFile(path, []byte(formatted), 0o666); err != nil {
+				c.reportIOError(err)
+				result = exitIO
+			}
+		}
+	}
+	return result
+}
+
+func (c *cli) disasmCommand(args []string) int {
+	const usage = "disasm <file>"
+	path, code := c.singleFile(c.flags("disasm"), usage, args)
+	if code != exitOK {
+		return code
+	}
+
+	src, code := c.load(path)
+	if code != exitOK {
+		return code
+	}
+	reporter := diagnostics.NewReporter(path)
+	fn, ok := c.compile(src, reporter)
+	if !ok {
+		c.printDiagnostics(src, reporter)
+		return exitData
+	}
+	bytecode.Disassemble(c.stdout, fn)
+	return exitOK
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	// line includes its '\n', which only the last line of a file may
+	// lack.
+	line string
+}
+
+// writeDiff writes the changes from old to new as a unified diff.
+func writeDiff(w io.Writer, path, old, new string) {
+	ops := diffLines(splitLines(old), splitLines(new))
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", path, path)
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := max(i-diffContext, 0)
+		end := i
+		for unchanged := 0; end < len(ops) && unchanged <= 2*diffContext; end++ {
+			if ops[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		for end > i && ops[end-1].kind == ' ' {
+			end--
+		}
+		end = min(end+diffContext, len(ops))
+
+		oldLine, newLine := 1, 1
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		var oldCount, newCount int
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, op := range ops[start:end] {
+			fmt.Fprintf(w, "%c%s", op.kind, op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				fmt.Fprint(w, "\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+}
+
+func hunkRange(line, count int) string {
+	if count == 0 {
+		line--
+	}
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// splitLines splits text after each '\n', so that a missing newline at the
+// end shows up as a change to the last line.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns a shortest edit script from a to b, with the deletions
+// in each run of changes before the insertions. It uses Myers' linear
+// space algorithm, which splits the problem at the middle snake of an
+// optimal path, so memory stays proportional to the input.
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	diffInto(&ops, a, b)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		end := i
+		for end < len(ops) && ops[end].kind != ' ' {
+			end++
+		}
+		sort.SliceStable(ops[i:end], func(x, y int) bool {
+			return ops[i+x].kind == '-' && ops[i+y].kind == '+'
+		})
+		i = end
+	}
+	return ops
+}
+
+func diffInto(ops *[]diffOp, a, b []string) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for _, line := range a[:prefix] {
+		*ops = append(*ops, diffOp{' ', line})
+	}
+
+	switch a, b := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]; {
+	case len(a) == 0:
+		for _, line := range b {
+			*ops = append(*ops, diffOp{'+', line})
+		}
+	case len(b) == 0:
+		for _, line := range a {
+			*ops = append(*ops, diffOp{'-', line})
+		}
+	default:
+		x, y, u, v := middleSnake(a, b)
+		diffInto(ops, a[:x], b[:y])
+		for _, line := range a[x:u] {
+			*ops = append(*ops, diffOp{' ', line})
+		}
+		diffInto(ops, a[u:], b[v:])
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		*ops = append(*ops, diffOp{' ', line})
+	}
+}
+
+// middleSnake returns the run of equal lines from a[x:u] to b[y:v] in the
+// middle of a shortest edit script from a to b, found by searching from
+// both ends at once. a and b must differ in their first and last lines.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	limit := (n + m + 1) / 2
+	// forward[k] is the furthest x reached on diagonal k = x-y from the
+	// start; backward[c] is the same from the end, on reversed input.
+	offset := limit + 1
+	forward := make([]int, 2*limit+3)
+	backward := make([]int, 2*limit+3)
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			x := forward[offset+k+1]
+			if k != -d && (k == d || forward[offset+k-1] >= forward[offset+k+1]) {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if c := delta - k; odd && c >= -(d-1) && c <= d-1 && x+backward[offset+c] >= n {
+				return x0, y0, x, y
+			}
+		}
+		for c := -d; c <= d; c += 2 {
+			x := backward[offset+c+1]
+			if c != -d && (c == d || backward[offset+c-1] >= backward[offset+c+1]) {
+				x = backward[offset+c-1] + 1
+			}
+			y := x - c
+			x0, y0 := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+c] = x
+			if k := delta - c; !odd && k >= -d && k <= d && x+forward[offset+k] >= n {
+				return n - x, m - y, n - x0, m - y0
+			}
+		}
+	}
+	panic("diff: no middle snake")
+}
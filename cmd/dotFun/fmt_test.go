@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func run(args ...string) (stdout, stderr string, code int) {
+	var out, errOut bytes.Buffer
+	c := &cli{stdin: strings.NewReader(""), stdout: &out, stderr: &errOut}
+	code = c.main(args)
+	return out.String(), errOut.String(), code
+}
+
+// copyFile copies the test input src into a temporary directory.
+func copyFile(t *testing.T, src string) string {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), filepath.Base(src))
+	if err := os.WriteFile(path, data, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestFmtGolden formats each testdata/fmt/*.fun and compares the result
+// with the .golden file beside it, which must itself be formatted.
+func TestFmtGolden(t *testing.T) {
+	inputs, _ := filepath.Glob("testdata/fmt/*.fun")
+	if len(inputs) == 0 {
+		t.Fatal("no inputs")
+	}
+	for _, input := range inputs {
+		golden := strings.TrimSuffix(input, ".fun") + ".golden"
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		path := copyFile(t, input)
+		if _, stderr, code := run("fmt", path); code != exitOK {
+			t.Fatalf("%s: fmt exited %d: %s", input, code, stderr)
+		}
+		got, _ := os.ReadFile(path)
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got\n%s\nwant\n%s", input, got, want)
+		}
+
+		if stdout, _, code := run("fmt", "--check", copyFile(t, golden)); code != exitOK || stdout != "" {
+			t.Errorf("%s is not formatted: %s", golden, stdout)
+		}
+	}
+}
+
+func TestFmtCheck(t *testing.T) {
+	path := copyFile(t, "testdata/fmt/indentation.fun")
+	before, _ := os.ReadFile(path)
+	stdout, _, code := run("fmt", "--check", path)
+	if code != exitData || stdout != path+"\n" {
+		t.Errorf("fmt --check exited %d with %q, want %d with the path", code, stdout, exitData)
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(before, after) {
+		t.Error("fmt --check rewrote the file")
+	}
+
+	if _, _, code := run("fmt", "--check", copyFile(t, "testdata/fmt/indentation.golden")); code != exitOK {
+		t.Errorf("fmt --check on a formatted file exited %d", code)
+	}
+}
+
+func TestFmtDiff(t *testing.T) {
+	tests := []struct {
+		src, diff string
+	}{
+		{"let a = 1\nif a {\n  print(a)\n}\n", `@@ -1,4 +1,4 @@
+ let a = 1
+ if a {
+-  print(a)
++    print(a)
+ }
+`},
+		{"let a = 1\nprint(a)", `@@ -1,2 +1,2 @@
+ let a = 1
+-print(a)
+\ No newline at end of file
++print(a)
+`},
+		{"let a = 1\n\n\n", `@@ -1,3 +1 @@
+ let a = 1
+-
+-
+`},
+		{"let a = 1\n", ""},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "a.fun")
+		os.WriteFile(path, []byte(tt.src), 0o666)
+		stdout, stderr, code := run("fmt", "--diff", path)
+		if code != exitOK {
+			t.Fatalf("%q: fmt --diff exited %d: %s", tt.src, code, stderr)
+		}
+		want := ""
+		if tt.diff != "" {
+			want = "--- " + path + "\n+++ " + path + "\n" + tt.diff
+		}
+		if stdout != want {
+			t.Errorf("%q: got\n%s\nwant\n%s", tt.src, stdout, want)
+		}
+		if got, _ := os.ReadFile(path); string(got) != tt.src {
+			t.Errorf("%q: fmt --diff rewrote the file", tt.src)
+		}
+	}
+}
+
+// TestFmtDiffOrder checks the exact output of fmt -d where Myers' algorithm
+// alone would interleave deletions and insertions.
+func TestFmtDiffOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.fun")
+	os.WriteFile(path, []byte("if a {\n  print(1)\n  print(2)\n}\nlet  b = 1\n"), 0o666)
+	stdout, stderr, code := run("fmt", "-d", path)
+	if code != exitOK {
+		t.Fatalf("fmt -d exited %d: %s", code, stderr)
+	}
+	want := "--- " + path + "\n+++ " + path + "\n" + `@@ -1,5 +1,5 @@
+ if a {
+-  print(1)
+-  print(2)
++    print(1)
++    print(2)
+ }
+-let  b = 1
++let b = 1
+`
+	if stdout != want {
+		t.Errorf("got\n%s\nwant\n%s", stdout, want)
+	}
+}
+
+// TestDiffLines checks that diffLines finds a shortest edit script by
+// comparing it with the longest common subsequence on random input.
+func TestDiffLines(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, rng.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(3)))
+		}
+		return lines
+	}
+	for i := 0; i < 5000; i++ {
+		a, b := random(), random()
+		ops := diffLines(a, b)
+		var gotA, gotB []string
+		common := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				gotA = append(gotA, op.line)
+			}
+			if op.kind != '-' {
+				gotB = append(gotB, op.line)
+			}
+			if op.kind == ' ' {
+				common++
+			}
+		}
+		for j := 1; j < len(ops); j++ {
+			if ops[j-1].kind == '+' && ops[j].kind == '-' {
+				t.Fatalf("diff of %q and %q inserts before it deletes: %v", a, b, ops)
+			}
+		}
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("diff of %q and %q does not rebuild them: %v", a, b, ops)
+		}
+		if want := lcs(a, b); common != want {
+			t.Fatalf("diff of %q and %q keeps %d lines, want %d", a, b, common, want)
+		}
+	}
+}
+
+func lcs(a, b []string) int {
+	length := make([][]int, len(a)+1)
+	for i := range length {
+		length[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				length[i][j] = length[i+1][j+1] + 1
+			} else {
+				length[i][j] = max(length[i+1][j], length[i][j+1])
+			}
+		}
+	}
+	return length[0][0]
+}
@@ -1 +1,98 @@
-package dotFun
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit codes follow the BSD sysexits convention so scripts can tell a bad
+// invocation from a broken program.
+const (
+	exitOK      = 0
+	exitUsage   = 64
+	exitData    = 65
+	exitRuntime = 70
+	exitIO      = 74
+)
+
+const version = "0.1.0"
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(c *cli, args []string) int
+}
+
+var commands = []*command{
+	{"run", "run [--vm] <file>", "execute a source file or compiled bytecode", (*cli).runCommand},
+	{"check", "check <file>...", "report syntax and resolution errors without running", (*cli).checkCommand},
+	{"build", "build [-o <out>] <file>", "compile a source file to bytecode", (*cli).buildCommand},
+	{"repl", "repl", "start an interactive session", (*cli).replCommand},
+	{"tokens", "tokens [--trivia] [--json] <file>", "print the token stream", (*cli).tokensCommand},
+	{"ast", "ast [--json] <file>", "print the syntax tree", (*cli).astCommand},
+	{"cst", "cst <file>", "print the lossless concrete syntax tree", (*cli).cstCommand},
+	{"complete", "complete --offset <n> <file>", "describe the code being written at a cursor", (*cli).completeCommand},
+	{"fmt", "fmt [--check] [--diff] <file>...", "format source files in place", (*cli).fmtCommand},
+	{"disasm", "disasm <file>", "print the compiled bytecode", (*cli).disasmCommand},
+}
+
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.main(os.Args[1:]))
+}
+
+func (c *cli) main(args []string) int {
+	if len(args) == 0 {
+		c.usage(c.stderr)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "help", "-h", "--help":
+		c.usage(c.stdout)
+		return exitOK
+	case "version", "--version":
+		fmt.Fprintf(c.stdout, "dotFun %s\n", version)
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(c, args[1:])
+		}
+	}
+
+	fmt.Fprintf(c.stderr, "dotFun: unknown command %q\n\n", args[0])
+	c.usage(c.stderr)
+	return exitUsage
+}
+
+func (c *cli) usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: dotFun <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-34s %s\n", cmd.usage, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Exit codes:")
+	fmt.Fprintln(w, "  0   success")
+	fmt.Fprintln(w, "  64  invalid command line")
+	fmt.Fprintln(w, "  65  syntax, resolution or compile errors")
+	fmt.Fprintln(w, "  70  runtime error")
+	fmt.Fprintln(w, "  74  file could not be read or written")
+}
+
+func (c *cli) usageError(usage string, format string, args ...any) int {
+	fmt.Fprintf(c.stderr, "dotFun: "+format+"\n", args...)
+	fmt.Fprintf(c.stderr, "Usage: dotFun %s\n", usage)
+	return exitUsage
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/ast/printer"
+	"dotFun/internal/bytecode"
+	"dotFun/internal/diagnostics"
+	"dotFun/internal/lexer"
+	"dotFun/internal/parser"
+	"dotFun/internal/resolver"
+)
+
+type source struct {
+	path     string
+	text     string
+	compiled bool
+}
+
+func (c *cli) load(path string) (*source, int) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c.reportIOError(err)
+		return nil, exitIO
+	}
+	return &source{
+		path:     path,
+		text:     string(data),
+		compiled: bytes.HasPrefix(data, []byte(bytecode.Magic)),
+	}, exitOK
+}
+
+func (c *cli) reportIOError(err error) {
+	d := &diagnostics.Diagnostic{Severity: diagnostics.SeverityError, Message: err.Error()}
+	diagnostics.NewFormatter("").Print(c.stderr, []*diagnostics.Diagnostic{d})
+}
+
+func (c *cli) printDiagnostics(src *source, reporter *diagnostics.Reporter) {
+	reporter.Sort()
+	diagnostics.NewFormatter(src.text).Print(c.stderr, reporter.Diagnostics())
+}
+
+func (c *cli) lex(src *source, reporter *diagnostics.Reporter, mode lexer.Mode) ([]lexer.Token, bool) {
+	l := lexer.NewFileLexer(src.path, src.text)
+	l.SetMode(mode)
+	tokens, err := l.Lex()
+	if err != nil {
+		diagnostics.ReportErrors(reporter, diagnostics.PhaseLexer, l.Errors())
+		return tokens, false
+	}
+	return tokens, true
+}
+
+// parse lexes and parses src. The parser carries on past lexical errors,
+// so every syntax error in the file is reported.
+func (c *cli) parse(src *source, reporter *diagnostics.Reporter) ([]ast.Stmt, bool) {
+	tokens, lexed := c.lex(src, reporter, 0)
+	p := parser.NewParser(tokens)
+	statements, err := p.Parse()
+	if err != nil {
+		diagnostics.ReportErrors(reporter, diagnostics.PhaseParser, p.Errors())
+	}
+	return statements, lexed && err == nil
+}
+
+// format returns src in canonical layout, keeping its comments.
+func (c *cli) format(src *source, reporter *diagnostics.Reporter) (string, bool) {
+	tokens, ok := c.lex(src, reporter, lexer.KeepTrivia)
+	p := parser.NewParser(tokens)
+	statements, err := p.Parse()
+	if err != nil {
+		diagnostics.ReportErrors(reporter, diagnostics.PhaseParser, p.Errors())
+	}
+	if !ok || err != nil {
+		return "", false
+	}
+
+	cfg := &printer.Config{Comments: []printer.Comment{}, Original: src.text}
+	addComments := func(trivia []lexer.Trivia) {
+		for _, t := range trivia {
+			switch t.Kind {
+			case lexer.TriviaLineComment, lexer.TriviaDocComment, lexer.TriviaShebang:
+				cfg.Comments = append(cfg.Comments, printer.Comment{Text: strings.TrimRight(t.Text, " \t\r"), Span: t.Span})
+			case lexer.TriviaBlockComment:
+				cfg.Comments = append(cfg.Comments, printer.Comment{Text: t.Text, Span: t.Span})
+			}
+		}
+	}
+	for _, tok := range tokens {
+		addComments(tok.Leading)
+		addComments(tok.Trailing)
+	}
+	return cfg.Source(statements), true
+}
+
+// frontend lexes, parses and resolves a source file, reporting every
+// problem it finds.
+func (c *cli) frontend(src *source, reporter *diagnostics.Reporter) ([]ast.Stmt, bool) {
+	statements, ok := c.parse(src, reporter)
+	if !ok {
+		return nil, false
+	}
+	for _, err := range resolver.NewResolver().Resolve(statements) {
+		reporter.ReportError(diagnostics.PhaseResolver, err)
+	}
+	return statements, !reporter.HasErrors()
+}
+
+// compile returns the bytecode for src, decoding it directly when src is
+// already compiled.
+func (c *cli) compile(src *source, reporter *diagnostics.Reporter) (*bytecode.Function, bool) {
+	if src.compiled {
+		fn, err := bytecode.Decode(bytes.NewReader([]byte(src.text)))
+		if err != nil {
+			reporter.ReportError(diagnostics.PhaseCompiler, err)
+			return nil, false
+		}
+		return fn, true
+	}
+
+	statements, ok := c.frontend(src, reporter)
+	if !ok {
+		return nil, false
+	}
+	fn, err := bytecode.Compile(statements)
+	if err != nil {
+		reporter.ReportError(diagnostics.PhaseCompiler, err)
+		return nil, false
+	}
+	return fn, true
+}
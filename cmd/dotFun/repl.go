@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/diagnostics"
+	"dotFun/internal/lexer"
+	"dotFun/internal/resolver"
+	"dotFun/internal/runtime"
+)
+
+func (c *cli) replCommand(args []string) int {
+	if len(args) != 0 {
+		return c.usageError("repl", "repl takes no arguments")
+	}
+
+	interpreter := resolver.NewInterpreter()
+	res := resolver.NewResolver()
+	scanner := bufio.NewScanner(c.stdin)
+
+	fmt.Fprintf(c.stdout, "dotFun %s — type :quit to exit\n", version)
+	for {
+		input, ok := c.readInput(scanner)
+		if !ok {
+			fmt.Fprintln(c.stdout)
+			return exitOK
+		}
+		switch strings.TrimSpace(input) {
+		case "":
+			continue
+		case ":quit", ":q":
+			return exitOK
+		}
+
+		src := &source{path: "<repl>", text: input}
+		reporter := diagnostics.NewReporter("")
+		statements, ok := c.parse(src, reporter)
+		if ok {
+			for _, err := range res.Resolve(statements) {
+				reporter.ReportError(diagnostics.PhaseResolver, err)
+			}
+		}
+		if reporter.HasErrors() {
+			c.printDiagnostics(src, reporter)
+			continue
+		}
+
+		if len(statements) == 1 {
+			if stmt, ok := statements[0].(*ast.ExpressionStmt); ok {
+				value, err := interpreter.Eval(stmt.Expression)
+				if err != nil {
+					reporter.ReportError(diagnostics.PhaseRuntime, err)
+					c.printDiagnostics(src, reporter)
+				} else if _, isNil := value.(runtime.Nil); !isNil {
+					fmt.Fprintln(c.stdout, runtime.Inspect(value))
+				}
+				continue
+			}
+		}
+		if err := interpreter.Interpret(statements); err != nil {
+			reporter.ReportError(diagnostics.PhaseRuntime, err)
+			c.printDiagnostics(src, reporter)
+		}
+	}
+}
+
+// readInput reads one logical entry, continuing across lines while
+// brackets are unbalanced or a multi-line string or comment is left open.
+func (c *cli) readInput(scanner *bufio.Scanner) (string, bool) {
+	var sb strings.Builder
+	prompt := ">>> "
+	for {
+		fmt.Fprint(c.stdout, prompt)
+		if !scanner.Scan() {
+			return sb.String(), sb.Len() > 0
+		}
+		sb.WriteString(scanner.Text())
+		sb.WriteString("\n")
+		if isComplete(sb.String()) {
+			return sb.String(), true
+		}
+		prompt = "... "
+	}
+}
+
+func isComplete(input string) bool {
+	tokens, _ := lexer.NewLexer(input).Lex()
+	depth := 0
+	for _, tok := range tokens {
+		switch tok.Type {
+		case lexer.ILLEGAL:
+			// Multi-line strings and block comments may continue on the
+			// next line; any other bad token is left for the parser.
+			if strings.HasPrefix(tok.Lexeme, `"""`) || strings.HasPrefix(tok.Lexeme, `r"""`) ||
+				strings.HasPrefix(tok.Lexeme, "/*") {
+				return false
+			}
+		case lexer.LEFT_PAREN, lexer.LEFT_BRACE, lexer.LEFT_BRACKET:
+			depth++
+		case lexer.RIGHT_PAREN, lexer.RIGHT_BRACE, lexer.RIGHT_BRACKET:
+			depth--
+		}
+	}
+	return depth <= 0
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dotFun/internal/runtime/stdlib/core"
+)
+
+// writeFile writes src to name in a temporary directory.
+func writeFile(t *testing.T, name, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(src), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// runProgram is run with what the program prints captured as well.
+func runProgram(args ...string) (printed, stderr string, code int) {
+	var buf bytes.Buffer
+	saved := core.Stdout
+	core.Stdout = &buf
+	defer func() { core.Stdout = saved }()
+	_, stderr, code = run(args...)
+	return buf.String(), stderr, code
+}
+
+// TestRun runs each program on the interpreter and on the VM, which must
+// print the same output, report the same error and exit with the same code.
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name, src string
+		printed   string
+		code      int
+		// diagnostic is the start of the error reported, if any.
+		diagnostic string
+	}{
+		{"ok", `println(1 + 2)`, "3\n", exitOK, ""},
+		{"closure", `fun counter() {
+  let n = 0
+  return () -> n += 1
+}
+let c = counter()
+c()
+println(c())`, "2\n", exitOK, ""},
+		{"runtime error", `println("before")
+println(1 / 0)`, "before\n", exitRuntime, ":2"},
+		{"throw", `throw "boom"`, "", exitRuntime, ":1"},
+		{"syntax error", `let = 1`, "", exitData, ":1:5: error[parser]:"},
+		{"resolve error", `val x = 1
+x = 2`, "", exitData, ":2:1: error[resolver]:"},
+		{"unsupported", `println("never")
+class A {}`, "", exitData, ":2:1: error[resolver]: class declarations are not supported yet"},
+		{"unsupported expression", `let a = new A()`, "", exitData, ":1:9: error[resolver]: new expressions are not supported yet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "main.fun", tt.src)
+			for _, args := range [][]string{{"run", path}, {"run", "--vm", path}} {
+				printed, stderr, code := runProgram(args...)
+				if printed != tt.printed {
+					t.Errorf("%v printed %q, want %q", args, printed, tt.printed)
+				}
+				if code != tt.code {
+					t.Errorf("%v exited %d, want %d: %s", args, code, tt.code, stderr)
+				}
+				if tt.diagnostic == "" && stderr != "" {
+					t.Errorf("%v reported %s", args, stderr)
+				}
+				if tt.diagnostic != "" && !strings.HasPrefix(stderr, path+tt.diagnostic) {
+					t.Errorf("%v reported %q, want it to start with %q", args, stderr, path+tt.diagnostic)
+				}
+			}
+		})
+	}
+}
+
+// TestRunMessages checks that the two back ends report a runtime error
+// with the same message.
+func TestRunMessages(t *testing.T) {
+	for _, src := range []string{`println(1 / 0)`, `throw "boom"`, `let a = [1]
+a.length = -1`, `nope()`} {
+		path := writeFile(t, "main.fun", src)
+		_, interpreted, _ := runProgram("run", path)
+		_, compiled, _ := runProgram("run", "--vm", path)
+		message := func(stderr string) string {
+			line, _, _ := strings.Cut(stderr, "\n")
+			_, msg, _ := strings.Cut(line, "error[runtime]: ")
+			return msg
+		}
+		if message(interpreted) == "" || message(interpreted) != message(compiled) {
+			t.Errorf("%q: interpreter reported %q, VM %q", src, interpreted, compiled)
+		}
+	}
+}
+
+// TestRunCompiled checks that a file built to bytecode runs like its source.
+func TestRunCompiled(t *testing.T) {
+	path := writeFile(t, "main.fun", `fun adder(n) {
+  return x -> x + n
+}
+println(adder(2)(3))`)
+	out := filepath.Join(t.TempDir(), "main.dfc")
+	if _, stderr, code := run("build", "-o", out, path); code != exitOK {
+		t.Fatalf("build exited %d: %s", code, stderr)
+	}
+	if printed, stderr, code := runProgram("run", out); code != exitOK || printed != "5\n" {
+		t.Errorf("running the built file exited %d and printed %q: %s", code, printed, stderr)
+	}
+
+	corrupt := writeFile(t, "corrupt.dfc", "DFBC\x00")
+	if _, stderr, code := run("run", corrupt); code != exitData {
+		t.Errorf("running a corrupt file exited %d, want %d: %s", code, exitData, stderr)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	good := writeFile(t, "good.fun", "fun f(x) {\n  return () -> x\n}\nprintln(f(1)())\n")
+	bad := writeFile(t, "bad.fun", "let a = 1\nenum E { A }\n")
+	missing := filepath.Join(t.TempDir(), "missing.fun")
+
+	tests := []struct {
+		files []string
+		code  int
+		// reported is what check writes, with "$bad" standing for the path.
+		reported string
+	}{
+		{[]string{good}, exitOK, ""},
+		{[]string{bad}, exitData, "$bad:2:1: error[resolver]: enum declarations are not supported yet"},
+		{[]string{good, bad}, exitData, "$bad:2:1:"},
+		{[]string{missing}, exitIO, "error: open " + missing},
+		{[]string{bad, missing}, exitIO, "$bad:2:1:"},
+	}
+	for _, tt := range tests {
+		stdout, stderr, code := run(append([]string{"check"}, tt.files...)...)
+		if code != tt.code {
+			t.Errorf("check %v exited %d, want %d: %s", tt.files, code, tt.code, stderr)
+		}
+		if stdout != "" {
+			t.Errorf("check %v printed %q", tt.files, stdout)
+		}
+		want := strings.ReplaceAll(tt.reported, "$bad", bad)
+		if !strings.HasPrefix(stderr, want) || (want == "") != (stderr == "") {
+			t.Errorf("check %v reported %q, want it to start with %q", tt.files, stderr, want)
+		}
+	}
+
+	// Check passes exactly the programs run can execute.
+	if printed, stderr, code := runProgram("run", "--vm", good); code != exitOK || printed != "1\n" {
+		t.Errorf("run --vm on a checked file exited %d and printed %q: %s", code, printed, stderr)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	path := writeFile(t, "main.fun", "println(1)\n")
+	missing := filepath.Join(t.TempDir(), "missing.fun")
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{nil, exitUsage},
+		{[]string{"help"}, exitOK},
+		{[]string{"version"}, exitOK},
+		{[]string{"frobnicate"}, exitUsage},
+		{[]string{"run"}, exitUsage},
+		{[]string{"run", path, path}, exitUsage},
+		{[]string{"run", "--fast", path}, exitUsage},
+		{[]string{"check"}, exitUsage},
+		{[]string{"run", missing}, exitIO},
+		{[]string{"run", "--vm", missing}, exitIO},
+		{[]string{"check", missing}, exitIO},
+	}
+	for _, tt := range tests {
+		if _, stderr, code := runProgram(tt.args...); code != tt.code {
+			t.Errorf("%v exited %d, want %d: %s", tt.args, code, tt.code, stderr)
+		}
+	}
+}
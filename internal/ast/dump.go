@@ -0,0 +1,89 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Fprint writes an indented tree of node to w, one field per line.
+func Fprint(w io.Writer, node any) error {
+	d := &dumper{w: w}
+	d.value(reflect.ValueOf(node), 0)
+	d.printf("\n")
+	return d.err
+}
+
+type dumper struct {
+	w   io.Writer
+	err error
+}
+
+func (d *dumper) printf(format string, args ...any) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.w, format, args...)
+}
+
+func (d *dumper) value(v reflect.Value, depth int) {
+	indent := strings.Repeat("  ", depth+1)
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		d.printf("nil")
+
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			d.printf("nil")
+			return
+		}
+		if t, ok := v.Interface().(Type); ok {
+			d.printf("%s", t.TypeName())
+			return
+		}
+		d.value(v.Elem(), depth)
+
+	case reflect.Slice:
+		if v.Len() == 0 {
+			d.printf("[]")
+			return
+		}
+		d.printf("[\n")
+		for i := 0; i < v.Len(); i++ {
+			d.printf("%s", indent)
+			d.value(v.Index(i), depth+1)
+			d.printf("\n")
+		}
+		d.printf("%s]", strings.Repeat("  ", depth))
+
+	case reflect.Struct:
+		if span, ok := v.Interface().(Span); ok {
+			d.printf("%d:%d-%d:%d", span.StartLine, span.StartCol, span.EndLine, span.EndCol)
+			return
+		}
+		t := v.Type()
+		d.printf("%s {", t.Name())
+		if t.NumField() == 0 {
+			d.printf("}")
+			return
+		}
+		d.printf("\n")
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			d.printf("%s%s: ", indent, t.Field(i).Name)
+			d.value(v.Field(i), depth+1)
+			d.printf("\n")
+		}
+		d.printf("%s}", strings.Repeat("  ", depth))
+
+	case reflect.String:
+		d.printf("%q", v.String())
+
+	default:
+		d.printf("%v", v.Interface())
+	}
+}
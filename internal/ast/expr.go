@@ -1,47 +1,101 @@
 package ast
 
+// Node is implemented by every syntax node. Nodes embed a Span, which
+// provides these methods.
+type Node interface {
+	Pos() Position
+	End() Position
+	SourceSpan() Span
+}
+
 type Expr interface {
+	Node
 	exprNode()
+	Accept(visitor ExprVisitor[any]) any
 }
 
 type IntLiteral struct {
+	Span
 	Value int64
 }
 
 func (il *IntLiteral) exprNode() {}
 
 type FloatLiteral struct {
+	Span
 	Value float64
 }
 
 func (fl *FloatLiteral) exprNode() {}
 
 type StringLiteral struct {
+	Span
 	Value string
 }
 
 func (sl *StringLiteral) exprNode() {}
 
+// InterpolatedString is a string literal with embedded expressions. Literal
+// text segments are *StringLiteral parts.
+type InterpolatedString struct {
+	Span
+	Parts []Expr
+}
+
+func (is *InterpolatedString) exprNode() {}
+
+type CharLiteral struct {
+	Span
+	Value rune
+}
+
+func (cl *CharLiteral) exprNode() {}
+
 type BoolLiteral struct {
+	Span
 	Value bool
 }
 
 func (bl *BoolLiteral) exprNode() {}
 
+type NilLiteral struct {
+	Span
+}
+
+func (nl *NilLiteral) exprNode() {}
+
 type ArrayLiteral struct {
+	Span
 	Elements []Expr
 }
 
 func (al *ArrayLiteral) exprNode() {}
 
+// AssignExpr is a plain or compound assignment; Operator is "=" or one of
+// "+=", "-=", "*=", "/=", "%=", "&=", "|=", "<<=" and ">>=".
 type AssignExpr struct {
-	Name  *Identifier
-	Value Expr
+	Span
+	Name     *Identifier
+	Operator string
+	Value    Expr
 }
 
 func (ase *AssignExpr) exprNode() {}
 
+// MemberAssignExpr assigns to object.property, with the same operators
+// as AssignExpr.
+type MemberAssignExpr struct {
+	Span
+	Object   Expr
+	Property *Identifier
+	Operator string
+	Value    Expr
+}
+
+func (mae *MemberAssignExpr) exprNode() {}
+
 type BinaryExpr struct {
+	Span
 	Left     Expr
 	Operator string
 	Right    Expr
@@ -50,6 +104,7 @@ type BinaryExpr struct {
 func (be *BinaryExpr) exprNode() {}
 
 type CallExpr struct {
+	Span
 	Callee    Expr
 	Arguments []Expr
 }
@@ -57,12 +112,14 @@ type CallExpr struct {
 func (ce *CallExpr) exprNode() {}
 
 type GroupingExpr struct {
+	Span
 	Expression Expr
 }
 
 func (ge *GroupingExpr) exprNode() {}
 
 type InstanceOfExpr struct {
+	Span
 	Object Expr
 	Type   Type
 }
@@ -70,6 +127,7 @@ type InstanceOfExpr struct {
 func (ie *InstanceOfExpr) exprNode() {}
 
 type LambdaExpr struct {
+	Span
 	Params []string
 	Body   Expr
 }
@@ -77,6 +135,7 @@ type LambdaExpr struct {
 func (le *LambdaExpr) exprNode() {}
 
 type LogicalExpr struct {
+	Span
 	Left     Expr
 	Operator string
 	Right    Expr
@@ -85,6 +144,7 @@ type LogicalExpr struct {
 func (le *LogicalExpr) exprNode() {}
 
 type NewExpr struct {
+	Span
 	ClassName string
 	Args      []Expr
 }
@@ -92,6 +152,7 @@ type NewExpr struct {
 func (ne *NewExpr) exprNode() {}
 
 type PostfixUnaryExpr struct {
+	Span
 	Operand  Expr
 	Operator string
 }
@@ -99,16 +160,20 @@ type PostfixUnaryExpr struct {
 func (pu *PostfixUnaryExpr) exprNode() {}
 
 type SuperExpr struct {
+	Span
 	Method Expr
 }
 
 func (se *SuperExpr) exprNode() {}
 
-type ThisExpr struct{}
+type ThisExpr struct {
+	Span
+}
 
 func (te *ThisExpr) exprNode() {}
 
 type UnaryExpr struct {
+	Span
 	Operator string
 	Right    Expr
 }
@@ -116,20 +181,43 @@ type UnaryExpr struct {
 func (ue *UnaryExpr) exprNode() {}
 
 type VariableExpr struct {
+	Span
 	Name         *Identifier
 	DeclaredType Type
 }
 
 func (ve *VariableExpr) exprNode() {}
 
+// MemberExpr is object.property, or object?.property when Optional, which
+// gives nil instead of failing when object is nil.
 type MemberExpr struct {
+	Span
 	Object   Expr
 	Property *Identifier
+	Optional bool
 }
 
 func (me *MemberExpr) exprNode() {}
 
+// RangeExpr is start..stop, or start..<stop when Inclusive is false.
+type RangeExpr struct {
+	Span
+	Start     Expr
+	Stop      Expr
+	Inclusive bool
+}
+
+func (re *RangeExpr) exprNode() {}
+
+// BadExpr stands in for an expression that had syntax errors.
+type BadExpr struct {
+	Span
+}
+
+func (be *BadExpr) exprNode() {}
+
 type Identifier struct {
+	Span
 	Name string
 }
 
@@ -0,0 +1,315 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"dotFun/internal/lexer"
+)
+
+// EncodeJSON writes the syntax tree of file to w as an indented JSON
+// document in the format described in docs/json.md.
+func EncodeJSON(w io.Writer, file string, stmts []Stmt) error {
+	doc := jsonObject{
+		{"version", lexer.JSONVersion},
+		{"file", file},
+		{"statements", encodeJSONValue(reflect.ValueOf(stmts))},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// DecodeJSON reads a document written by EncodeJSON and returns the file
+// name and statements it holds.
+func DecodeJSON(r io.Reader) (string, []Stmt, error) {
+	var doc struct {
+		Version    int             `json:"version"`
+		File       string          `json:"file"`
+		Statements json.RawMessage `json:"statements"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return "", nil, err
+	}
+	if doc.Version != lexer.JSONVersion {
+		return "", nil, fmt.Errorf("unsupported syntax tree version %d", doc.Version)
+	}
+	d := &jsonDecoder{file: doc.File}
+	v, err := d.value(doc.Statements, reflect.TypeOf([]Stmt(nil)))
+	if err != nil {
+		return "", nil, err
+	}
+	return doc.File, v.Interface().([]Stmt), nil
+}
+
+// jsonObject is a JSON object that keeps its keys in order, so that every
+// node starts with its kind.
+type jsonObject []jsonField
+
+type jsonField struct {
+	name  string
+	value any
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(f.name)
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var (
+	spanType      = reflect.TypeOf(Span{})
+	modifierType  = reflect.TypeOf(ModifierNone)
+	primitiveType = reflect.TypeOf(IntType)
+	typeType      = reflect.TypeOf((*Type)(nil)).Elem()
+)
+
+// jsonKinds maps the kind of every object in the format to its Go type.
+var jsonKinds = map[string]reflect.Type{}
+
+func init() {
+	for _, v := range []any{
+		&IntLiteral{}, &FloatLiteral{}, &StringLiteral{}, &InterpolatedString{},
+		&CharLiteral{}, &BoolLiteral{}, &NilLiteral{}, &ArrayLiteral{},
+		&AssignExpr{}, &MemberAssignExpr{}, &BinaryExpr{}, &CallExpr{},
+		&GroupingExpr{}, &InstanceOfExpr{}, &LambdaExpr{}, &LogicalExpr{},
+		&NewExpr{}, &PostfixUnaryExpr{}, &SuperExpr{}, &ThisExpr{},
+		&UnaryExpr{}, &VariableExpr{}, &MemberExpr{}, &RangeExpr{},
+		&Identifier{}, &BadExpr{},
+
+		&BlockStmt{}, &BreakStmt{}, &ContinueStmt{}, &ExpressionStmt{},
+		&ReturnStmt{}, &ThrowStmt{}, &TryStmt{}, &IfStmt{}, &WhileStmt{},
+		&ForStmt{}, &SwitchCase{}, &SwitchStmt{}, &ValStmt{}, &LetStmt{},
+		&GlobalStmt{}, &FunctionStmt{}, &ConstructorStmt{}, &ClassStmt{},
+		&InterfaceStmt{}, &StructStmt{}, &EnumStmt{}, &DataStmt{},
+		&ImportStmt{}, &ExportStmt{}, &BadStmt{},
+
+		&Parameter{}, &ArrayType{}, &NamedType{},
+	} {
+		t := reflect.TypeOf(v)
+		jsonKinds[t.Elem().Name()] = t
+	}
+	jsonKinds[primitiveType.Name()] = primitiveType
+}
+
+func jsonName(field string) string {
+	r, size := utf8.DecodeRuneInString(field)
+	return string(unicode.ToLower(r)) + field[size:]
+}
+
+func encodeJSONValue(v reflect.Value) any {
+	switch {
+	case v.Type() == modifierType:
+		return v.Interface().(Modifier).String()
+	case v.Type() == primitiveType:
+		return jsonObject{{"kind", primitiveType.Name()}, {"name", v.String()}}
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return encodeJSONValue(v.Elem())
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = encodeJSONValue(v.Index(i))
+		}
+		return list
+
+	case reflect.Struct:
+		t := v.Type()
+		obj := jsonObject{{"kind", t.Name()}}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			switch {
+			case field.Type == spanType:
+				obj = append(obj, jsonField{"span", v.Field(i).Interface().(Span).JSON()})
+			case field.IsExported():
+				obj = append(obj, jsonField{jsonName(field.Name), encodeJSONValue(v.Field(i))})
+			}
+		}
+		return obj
+
+	case reflect.Int32:
+		return string(rune(v.Int()))
+	case reflect.Float64:
+		return lexer.JSONFloat(v.Float())
+	}
+	return v.Interface()
+}
+
+type jsonDecoder struct {
+	file string
+}
+
+func (d *jsonDecoder) value(data json.RawMessage, t reflect.Type) (reflect.Value, error) {
+	isNull := len(data) == 0 || string(data) == "null"
+
+	switch {
+	case t == modifierType:
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return reflect.Value{}, err
+		}
+		m := ModifierFromString(s)
+		if m.String() != s {
+			return reflect.Value{}, fmt.Errorf("unknown modifier %q", s)
+		}
+		return reflect.ValueOf(m), nil
+	case t == primitiveType:
+		var obj struct{ Name string }
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(PrimitiveType(obj.Name)), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if isNull {
+			return reflect.Zero(t), nil
+		}
+		var obj struct{ Kind string }
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return reflect.Value{}, err
+		}
+		kind, ok := jsonKinds[obj.Kind]
+		if !ok || !kind.Implements(t) {
+			return reflect.Value{}, fmt.Errorf("unexpected %q where %s was expected", obj.Kind, t.Name())
+		}
+		v, err := d.value(data, kind)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		iv := reflect.New(t).Elem()
+		iv.Set(v)
+		return iv, nil
+
+	case reflect.Pointer:
+		if isNull {
+			return reflect.Zero(t), nil
+		}
+		v, err := d.value(data, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(v)
+		return p, nil
+
+	case reflect.Slice:
+		if isNull {
+			return reflect.Zero(t), nil
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return reflect.Value{}, err
+		}
+		s := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			v, err := d.value(item, t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			s.Index(i).Set(v)
+		}
+		return s, nil
+
+	case reflect.Struct:
+		return d.object(data, t)
+
+	case reflect.Int32:
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return reflect.Value{}, err
+		}
+		r, size := utf8.DecodeRuneInString(s)
+		if size == 0 || size != len(s) {
+			return reflect.Value{}, fmt.Errorf("character literal %q must hold exactly one character", s)
+		}
+		return reflect.ValueOf(r), nil
+
+	case reflect.Float64:
+		var s string
+		if json.Unmarshal(data, &s) == nil {
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil || !(math.IsInf(f, 0) || math.IsNaN(f)) {
+				return reflect.Value{}, fmt.Errorf("invalid float %q", s)
+			}
+			return reflect.ValueOf(f), nil
+		}
+	}
+
+	p := reflect.New(t)
+	if err := json.Unmarshal(data, p.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return p.Elem(), nil
+}
+
+func (d *jsonDecoder) object(data json.RawMessage, t reflect.Type) (reflect.Value, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return reflect.Value{}, err
+	}
+	var kind string
+	if err := json.Unmarshal(fields["kind"], &kind); err != nil || kind != t.Name() {
+		return reflect.Value{}, fmt.Errorf("expected a %s object", t.Name())
+	}
+
+	v := reflect.New(t).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		switch {
+		case field.Type == spanType:
+			data, ok := fields["span"]
+			if !ok {
+				continue
+			}
+			var span lexer.JSONSpan
+			if err := json.Unmarshal(data, &span); err != nil {
+				return reflect.Value{}, fmt.Errorf("%s.span: %w", kind, err)
+			}
+			v.Field(i).Set(reflect.ValueOf(span.Span(d.file)))
+		case field.IsExported():
+			name := jsonName(field.Name)
+			data, ok := fields[name]
+			if !ok {
+				continue
+			}
+			fv, err := d.value(data, field.Type)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("%s.%s: %w", kind, name, err)
+			}
+			v.Field(i).Set(fv)
+		}
+	}
+	return v, nil
+}
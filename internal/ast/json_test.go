@@ -0,0 +1,87 @@
+package ast_test
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/lexer"
+	"dotFun/internal/parser"
+)
+
+// TestJSONRoundTrip checks that decoding the encoding of the corpus gives
+// back the same statements.
+func TestJSONRoundTrip(t *testing.T) {
+	src, err := os.ReadFile("../parser/testdata/corpus.fun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens, _ := lexer.NewFileLexer("corpus.fun", string(src)).Lex()
+	statements, _ := parser.NewParser(tokens).Parse()
+
+	var buf bytes.Buffer
+	if err := ast.EncodeJSON(&buf, "corpus.fun", statements); err != nil {
+		t.Fatal(err)
+	}
+	file, decoded, err := ast.DecodeJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file != "corpus.fun" {
+		t.Errorf("file is %q, want corpus.fun", file)
+	}
+	if !reflect.DeepEqual(decoded, statements) {
+		t.Errorf("decoded statements differ:\n%s\nwant\n%s", dump(decoded), dump(statements))
+	}
+}
+
+// TestJSONGolden checks the encoded shape against testdata/shape.json.
+func TestJSONGolden(t *testing.T) {
+	src := "public async fun f(a: Int, b: []String): Int { return a ?? 1.5 }\n" +
+		"class C extends B { override fun g() { super.g(); this.c = 'x' } }\n"
+	tokens, err := lexer.NewFileLexer("shape.fun", src).Lex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements, err := parser.NewParser(tokens).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := ast.EncodeJSON(&buf, "shape.fun", statements); err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/shape.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != string(want) {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJSONDecodeErrors(t *testing.T) {
+	tests := []struct {
+		doc, err string
+	}{
+		{`{"version": 1, "file": "a", "statements": [{"kind": "GotoStmt"}]}`,
+			`unexpected "GotoStmt" where Stmt was expected`},
+		{`{"version": 1, "file": "a", "statements": [{"kind": "IntLiteral", "value": 1}]}`,
+			`unexpected "IntLiteral" where Stmt was expected`},
+		{`{"version": 1, "file": "a", "statements": [{"kind": "ExpressionStmt", "expression": {"kind": "Nope"}}]}`,
+			`ExpressionStmt.expression: unexpected "Nope" where Expr was expected`},
+		{`{"version": 1, "file": "a", "statements": [{"kind": "ValStmt", "modifiers": "secret"}]}`,
+			`ValStmt.modifiers: unknown modifier "secret"`},
+		{`{"version": 99, "file": "a", "statements": []}`,
+			`unsupported syntax tree version 99`},
+	}
+	for _, tt := range tests {
+		_, _, err := ast.DecodeJSON(strings.NewReader(tt.doc))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s: error %v, want %s", tt.doc, err, tt.err)
+		}
+	}
+}
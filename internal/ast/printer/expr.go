@@ -0,0 +1,289 @@
+package printer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"dotFun/internal/ast"
+)
+
+// Precedence levels, from loosest to tightest binding. They mirror the
+// parser's table in internal/parser/precedence.go.
+const (
+	precLowest = iota
+	precNilCoalescing
+	precOr
+	precAnd
+	precEquality
+	precComparison
+	precRange
+	precBitOr
+	precBitXor
+	precBitAnd
+	precShift
+	precTerm
+	precFactor
+	precUnary
+	precPower
+	precPostfix
+	precPrimary
+)
+
+var binaryPrecedence = map[string]int{
+	"??": precNilCoalescing,
+	"||": precOr, "or": precOr,
+	"&&": precAnd, "and": precAnd,
+	"==": precEquality, "!=": precEquality,
+	"<": precComparison, "<=": precComparison, ">": precComparison, ">=": precComparison,
+	"|":  precBitOr,
+	"^":  precBitXor,
+	"&":  precBitAnd,
+	"<<": precShift, ">>": precShift,
+	"+": precTerm, "-": precTerm,
+	"*": precFactor, "/": precFactor, "%": precFactor,
+	"**": precPower,
+}
+
+func precedence(expr ast.Expr) int {
+	switch e := expr.(type) {
+	case *ast.AssignExpr, *ast.MemberAssignExpr, *ast.LambdaExpr:
+		return precLowest
+	case *ast.BinaryExpr:
+		return binaryPrecedence[e.Operator]
+	case *ast.LogicalExpr:
+		return binaryPrecedence[e.Operator]
+	case *ast.InstanceOfExpr:
+		return precComparison
+	case *ast.RangeExpr:
+		return precRange
+	case *ast.UnaryExpr:
+		return precUnary
+	case *ast.PostfixUnaryExpr:
+		return precPostfix
+	case *ast.IntLiteral:
+		if e.Value < 0 {
+			return precUnary
+		}
+	case *ast.FloatLiteral:
+		if e.Value < 0 {
+			return precUnary
+		}
+	}
+	return precPrimary
+}
+
+// expr prints expr, in parentheses if it binds more loosely than min,
+// along with the comments before and right after it.
+func (p *printer) expr(expr ast.Expr, min int) {
+	p.commentsInside(expr.Pos().Offset)
+	p.exprBody(expr, min)
+	p.commentsAfter(expr.End().Offset)
+}
+
+func (p *printer) exprBody(expr ast.Expr, min int) {
+	if precedence(expr) < min {
+		p.write("(")
+		p.expr(expr, precLowest)
+		p.write(")")
+		return
+	}
+	if text, ok := p.spelling(expr); ok {
+		p.write(text)
+		return
+	}
+
+	switch e := expr.(type) {
+	case *ast.IntLiteral:
+		p.write(strconv.FormatInt(e.Value, 10))
+	case *ast.FloatLiteral:
+		p.write(formatFloat(e.Value))
+	case *ast.StringLiteral:
+		p.write(quote(e.Value, '"'))
+	case *ast.InterpolatedString:
+		p.write(`"`)
+		for _, part := range e.Parts {
+			if s, ok := part.(*ast.StringLiteral); ok {
+				p.write(escape(s.Value, '"'))
+				continue
+			}
+			p.write("${")
+			p.expr(part, precLowest)
+			p.write("}")
+		}
+		p.write(`"`)
+	case *ast.CharLiteral:
+		p.write(quote(string(e.Value), '\''))
+	case *ast.BoolLiteral:
+		p.write(strconv.FormatBool(e.Value))
+	case *ast.NilLiteral:
+		p.write("nil")
+	case *ast.ArrayLiteral:
+		p.write("[")
+		p.exprList(e.Elements)
+		p.commentsInside(e.End().Offset - 1)
+		p.write("]")
+
+	case *ast.AssignExpr:
+		p.write(e.Name.Name, " ", e.Operator, " ")
+		p.expr(e.Value, precLowest)
+	case *ast.MemberAssignExpr:
+		p.expr(e.Object, precPrimary)
+		p.write(".", e.Property.Name, " ", e.Operator, " ")
+		p.expr(e.Value, precLowest)
+	case *ast.BinaryExpr:
+		prec := binaryPrecedence[e.Operator]
+		if e.Operator == "**" {
+			// Right-associative, and its base cannot be a unary expression.
+			p.expr(e.Left, precPostfix)
+			p.write(" ** ")
+			p.expr(e.Right, precUnary)
+			return
+		}
+		p.expr(e.Left, prec)
+		p.write(" ", e.Operator, " ")
+		p.expr(e.Right, prec+1)
+	case *ast.LogicalExpr:
+		prec := binaryPrecedence[e.Operator]
+		p.expr(e.Left, prec)
+		p.write(" ", e.Operator, " ")
+		p.expr(e.Right, prec+1)
+	case *ast.InstanceOfExpr:
+		p.expr(e.Object, precComparison)
+		p.write(" instanceof ", e.Type.TypeName())
+	case *ast.RangeExpr:
+		p.expr(e.Start, precBitOr)
+		if e.Inclusive {
+			p.write("..")
+		} else {
+			p.write("..<")
+		}
+		p.expr(e.Stop, precBitOr)
+	case *ast.UnaryExpr:
+		p.write(e.Operator)
+		operand := String(e.Right)
+		if e.Operator == "not" || (e.Operator == "-" && strings.HasPrefix(operand, "-")) {
+			p.write(" ")
+		}
+		p.expr(e.Right, precUnary)
+	case *ast.PostfixUnaryExpr:
+		p.expr(e.Operand, precPrimary)
+		p.write(e.Operator)
+
+	case *ast.CallExpr:
+		p.expr(e.Callee, precPrimary)
+		p.write("(")
+		p.exprList(e.Arguments)
+		p.commentsInside(e.End().Offset - 1)
+		p.write(")")
+	case *ast.MemberExpr:
+		p.expr(e.Object, precPrimary)
+		if e.Optional {
+			p.write("?.")
+		} else {
+			p.write(".")
+		}
+		p.write(e.Property.Name)
+	case *ast.NewExpr:
+		p.write("new ", e.ClassName, "(")
+		p.exprList(e.Args)
+		p.commentsInside(e.End().Offset - 1)
+		p.write(")")
+	case *ast.SuperExpr:
+		p.write("super.")
+		p.expr(e.Method, precPrimary)
+	case *ast.ThisExpr:
+		p.write("this")
+	case *ast.GroupingExpr:
+		// Parentheses precedence does not need are dropped, except around
+		// a comment: without them a line comment could end the statement.
+		if len(p.comments) == 0 || p.comments[0].Span.StartOffset >= e.End().Offset {
+			p.expr(e.Expression, min)
+			return
+		}
+		p.write("(")
+		p.expr(e.Expression, precLowest)
+		p.write(")")
+	case *ast.LambdaExpr:
+		if len(e.Params) == 1 {
+			p.write(e.Params[0])
+		} else {
+			p.write("(", strings.Join(e.Params, ", "), ")")
+		}
+		p.write(" -> ")
+		p.expr(e.Body, precLowest)
+	case *ast.VariableExpr:
+		p.write(e.Name.Name)
+	case *ast.Identifier:
+		p.write(e.Name)
+	case *ast.BadExpr:
+		p.write("BadExpr")
+	default:
+		panic(fmt.Sprintf("printer: unexpected expression %T", expr))
+	}
+}
+
+func (p *printer) exprList(exprs []ast.Expr) {
+	for i, e := range exprs {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expr(e, precLowest)
+	}
+}
+
+// spelling returns the original text of a literal, when the printer has
+// the source it was parsed from.
+func (p *printer) spelling(expr ast.Expr) (string, bool) {
+	switch expr.(type) {
+	case *ast.IntLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.InterpolatedString, *ast.CharLiteral:
+		span := expr.SourceSpan()
+		if p.original != "" && span.StartOffset < span.EndOffset && span.EndOffset <= len(p.original) {
+			return p.original[span.StartOffset:span.EndOffset], true
+		}
+	}
+	return "", false
+}
+
+// formatFloat prints v so that it lexes as a float again.
+func formatFloat(v float64) string {
+	text := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(text, ".eIN") {
+		text += ".0"
+	}
+	return text
+}
+
+func quote(s string, q rune) string {
+	return string(q) + escape(s, q) + string(q)
+}
+
+// escape writes s for use between q quotes. A '$' is escaped only where it
+// would start an interpolation.
+func escape(s string, q rune) string {
+	var sb strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case r == q || r == '\\':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == 0:
+			sb.WriteString(`\0`)
+		case r == '$' && q == '"' && i+1 < len(runes) && (runes[i+1] == '{' || runes[i+1] == '_' || unicode.IsLetter(runes[i+1])):
+			sb.WriteString(`\$`)
+		case !unicode.IsPrint(r):
+			fmt.Fprintf(&sb, `\u{%X}`, r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
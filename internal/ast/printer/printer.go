@@ -0,0 +1,494 @@
+// Package printer turns syntax trees back into dotFun source in a
+// canonical layout: four-space indentation, one statement per line, single
+// spaces around binary operators and parentheses only where precedence
+// needs them, or around a comment where they were written.
+package printer
+
+import (
+	"io"
+	"math"
+	"strings"
+
+	"dotFun/internal/ast"
+)
+
+const indentUnit = "    "
+
+// Comment is a comment from the source, placed in the output by its span.
+type Comment struct {
+	Text string
+	Span ast.Span
+}
+
+// Config holds printer options.
+type Config struct {
+	// Comments, in source order, are printed next to the statements they
+	// were written beside, or inside a statement after the expression or
+	// list separator they followed; a line comment there breaks the line.
+	// When set, doc comments are expected among them and the Doc fields of
+	// declarations are not printed.
+	Comments []Comment
+	// Original is the source the statements were parsed from. When set,
+	// literals are printed as they were spelled there, so a formatter
+	// keeps hexadecimal numbers, escapes, raw and block strings as
+	// written.
+	Original string
+}
+
+// Fprint writes the program stmts to w.
+func (c *Config) Fprint(w io.Writer, stmts []ast.Stmt) error {
+	_, err := io.WriteString(w, c.Source(stmts))
+	return err
+}
+
+// Source returns the program stmts as source text.
+func (c *Config) Source(stmts []ast.Stmt) string {
+	p := &printer{comments: c.Comments, withComments: c.Comments != nil, original: c.Original}
+	p.stmts(stmts, math.MaxInt)
+	return p.sb.String()
+}
+
+// Fprint writes the program stmts to w using the default Config.
+func Fprint(w io.Writer, stmts []ast.Stmt) error {
+	return (&Config{}).Fprint(w, stmts)
+}
+
+// Source returns the program stmts as source text using the default
+// Config.
+func Source(stmts []ast.Stmt) string {
+	return (&Config{}).Source(stmts)
+}
+
+// String returns the source of a single expression or statement, without
+// a trailing newline.
+func String(node ast.Node) string {
+	p := &printer{}
+	switch n := node.(type) {
+	case ast.Expr:
+		p.expr(n, precLowest)
+	case ast.Stmt:
+		p.stmt(n, math.MaxInt)
+	}
+	return strings.TrimSuffix(p.sb.String(), "\n")
+}
+
+type printer struct {
+	sb     strings.Builder
+	indent int
+	// comments are those not printed yet.
+	comments     []Comment
+	withComments bool
+	// lastLine is the source line where the last statement or comment
+	// printed in the current list ended, or 0 at the start of a list.
+	lastLine int
+	original string
+	// end is the offset where the statement being printed ends. Comments
+	// before it are placed inside the statement, after the tokens they
+	// follow.
+	end int
+	// lineBreak is set by a line comment inside a statement: what is
+	// written next goes on a new line, indented one more level unless it
+	// is a closing bracket. space is set by a block comment, to separate
+	// it from what is written next.
+	lineBreak bool
+	space     bool
+}
+
+func (p *printer) write(text ...string) {
+	for _, t := range text {
+		if p.lineBreak {
+			if t = strings.TrimLeft(t, " "); t == "" {
+				continue
+			}
+			p.lineBreak = false
+			indent := p.indent + 1
+			if strings.IndexByte(")]}", t[0]) >= 0 {
+				indent = p.indent
+			}
+			p.sb.WriteString("\n" + strings.Repeat(indentUnit, indent))
+		}
+		if p.space && t != "" {
+			p.space = false
+			if strings.IndexByte(" \n,)]", t[0]) < 0 {
+				p.sb.WriteString(" ")
+			}
+		}
+		p.sb.WriteString(t)
+	}
+}
+
+// line starts a new indented line.
+func (p *printer) line() {
+	p.sb.WriteString(strings.Repeat(indentUnit, p.indent))
+}
+
+// stmts prints a statement list ending before offset end, keeping a
+// single blank line wherever the source had one or more.
+func (p *printer) stmts(stmts []ast.Stmt, end int) {
+	for i, stmt := range stmts {
+		p.commentsBefore(stmt.Pos().Offset)
+		start := stmt.Pos().Line
+		if doc := docOf(stmt); doc != "" && !p.withComments && start > 0 {
+			start -= strings.Count(doc, "\n") + 1
+		}
+		p.blankLine(start)
+
+		limit := end
+		if i+1 < len(stmts) {
+			limit = stmts[i+1].Pos().Offset
+		}
+		p.stmt(stmt, limit)
+	}
+	p.commentsBefore(end)
+}
+
+// blankLine separates something starting on source line start from what
+// was printed before it if the source had a blank line between them.
+func (p *printer) blankLine(start int) {
+	if p.lastLine > 0 && start > p.lastLine+1 {
+		p.write("\n")
+	}
+}
+
+// commentsBefore prints the pending comments that start before offset,
+// each on its own line.
+func (p *printer) commentsBefore(offset int) {
+	for len(p.comments) > 0 && p.comments[0].Span.StartOffset < offset {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+		p.blankLine(c.Span.StartLine)
+		p.line()
+		p.write(c.Text, "\n")
+		p.lastLine = c.Span.EndLine
+	}
+}
+
+// trailingComments appends to the current line the pending comments that
+// start before limit and either sit inside the statement that just ended
+// at end, where they cannot be placed exactly, or follow it on its line.
+func (p *printer) trailingComments(end ast.Position, limit int) {
+	for len(p.comments) > 0 {
+		c := p.comments[0]
+		if c.Span.StartOffset >= limit || (c.Span.StartOffset >= end.Offset && c.Span.StartLine != end.Line) {
+			return
+		}
+		p.comments = p.comments[1:]
+		p.write(" ", c.Text)
+		p.lastLine = max(p.lastLine, c.Span.EndLine)
+	}
+}
+
+// commentsInside places the pending comments that start before offset in
+// the statement being printed, after what has been written so far.
+func (p *printer) commentsInside(offset int) {
+	for len(p.comments) > 0 && p.comments[0].Span.StartOffset < min(offset, p.end) {
+		p.inlineComment(p.comments[0])
+		p.comments = p.comments[1:]
+	}
+}
+
+// commentsAfter places the pending comments in the statement being printed
+// that follow offset with only white space before them, so that they stay
+// after the token ending there rather than move past the next one.
+func (p *printer) commentsAfter(offset int) {
+	for p.original != "" && len(p.comments) > 0 {
+		c := p.comments[0]
+		if c.Span.StartOffset >= p.end || c.Span.StartOffset < offset || strings.TrimSpace(p.original[offset:c.Span.StartOffset]) != "" {
+			return
+		}
+		p.inlineComment(c)
+		p.comments = p.comments[1:]
+		offset = c.Span.EndOffset
+	}
+}
+
+// inlineComment writes a comment from inside a statement. A line comment
+// ends its line, so the statement carries on on the next one.
+func (p *printer) inlineComment(c Comment) {
+	line := strings.HasPrefix(c.Text, "//")
+	glued := " \n([" // what a block comment may follow without a space
+	if line {
+		glued = " \n"
+	}
+	if s := p.sb.String(); !p.lineBreak && !p.space && s != "" && strings.IndexByte(glued, s[len(s)-1]) < 0 {
+		p.write(" ")
+	}
+	p.write(c.Text)
+	p.lineBreak, p.space = line, !line
+}
+
+func docOf(stmt ast.Stmt) string {
+	switch s := stmt.(type) {
+	case *ast.FunctionStmt:
+		return s.Doc
+	case *ast.ClassStmt:
+		return s.Doc
+	case *ast.InterfaceStmt:
+		return s.Doc
+	case *ast.EnumStmt:
+		return s.Doc
+	case *ast.DataStmt:
+		return s.Doc
+	}
+	return ""
+}
+
+func (p *printer) doc(doc string) {
+	if doc == "" || p.withComments {
+		return
+	}
+	for _, text := range strings.Split(doc, "\n") {
+		p.line()
+		if text == "" {
+			p.write("///\n")
+		} else {
+			p.write("/// ", text, "\n")
+		}
+	}
+}
+
+// stmt prints stmt on its own line or lines. Comments before limit that
+// are left once it is printed go on its last line.
+func (p *printer) stmt(stmt ast.Stmt, limit int) {
+	p.doc(docOf(stmt))
+	p.line()
+	end := p.end
+	p.end = stmt.End().Offset
+	p.stmtBody(stmt)
+	p.end, p.lineBreak, p.space = end, false, false
+	p.lastLine = stmt.End().Line
+	p.trailingComments(stmt.End(), limit)
+	p.write("\n")
+}
+
+// stmtBody prints stmt without indentation or the final newline.
+func (p *printer) stmtBody(stmt ast.Stmt) {
+	switch s := stmt.(type) {
+	case *ast.BlockStmt:
+		p.block(s)
+	case *ast.BreakStmt:
+		p.write("break")
+	case *ast.ContinueStmt:
+		p.write("continue")
+	case *ast.ExpressionStmt:
+		p.expr(s.Expression, precLowest)
+	case *ast.ReturnStmt:
+		p.write("return")
+		if s.Value != nil {
+			p.write(" ")
+			p.expr(s.Value, precLowest)
+		}
+	case *ast.ThrowStmt:
+		p.write("throw ")
+		p.expr(s.Value, precLowest)
+
+	case *ast.TryStmt:
+		p.write("try ")
+		p.block(s.TryBlock)
+		if s.CatchBlock != nil {
+			p.write(" catch ")
+			if s.CatchVarName != "" {
+				p.write(s.CatchVarName, " ")
+			}
+			p.block(s.CatchBlock)
+		}
+		if s.FinallyBlock != nil {
+			p.write(" finally ")
+			p.block(s.FinallyBlock)
+		}
+
+	case *ast.IfStmt:
+		p.write("if ")
+		p.expr(s.Condition, precLowest)
+		p.write(" ")
+		p.block(s.ThenBlock)
+		if s.ElseBlock != nil {
+			p.write(" else ")
+			p.stmtBody(s.ElseBlock)
+		}
+	case *ast.WhileStmt:
+		p.write("while ")
+		p.expr(s.Condition, precLowest)
+		p.write(" ")
+		p.block(s.Body)
+	case *ast.ForStmt:
+		p.write("for ")
+		if s.Init != nil {
+			p.stmtBody(s.Init)
+		}
+		p.write(";")
+		if s.Condition != nil {
+			p.write(" ")
+			p.expr(s.Condition, precLowest)
+		}
+		p.write(";")
+		if s.Post != nil {
+			p.write(" ")
+			p.stmtBody(s.Post)
+		}
+		p.write(" ")
+		p.block(s.Body)
+
+	case *ast.SwitchCase:
+		p.write("case ")
+		p.exprList(s.CaseExprs)
+		p.write(" ")
+		p.block(s.Body)
+	case *ast.SwitchStmt:
+		p.write("turn ")
+		p.expr(s.Expr, precLowest)
+		p.write(" {\n")
+		p.indent++
+		outer := p.lastLine
+		p.lastLine = 0
+		for i, c := range s.Cases {
+			p.commentsBefore(c.Pos().Offset)
+			limit := s.End().Offset
+			if i+1 < len(s.Cases) {
+				limit = s.Cases[i+1].Pos().Offset
+			} else if s.Default != nil {
+				limit = s.Default.Pos().Offset
+			}
+			p.stmt(c, limit)
+		}
+		if s.Default != nil {
+			p.commentsBefore(s.Default.Pos().Offset)
+			p.line()
+			p.write("default ")
+			p.block(s.Default)
+			p.lastLine = s.Default.End().Line
+			p.trailingComments(s.Default.End(), s.End().Offset)
+			p.write("\n")
+		}
+		p.commentsBefore(s.End().Offset)
+		p.lastLine = outer
+		p.indent--
+		p.line()
+		p.write("}")
+
+	case *ast.ValStmt:
+		p.modifiers(s.Modifiers)
+		p.variable("val", s.Name, s.DeclaredType, s.Initializer)
+	case *ast.LetStmt:
+		p.modifiers(s.Modifiers)
+		p.variable("let", s.Name, s.DeclaredType, s.Initializer)
+	case *ast.GlobalStmt:
+		p.variable("global", s.Name, s.DeclaredType, s.Initializer)
+
+	case *ast.FunctionStmt:
+		p.modifiers(s.Modifiers)
+		if s.Override {
+			p.write("override ")
+		}
+		if s.Async {
+			p.write("async ")
+		}
+		p.write("fun ", s.Name)
+		p.parameters(s.Parameters)
+		if s.ReturnType != nil {
+			p.write(": ", s.ReturnType.TypeName())
+		}
+		if s.Body != nil {
+			p.write(" ")
+			p.block(s.Body)
+		}
+	case *ast.ConstructorStmt:
+		p.write("constructor")
+		p.parameters(s.Parameters)
+		p.write(" ")
+		p.block(s.Body)
+
+	case *ast.ClassStmt:
+		p.modifiers(s.Modifiers)
+		p.write("class ", s.Name)
+		if s.SuperClass != "" {
+			p.write(" extends ", s.SuperClass)
+		}
+		p.write(" ")
+		p.members(s.Members, s.End().Offset)
+	case *ast.InterfaceStmt:
+		p.modifiers(s.Modifiers)
+		p.write("interface ", s.Name, " ")
+		p.members(s.Members, s.End().Offset)
+	case *ast.StructStmt:
+		p.modifiers(s.Modifiers)
+		p.write("struct ", s.Name, " ")
+		p.members(s.Members, s.End().Offset)
+	case *ast.EnumStmt:
+		p.modifiers(s.Modifiers)
+		p.write("enum ", s.Name, " {")
+		if len(s.Elements) > 0 {
+			p.write(" ", strings.Join(s.Elements, ", "), " ")
+		}
+		p.write("}")
+	case *ast.DataStmt:
+		p.modifiers(s.Modifiers)
+		p.write("data ", s.Name)
+		p.parameters(s.Fields)
+
+	case *ast.ImportStmt:
+		p.write("import ", quote(s.Module, '"'))
+	case *ast.ExportStmt:
+		p.write("export ", s.ExportedName)
+	case *ast.BadStmt:
+		p.write("BadStmt")
+	}
+}
+
+func (p *printer) block(b *ast.BlockStmt) {
+	p.members(b.Statements, b.End().Offset)
+}
+
+// members prints a braced statement list whose closing brace ends at
+// offset end. An empty one stays on one line.
+func (p *printer) members(stmts []ast.Stmt, end int) {
+	if end == 0 {
+		end = math.MaxInt
+	}
+	if len(stmts) == 0 && (len(p.comments) == 0 || p.comments[0].Span.StartOffset >= end) {
+		p.write("{}")
+		return
+	}
+	p.write("{\n")
+	p.indent++
+	outer := p.lastLine
+	p.lastLine = 0
+	p.stmts(stmts, end)
+	p.lastLine = outer
+	p.indent--
+	p.line()
+	p.write("}")
+}
+
+func (p *printer) modifiers(m ast.Modifier) {
+	if m != ast.ModifierNone {
+		p.write(m.String(), " ")
+	}
+}
+
+func (p *printer) variable(keyword, name string, typ ast.Type, initializer ast.Expr) {
+	p.write(keyword, " ", name)
+	if typ != nil {
+		p.write(": ", typ.TypeName())
+	}
+	if initializer != nil {
+		p.write(" = ")
+		p.expr(initializer, precLowest)
+	}
+}
+
+func (p *printer) parameters(params []ast.Parameter) {
+	p.write("(")
+	for i, param := range params {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.commentsInside(param.Pos().Offset)
+		p.write(param.Name)
+		if param.Type != nil {
+			p.write(": ", param.Type.TypeName())
+		}
+		p.commentsAfter(param.End().Offset)
+	}
+	p.write(")")
+}
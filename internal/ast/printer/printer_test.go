@@ -0,0 +1,180 @@
+package printer_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/ast/printer"
+	"dotFun/internal/lexer"
+	"dotFun/internal/parser"
+)
+
+func parse(t *testing.T, src string) []ast.Stmt {
+	t.Helper()
+	tokens, err := lexer.NewLexer(src).Lex()
+	if err != nil {
+		t.Fatalf("lexing %q: %v", src, err)
+	}
+	statements, err := parser.NewParser(tokens).Parse()
+	if err != nil {
+		t.Fatalf("parsing %q: %v", src, err)
+	}
+	return statements
+}
+
+var spanLine = regexp.MustCompile(`(?m)^\s*Span: .*\n`)
+
+// dump prints statements as a tree without their spans.
+func dump(statements []ast.Stmt) string {
+	var buf bytes.Buffer
+	ast.Fprint(&buf, statements)
+	return spanLine.ReplaceAllString(buf.String(), "")
+}
+
+// ungroup replaces each GroupingExpr in statements with the expression it
+// holds, as the printer leaves out the parentheses precedence does not
+// need.
+func ungroup(statements []ast.Stmt) []ast.Stmt {
+	out := make([]ast.Stmt, len(statements))
+	for i, stmt := range statements {
+		out[i] = ast.Rewrite(stmt, func(n ast.Node) ast.Node {
+			if g, ok := n.(*ast.GroupingExpr); ok {
+				return g.Expression
+			}
+			return n
+		}).(ast.Stmt)
+	}
+	return out
+}
+
+// roundTrip checks that printing the statements parsed from src gives
+// source that parses to the same statements, but for parentheses, and
+// that printing those again gives the same source.
+func roundTrip(t *testing.T, src string) {
+	t.Helper()
+	statements := parse(t, src)
+	printed := printer.Source(statements)
+	reparsed := parse(t, printed)
+	if got, want := dump(ungroup(reparsed)), dump(ungroup(statements)); got != want {
+		t.Errorf("%q printed as %q, which parses differently:\n%s\nwant\n%s", src, printed, got, want)
+		return
+	}
+	if again := printer.Source(reparsed); again != printed {
+		t.Errorf("%q printed as %q, then as %q", src, printed, again)
+	}
+}
+
+// kinds are the statement and expression nodes the corpus must use.
+var kinds = []ast.Node{
+	&ast.IntLiteral{}, &ast.FloatLiteral{}, &ast.StringLiteral{}, &ast.InterpolatedString{},
+	&ast.CharLiteral{}, &ast.BoolLiteral{}, &ast.NilLiteral{}, &ast.ArrayLiteral{},
+	&ast.AssignExpr{}, &ast.MemberAssignExpr{}, &ast.BinaryExpr{}, &ast.CallExpr{},
+	&ast.GroupingExpr{}, &ast.InstanceOfExpr{}, &ast.LambdaExpr{}, &ast.LogicalExpr{},
+	&ast.NewExpr{}, &ast.PostfixUnaryExpr{}, &ast.SuperExpr{}, &ast.ThisExpr{},
+	&ast.UnaryExpr{}, &ast.VariableExpr{}, &ast.MemberExpr{}, &ast.RangeExpr{},
+
+	&ast.BlockStmt{}, &ast.BreakStmt{}, &ast.ContinueStmt{}, &ast.ExpressionStmt{},
+	&ast.ReturnStmt{}, &ast.ThrowStmt{}, &ast.TryStmt{}, &ast.IfStmt{},
+	&ast.WhileStmt{}, &ast.ForStmt{}, &ast.SwitchStmt{}, &ast.ValStmt{},
+	&ast.LetStmt{}, &ast.GlobalStmt{}, &ast.FunctionStmt{}, &ast.ClassStmt{},
+	&ast.ConstructorStmt{}, &ast.InterfaceStmt{}, &ast.StructStmt{}, &ast.EnumStmt{},
+	&ast.DataStmt{}, &ast.ImportStmt{}, &ast.ExportStmt{},
+}
+
+func TestRoundTripCorpus(t *testing.T) {
+	data, err := os.ReadFile("../../parser/testdata/corpus.fun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(data)
+	roundTrip(t, src)
+
+	// Literals spelled as in the source must parse to the same values.
+	statements := parse(t, src)
+	original := (&printer.Config{Original: src}).Source(statements)
+	if got, want := dump(ungroup(parse(t, original))), dump(ungroup(statements)); got != want {
+		t.Errorf("printing with the original literals changed the program:\n%s", original)
+	}
+
+	used := map[string]bool{}
+	for _, stmt := range parse(t, src) {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			used[fmt.Sprintf("%T", n)] = true
+			return true
+		})
+	}
+	for _, kind := range kinds {
+		if name := fmt.Sprintf("%T", kind); !used[name] {
+			t.Errorf("the corpus has no %s", name)
+		}
+	}
+}
+
+func TestRoundTripExpressions(t *testing.T) {
+	for _, src := range []string{
+		// Unary operators and '**'.
+		"-2 ** 2", "(-2) ** 2", "2 ** -1", "2 ** 3 ** 2", "(2 ** 3) ** 2",
+		"-x ** -y", "~-x", "!!a", "not not a", "not -a",
+		// Spacing that must not fuse tokens.
+		"- -x", "-(-x)", "a - -b", "a - (-b)", "-(x++)", "x++ - 1",
+		"-1", "- 1", "1 - -1", "-1.5 ** 2",
+		// Grouping and associativity.
+		"a - (b - c)", "(a - b) - c", "a / (b * c)", "a ?? (b ?? c)", "(a ?? b) ?? c",
+		"a = b = c", "a += b -= c", "(a and b) or c", "a and (b or c)",
+		"a.b = c.d = e", "f(x).y *= 2", "(a.b = c) + 1", "(a).b = c",
+		"not a and b", "not (a and b)", "a == (b == c)", "(a < b) == c",
+		"1..10", "1..<n + 1", "(1..2) == r", "a | b ^ c & d", "(a | b) & c",
+		"a << b >> c", "a << (b >> c)", "p instanceof Point == true",
+		// Calls, members and lambdas.
+		"f(x => x + 1, (a, b) -> a * b)", "f()(1)", "(x => x)(1)", "a.b?.c(d).e",
+		"new Point(1, 2).x", "(new Point()).x", "super.f(this)",
+		"let f = x => y => x + y", "let g = () => 1", "let h = (a) -> (a)",
+		// Literals that need escaping.
+		`"quote \" backslash \\ dollar \$ tab \t newline \n"`, `'\''`, `'\\'`,
+		`"a ${b} c $d e ${f(g)}"`, `"\u{1F600}"`, "1.0", "1e100", "0.1",
+		"[]", "[1, [2, 3]]",
+	} {
+		roundTrip(t, src)
+	}
+}
+
+// TestParentheses checks that the parentheses precedence needs are
+// printed, and only those.
+func TestParentheses(t *testing.T) {
+	for _, tt := range []struct{ src, want string }{
+		{"((a)) + (b)", "a + b"},
+		{"(a - b) - c", "a - b - c"},
+		{"a - (b - c)", "a - (b - c)"},
+		{"(a * b) + (c * d)", "a * b + c * d"},
+		{"(a + b) * c", "(a + b) * c"},
+		{"((a + b)) * c", "(a + b) * c"},
+		{"(-2) ** 2", "(-2) ** 2"},
+		{"-(2 ** 2)", "-2 ** 2"},
+		{"2 ** (3 ** 2)", "2 ** 3 ** 2"},
+		{"(2 ** 3) ** 2", "(2 ** 3) ** 2"},
+		{"-(-x)", "- -x"},
+		{"not (a and b)", "not (a and b)"},
+		{"(not a) and b", "not a and b"},
+		{"(a < b) == c", "a < b == c"},
+		{"((1..2)) == r", "1..2 == r"},
+		{"1..(2 == r)", "1..(2 == r)"},
+		{"(a.b)(c)", "a.b(c)"},
+		{"(x => x)(1)", "(x -> x)(1)"},
+		{"let h = (a) -> (a)", "let h = a -> a"},
+		{"a = (b = c)", "a = b = c"},
+		{"(a = b) + 1", "(a = b) + 1"},
+		{`"${(a)}"`, `"${a}"`},
+	} {
+		statements := parse(t, tt.src)
+		got := strings.TrimSuffix(printer.Source(statements), "\n")
+		if got != tt.want {
+			t.Errorf("%q printed as %q, want %q", tt.src, got, tt.want)
+		}
+		roundTrip(t, tt.src)
+	}
+}
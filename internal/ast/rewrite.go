@@ -0,0 +1,344 @@
+package ast
+
+// Rewrite replaces nodes in the tree rooted at node, bottom-up: the
+// children of each node are rewritten first, then f is called with the
+// node and its result takes the node's place. f returns its argument to
+// keep a node. A replacement must fit where the original was: an Expr for
+// an Expr, a *BlockStmt for a block, and so on. The input is not modified:
+// a node whose children change is copied, and subtrees left alone are
+// shared with the result. Parameters are values, not pointers, so f is not
+// called for them.
+func Rewrite(node Node, f func(Node) Node) Node {
+	switch n := node.(type) {
+	case *InterpolatedString:
+		parts, partsChanged := rewriteExprs(n.Parts, f)
+		if partsChanged {
+			n = clone(n)
+			n.Parts = parts
+			node = n
+		}
+	case *ArrayLiteral:
+		elements, elementsChanged := rewriteExprs(n.Elements, f)
+		if elementsChanged {
+			n = clone(n)
+			n.Elements = elements
+			node = n
+		}
+	case *AssignExpr:
+		name := rewriteIdent(n.Name, f)
+		value := rewriteExpr(n.Value, f)
+		if name != n.Name || value != n.Value {
+			n = clone(n)
+			n.Name, n.Value = name, value
+			node = n
+		}
+	case *MemberAssignExpr:
+		object := rewriteExpr(n.Object, f)
+		property := rewriteIdent(n.Property, f)
+		value := rewriteExpr(n.Value, f)
+		if object != n.Object || property != n.Property || value != n.Value {
+			n = clone(n)
+			n.Object, n.Property, n.Value = object, property, value
+			node = n
+		}
+	case *BinaryExpr:
+		left := rewriteExpr(n.Left, f)
+		right := rewriteExpr(n.Right, f)
+		if left != n.Left || right != n.Right {
+			n = clone(n)
+			n.Left, n.Right = left, right
+			node = n
+		}
+	case *CallExpr:
+		callee := rewriteExpr(n.Callee, f)
+		arguments, argumentsChanged := rewriteExprs(n.Arguments, f)
+		if callee != n.Callee || argumentsChanged {
+			n = clone(n)
+			n.Callee, n.Arguments = callee, arguments
+			node = n
+		}
+	case *GroupingExpr:
+		expression := rewriteExpr(n.Expression, f)
+		if expression != n.Expression {
+			n = clone(n)
+			n.Expression = expression
+			node = n
+		}
+	case *InstanceOfExpr:
+		object := rewriteExpr(n.Object, f)
+		if object != n.Object {
+			n = clone(n)
+			n.Object = object
+			node = n
+		}
+	case *LambdaExpr:
+		body := rewriteExpr(n.Body, f)
+		if body != n.Body {
+			n = clone(n)
+			n.Body = body
+			node = n
+		}
+	case *LogicalExpr:
+		left := rewriteExpr(n.Left, f)
+		right := rewriteExpr(n.Right, f)
+		if left != n.Left || right != n.Right {
+			n = clone(n)
+			n.Left, n.Right = left, right
+			node = n
+		}
+	case *NewExpr:
+		args, argsChanged := rewriteExprs(n.Args, f)
+		if argsChanged {
+			n = clone(n)
+			n.Args = args
+			node = n
+		}
+	case *PostfixUnaryExpr:
+		operand := rewriteExpr(n.Operand, f)
+		if operand != n.Operand {
+			n = clone(n)
+			n.Operand = operand
+			node = n
+		}
+	case *SuperExpr:
+		method := rewriteExpr(n.Method, f)
+		if method != n.Method {
+			n = clone(n)
+			n.Method = method
+			node = n
+		}
+	case *UnaryExpr:
+		right := rewriteExpr(n.Right, f)
+		if right != n.Right {
+			n = clone(n)
+			n.Right = right
+			node = n
+		}
+	case *VariableExpr:
+		name := rewriteIdent(n.Name, f)
+		if name != n.Name {
+			n = clone(n)
+			n.Name = name
+			node = n
+		}
+	case *MemberExpr:
+		object := rewriteExpr(n.Object, f)
+		property := rewriteIdent(n.Property, f)
+		if object != n.Object || property != n.Property {
+			n = clone(n)
+			n.Object, n.Property = object, property
+			node = n
+		}
+	case *RangeExpr:
+		start := rewriteExpr(n.Start, f)
+		stop := rewriteExpr(n.Stop, f)
+		if start != n.Start || stop != n.Stop {
+			n = clone(n)
+			n.Start, n.Stop = start, stop
+			node = n
+		}
+
+	case *BlockStmt:
+		statements, statementsChanged := rewriteStmts(n.Statements, f)
+		if statementsChanged {
+			n = clone(n)
+			n.Statements = statements
+			node = n
+		}
+	case *ExpressionStmt:
+		expression := rewriteExpr(n.Expression, f)
+		if expression != n.Expression {
+			n = clone(n)
+			n.Expression = expression
+			node = n
+		}
+	case *ReturnStmt:
+		value := rewriteExpr(n.Value, f)
+		if value != n.Value {
+			n = clone(n)
+			n.Value = value
+			node = n
+		}
+	case *ThrowStmt:
+		value := rewriteExpr(n.Value, f)
+		if value != n.Value {
+			n = clone(n)
+			n.Value = value
+			node = n
+		}
+	case *TryStmt:
+		tryBlock := rewriteBlock(n.TryBlock, f)
+		catchBlock := rewriteBlock(n.CatchBlock, f)
+		finallyBlock := rewriteBlock(n.FinallyBlock, f)
+		if tryBlock != n.TryBlock || catchBlock != n.CatchBlock || finallyBlock != n.FinallyBlock {
+			n = clone(n)
+			n.TryBlock, n.CatchBlock, n.FinallyBlock = tryBlock, catchBlock, finallyBlock
+			node = n
+		}
+	case *IfStmt:
+		condition := rewriteExpr(n.Condition, f)
+		thenBlock := rewriteBlock(n.ThenBlock, f)
+		elseBlock := rewriteStmt(n.ElseBlock, f)
+		if condition != n.Condition || thenBlock != n.ThenBlock || elseBlock != n.ElseBlock {
+			n = clone(n)
+			n.Condition, n.ThenBlock, n.ElseBlock = condition, thenBlock, elseBlock
+			node = n
+		}
+	case *WhileStmt:
+		condition := rewriteExpr(n.Condition, f)
+		body := rewriteBlock(n.Body, f)
+		if condition != n.Condition || body != n.Body {
+			n = clone(n)
+			n.Condition, n.Body = condition, body
+			node = n
+		}
+	case *ForStmt:
+		initial := rewriteStmt(n.Init, f)
+		condition := rewriteExpr(n.Condition, f)
+		post := rewriteStmt(n.Post, f)
+		body := rewriteBlock(n.Body, f)
+		if initial != n.Init || condition != n.Condition || post != n.Post || body != n.Body {
+			n = clone(n)
+			n.Init, n.Condition, n.Post, n.Body = initial, condition, post, body
+			node = n
+		}
+	case *SwitchCase:
+		caseExprs, caseExprsChanged := rewriteExprs(n.CaseExprs, f)
+		body := rewriteBlock(n.Body, f)
+		if caseExprsChanged || body != n.Body {
+			n = clone(n)
+			n.CaseExprs, n.Body = caseExprs, body
+			node = n
+		}
+	case *SwitchStmt:
+		expr := rewriteExpr(n.Expr, f)
+		cases, casesChanged := rewriteCases(n.Cases, f)
+		defaultBlock := rewriteBlock(n.Default, f)
+		if expr != n.Expr || casesChanged || defaultBlock != n.Default {
+			n = clone(n)
+			n.Expr, n.Cases, n.Default = expr, cases, defaultBlock
+			node = n
+		}
+	case *ValStmt:
+		initializer := rewriteExpr(n.Initializer, f)
+		if initializer != n.Initializer {
+			n = clone(n)
+			n.Initializer = initializer
+			node = n
+		}
+	case *LetStmt:
+		initializer := rewriteExpr(n.Initializer, f)
+		if initializer != n.Initializer {
+			n = clone(n)
+			n.Initializer = initializer
+			node = n
+		}
+	case *GlobalStmt:
+		initializer := rewriteExpr(n.Initializer, f)
+		if initializer != n.Initializer {
+			n = clone(n)
+			n.Initializer = initializer
+			node = n
+		}
+	case *FunctionStmt:
+		body := rewriteBlock(n.Body, f)
+		if body != n.Body {
+			n = clone(n)
+			n.Body = body
+			node = n
+		}
+	case *ClassStmt:
+		members, membersChanged := rewriteStmts(n.Members, f)
+		if membersChanged {
+			n = clone(n)
+			n.Members = members
+			node = n
+		}
+	case *ConstructorStmt:
+		body := rewriteBlock(n.Body, f)
+		if body != n.Body {
+			n = clone(n)
+			n.Body = body
+			node = n
+		}
+	case *InterfaceStmt:
+		members, membersChanged := rewriteStmts(n.Members, f)
+		if membersChanged {
+			n = clone(n)
+			n.Members = members
+			node = n
+		}
+	case *StructStmt:
+		members, membersChanged := rewriteStmts(n.Members, f)
+		if membersChanged {
+			n = clone(n)
+			n.Members = members
+			node = n
+		}
+	}
+	return f(node)
+}
+
+func clone[T any](n *T) *T {
+	c := *n
+	return &c
+}
+
+func rewriteExpr(e Expr, f func(Node) Node) Expr {
+	if e == nil {
+		return nil
+	}
+	return Rewrite(e, f).(Expr)
+}
+
+func rewriteStmt(s Stmt, f func(Node) Node) Stmt {
+	if s == nil {
+		return nil
+	}
+	return Rewrite(s, f).(Stmt)
+}
+
+func rewriteBlock(b *BlockStmt, f func(Node) Node) *BlockStmt {
+	if b == nil {
+		return nil
+	}
+	return Rewrite(b, f).(*BlockStmt)
+}
+
+func rewriteIdent(id *Identifier, f func(Node) Node) *Identifier {
+	if id == nil {
+		return nil
+	}
+	return Rewrite(id, f).(*Identifier)
+}
+
+// rewriteList rewrites each element of list, returning a new slice and
+// true if any of them changed, or list itself and false.
+func rewriteList[T comparable](list []T, rewrite func(T) T) ([]T, bool) {
+	var out []T
+	for i, x := range list {
+		if y := rewrite(x); y != x && out == nil {
+			out = make([]T, len(list))
+			copy(out, list[:i])
+			out[i] = y
+		} else if out != nil {
+			out[i] = y
+		}
+	}
+	if out == nil {
+		return list, false
+	}
+	return out, true
+}
+
+func rewriteExprs(exprs []Expr, f func(Node) Node) ([]Expr, bool) {
+	return rewriteList(exprs, func(e Expr) Expr { return rewriteExpr(e, f) })
+}
+
+func rewriteStmts(stmts []Stmt, f func(Node) Node) ([]Stmt, bool) {
+	return rewriteList(stmts, func(s Stmt) Stmt { return rewriteStmt(s, f) })
+}
+
+func rewriteCases(cases []*SwitchCase, f func(Node) Node) ([]*SwitchCase, bool) {
+	return rewriteList(cases, func(c *SwitchCase) *SwitchCase { return Rewrite(c, f).(*SwitchCase) })
+}
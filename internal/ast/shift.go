@@ -0,0 +1,55 @@
+package ast
+
+import "reflect"
+
+// Shift returns a deep copy of stmt with every span moved by offset bytes
+// and lines lines, so that a subtree can be reused after an edit earlier in
+// the source.
+func Shift(stmt Stmt, offset, lines int) Stmt {
+	return shiftValue(reflect.ValueOf(stmt), offset, lines).Interface().(Stmt)
+}
+
+func shiftValue(v reflect.Value, offset, lines int) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(shiftValue(v.Elem(), offset, lines))
+		return c
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(shiftValue(v.Elem(), offset, lines))
+		return c
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(shiftValue(v.Index(i), offset, lines))
+		}
+		return c
+
+	case reflect.Struct:
+		if span, ok := v.Interface().(Span); ok {
+			return reflect.ValueOf(span.Shift(offset, lines))
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				c.Field(i).Set(shiftValue(v.Field(i), offset, lines))
+			}
+		}
+		return c
+	}
+	return v
+}
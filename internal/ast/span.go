@@ -0,0 +1,9 @@
+package ast
+
+import "dotFun/internal/lexer"
+
+// Span is embedded in every node and records the source range it was
+// parsed from.
+type Span = lexer.Span
+
+type Position = lexer.Position
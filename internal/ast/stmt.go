@@ -1,7 +1,9 @@
 package ast
 
 type Stmt interface {
+	Node
 	stmtNode()
+	Accept(visitor StmtVisitor[any]) any
 }
 
 type Modifier int
@@ -26,39 +28,69 @@ func ModifierFromString(s string) Modifier {
 	}
 }
 
+func (m Modifier) String() string {
+	switch m {
+	case ModifierPublic:
+		return "public"
+	case ModifierProtected:
+		return "protected"
+	case ModifierPrivate:
+		return "private"
+	default:
+		return "none"
+	}
+}
+
+// BadStmt stands in for the tokens the parser skipped after a syntax
+// error in a statement or declaration.
+type BadStmt struct {
+	Span
+}
+
+func (bs *BadStmt) stmtNode() {}
+
 type BlockStmt struct {
+	Span
 	Statements []Stmt
 }
 
 func (bs *BlockStmt) stmtNode() {}
 
-type BreakStmt struct{}
+type BreakStmt struct {
+	Span
+}
 
 func (bs *BreakStmt) stmtNode() {}
 
-type ContinueStmt struct{}
+type ContinueStmt struct {
+	Span
+}
 
 func (cs *ContinueStmt) stmtNode() {}
 
 type ExpressionStmt struct {
+	Span
 	Expression Expr
 }
 
 func (es *ExpressionStmt) stmtNode() {}
 
 type ReturnStmt struct {
+	Span
 	Value Expr
 }
 
 func (rs *ReturnStmt) stmtNode() {}
 
 type ThrowStmt struct {
+	Span
 	Value Expr
 }
 
 func (ts *ThrowStmt) stmtNode() {}
 
 type TryStmt struct {
+	Span
 	TryBlock     *BlockStmt
 	CatchVarName string
 	CatchBlock   *BlockStmt
@@ -68,6 +100,7 @@ type TryStmt struct {
 func (ts *TryStmt) stmtNode() {}
 
 type IfStmt struct {
+	Span
 	Condition Expr
 	ThenBlock *BlockStmt
 	ElseBlock Stmt
@@ -76,6 +109,7 @@ type IfStmt struct {
 func (ifs *IfStmt) stmtNode() {}
 
 type WhileStmt struct {
+	Span
 	Condition Expr
 	Body      *BlockStmt
 }
@@ -83,6 +117,7 @@ type WhileStmt struct {
 func (ws *WhileStmt) stmtNode() {}
 
 type ForStmt struct {
+	Span
 	Init      Stmt
 	Condition Expr
 	Post      Stmt
@@ -92,6 +127,7 @@ type ForStmt struct {
 func (fs *ForStmt) stmtNode() {}
 
 type SwitchCase struct {
+	Span
 	CaseExprs []Expr
 	Body      *BlockStmt
 }
@@ -99,6 +135,7 @@ type SwitchCase struct {
 func (sc *SwitchCase) stmtNode() {}
 
 type SwitchStmt struct {
+	Span
 	Expr    Expr
 	Cases   []*SwitchCase
 	Default *BlockStmt
@@ -107,6 +144,7 @@ type SwitchStmt struct {
 func (ss *SwitchStmt) stmtNode() {}
 
 type ValStmt struct {
+	Span
 	Name         string
 	DeclaredType Type
 	Initializer  Expr
@@ -116,6 +154,7 @@ type ValStmt struct {
 func (vs *ValStmt) stmtNode() {}
 
 type LetStmt struct {
+	Span
 	Name         string
 	DeclaredType Type
 	Initializer  Expr
@@ -125,6 +164,7 @@ type LetStmt struct {
 func (ls *LetStmt) stmtNode() {}
 
 type GlobalStmt struct {
+	Span
 	Name         string
 	DeclaredType Type
 	Initializer  Expr
@@ -133,6 +173,9 @@ type GlobalStmt struct {
 func (gs *GlobalStmt) stmtNode() {}
 
 type FunctionStmt struct {
+	Span
+	// Doc is the text of the doc comment before the declaration.
+	Doc        string
 	Name       string
 	Parameters []Parameter
 	ReturnType Type
@@ -145,11 +188,14 @@ type FunctionStmt struct {
 func (fs *FunctionStmt) stmtNode() {}
 
 type Parameter struct {
+	Span
 	Name string
 	Type Type
 }
 
 type ClassStmt struct {
+	Span
+	Doc        string
 	Name       string
 	SuperClass string
 	Modifiers  Modifier
@@ -159,6 +205,7 @@ type ClassStmt struct {
 func (cs *ClassStmt) stmtNode() {}
 
 type ConstructorStmt struct {
+	Span
 	Parameters []Parameter
 	Body       *BlockStmt
 }
@@ -166,6 +213,8 @@ type ConstructorStmt struct {
 func (cs *ConstructorStmt) stmtNode() {}
 
 type InterfaceStmt struct {
+	Span
+	Doc       string
 	Name      string
 	Modifiers Modifier
 	Members   []Stmt
@@ -174,6 +223,7 @@ type InterfaceStmt struct {
 func (is *InterfaceStmt) stmtNode() {}
 
 type StructStmt struct {
+	Span
 	Name      string
 	Modifiers Modifier
 	Members   []Stmt
@@ -182,6 +232,8 @@ type StructStmt struct {
 func (ss *StructStmt) stmtNode() {}
 
 type EnumStmt struct {
+	Span
+	Doc       string
 	Name      string
 	Modifiers Modifier
 	Elements  []string
@@ -190,6 +242,8 @@ type EnumStmt struct {
 func (es *EnumStmt) stmtNode() {}
 
 type DataStmt struct {
+	Span
+	Doc       string
 	Name      string
 	Modifiers Modifier
 	Fields    []Parameter
@@ -198,12 +252,14 @@ type DataStmt struct {
 func (ds *DataStmt) stmtNode() {}
 
 type ImportStmt struct {
+	Span
 	Module string
 }
 
 func (is *ImportStmt) stmtNode() {}
 
 type ExportStmt struct {
+	Span
 	ExportedName string
 }
 
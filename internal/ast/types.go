@@ -18,6 +18,7 @@ func (p PrimitiveType) String() string {
 const (
 	IntType    PrimitiveType = "Int"
 	StringType PrimitiveType = "String"
+	CharType   PrimitiveType = "Char"
 	FloatType  PrimitiveType = "Float"
 	BoolType   PrimitiveType = "Bool"
 	AnyType    PrimitiveType = "Any"
@@ -34,3 +35,15 @@ func (a ArrayType) TypeName() string {
 func (a *ArrayType) String() string {
 	return a.TypeName()
 }
+
+type NamedType struct {
+	Name string
+}
+
+func (n *NamedType) TypeName() string {
+	return n.Name
+}
+
+func (n *NamedType) String() string {
+	return n.TypeName()
+}
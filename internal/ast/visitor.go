@@ -1,222 +1,389 @@
 package ast
 
-type ExprVisitor interface {
-	VisitIntLiteral(*IntLiteral) any
-	VisitFloatLiteral(*FloatLiteral) any
-	VisitStringLiteral(*StringLiteral) any
-	VisitBoolLiteral(*BoolLiteral) any
-	VisitArrayLiteral(*ArrayLiteral) any
-	VisitAssignExpr(*AssignExpr) any
-	VisitBinaryExpr(*BinaryExpr) any
-	VisitCallExpr(*CallExpr) any
-	VisitGroupingExpr(*GroupingExpr) any
-	VisitInstanceOfExpr(*InstanceOfExpr) any
-	VisitLambdaExpr(*LambdaExpr) any
-	VisitLogicalExpr(*LogicalExpr) any
-	VisitNewExpr(*NewExpr) any
-	VisitPostfixUnaryExpr(*PostfixUnaryExpr) any
-	VisitSuperExpr(*SuperExpr) any
-	VisitThisExpr(*ThisExpr) any
-	VisitUnaryExpr(*UnaryExpr) any
-	VisitVariableExpr(*VariableExpr) any
-	VisitMemberExpr(*MemberExpr) any
-}
-
-type StmtVisitor interface {
-	VisitBlockStmt(*BlockStmt) any
-	VisitBreakStmt(*BreakStmt) any
-	VisitContinueStmt(*ContinueStmt) any
-	VisitExpressionStmt(*ExpressionStmt) any
-	VisitReturnStmt(*ReturnStmt) any
-	VisitThrowStmt(*ThrowStmt) any
-	VisitTryStmt(*TryStmt) any
-	VisitIfStmt(*IfStmt) any
-	VisitWhileStmt(*WhileStmt) any
-	VisitForStmt(*ForStmt) any
-	VisitSwitchCase(*SwitchCase) any
-	VisitSwitchStmt(*SwitchStmt) any
-	VisitValStmt(*ValStmt) any
-	VisitLetStmt(*LetStmt) any
-	VisitGlobalStmt(*GlobalStmt) any
-	VisitFunctionStmt(*FunctionStmt) any
-	VisitClassStmt(*ClassStmt) any
-	VisitConstructorStmt(*ConstructorStmt) any
-	VisitInterfaceStmt(*InterfaceStmt) any
-	VisitStructStmt(*StructStmt) any
-	VisitEnumStmt(*EnumStmt) any
-	VisitDataStmt(*DataStmt) any
-	VisitImportStmt(*ImportStmt) any
-	VisitExportStmt(*ExportStmt) any
-}
-
-func (il *IntLiteral) Accept(visitor ExprVisitor) any {
+import "fmt"
+
+// ExprVisitor has one method per expression node, each returning R. Use
+// VisitExpr to dispatch to it.
+type ExprVisitor[R any] interface {
+	VisitIntLiteral(*IntLiteral) R
+	VisitFloatLiteral(*FloatLiteral) R
+	VisitStringLiteral(*StringLiteral) R
+	VisitInterpolatedString(*InterpolatedString) R
+	VisitCharLiteral(*CharLiteral) R
+	VisitBoolLiteral(*BoolLiteral) R
+	VisitNilLiteral(*NilLiteral) R
+	VisitArrayLiteral(*ArrayLiteral) R
+	VisitAssignExpr(*AssignExpr) R
+	VisitMemberAssignExpr(*MemberAssignExpr) R
+	VisitBinaryExpr(*BinaryExpr) R
+	VisitCallExpr(*CallExpr) R
+	VisitGroupingExpr(*GroupingExpr) R
+	VisitInstanceOfExpr(*InstanceOfExpr) R
+	VisitLambdaExpr(*LambdaExpr) R
+	VisitLogicalExpr(*LogicalExpr) R
+	VisitNewExpr(*NewExpr) R
+	VisitPostfixUnaryExpr(*PostfixUnaryExpr) R
+	VisitSuperExpr(*SuperExpr) R
+	VisitThisExpr(*ThisExpr) R
+	VisitUnaryExpr(*UnaryExpr) R
+	VisitVariableExpr(*VariableExpr) R
+	VisitMemberExpr(*MemberExpr) R
+	VisitRangeExpr(*RangeExpr) R
+	VisitIdentifier(*Identifier) R
+	VisitBadExpr(*BadExpr) R
+}
+
+// StmtVisitor is the statement counterpart of ExprVisitor; use VisitStmt to
+// dispatch to it.
+type StmtVisitor[R any] interface {
+	VisitBlockStmt(*BlockStmt) R
+	VisitBreakStmt(*BreakStmt) R
+	VisitContinueStmt(*ContinueStmt) R
+	VisitExpressionStmt(*ExpressionStmt) R
+	VisitReturnStmt(*ReturnStmt) R
+	VisitThrowStmt(*ThrowStmt) R
+	VisitTryStmt(*TryStmt) R
+	VisitIfStmt(*IfStmt) R
+	VisitWhileStmt(*WhileStmt) R
+	VisitForStmt(*ForStmt) R
+	VisitSwitchCase(*SwitchCase) R
+	VisitSwitchStmt(*SwitchStmt) R
+	VisitValStmt(*ValStmt) R
+	VisitLetStmt(*LetStmt) R
+	VisitGlobalStmt(*GlobalStmt) R
+	VisitFunctionStmt(*FunctionStmt) R
+	VisitClassStmt(*ClassStmt) R
+	VisitConstructorStmt(*ConstructorStmt) R
+	VisitInterfaceStmt(*InterfaceStmt) R
+	VisitStructStmt(*StructStmt) R
+	VisitEnumStmt(*EnumStmt) R
+	VisitDataStmt(*DataStmt) R
+	VisitImportStmt(*ImportStmt) R
+	VisitExportStmt(*ExportStmt) R
+	VisitBadStmt(*BadStmt) R
+}
+
+// VisitExpr calls the method of v for the type of expr and returns its
+// result.
+func VisitExpr[R any](v ExprVisitor[R], expr Expr) R {
+	switch n := expr.(type) {
+	case *IntLiteral:
+		return v.VisitIntLiteral(n)
+	case *FloatLiteral:
+		return v.VisitFloatLiteral(n)
+	case *StringLiteral:
+		return v.VisitStringLiteral(n)
+	case *InterpolatedString:
+		return v.VisitInterpolatedString(n)
+	case *CharLiteral:
+		return v.VisitCharLiteral(n)
+	case *BoolLiteral:
+		return v.VisitBoolLiteral(n)
+	case *NilLiteral:
+		return v.VisitNilLiteral(n)
+	case *ArrayLiteral:
+		return v.VisitArrayLiteral(n)
+	case *AssignExpr:
+		return v.VisitAssignExpr(n)
+	case *MemberAssignExpr:
+		return v.VisitMemberAssignExpr(n)
+	case *BinaryExpr:
+		return v.VisitBinaryExpr(n)
+	case *CallExpr:
+		return v.VisitCallExpr(n)
+	case *GroupingExpr:
+		return v.VisitGroupingExpr(n)
+	case *InstanceOfExpr:
+		return v.VisitInstanceOfExpr(n)
+	case *LambdaExpr:
+		return v.VisitLambdaExpr(n)
+	case *LogicalExpr:
+		return v.VisitLogicalExpr(n)
+	case *NewExpr:
+		return v.VisitNewExpr(n)
+	case *PostfixUnaryExpr:
+		return v.VisitPostfixUnaryExpr(n)
+	case *SuperExpr:
+		return v.VisitSuperExpr(n)
+	case *ThisExpr:
+		return v.VisitThisExpr(n)
+	case *UnaryExpr:
+		return v.VisitUnaryExpr(n)
+	case *VariableExpr:
+		return v.VisitVariableExpr(n)
+	case *MemberExpr:
+		return v.VisitMemberExpr(n)
+	case *RangeExpr:
+		return v.VisitRangeExpr(n)
+	case *Identifier:
+		return v.VisitIdentifier(n)
+	case *BadExpr:
+		return v.VisitBadExpr(n)
+	}
+	panic(fmt.Sprintf("ast.VisitExpr: unexpected node %T", expr))
+}
+
+// VisitStmt calls the method of v for the type of stmt and returns its
+// result.
+func VisitStmt[R any](v StmtVisitor[R], stmt Stmt) R {
+	switch n := stmt.(type) {
+	case *BlockStmt:
+		return v.VisitBlockStmt(n)
+	case *BreakStmt:
+		return v.VisitBreakStmt(n)
+	case *ContinueStmt:
+		return v.VisitContinueStmt(n)
+	case *ExpressionStmt:
+		return v.VisitExpressionStmt(n)
+	case *ReturnStmt:
+		return v.VisitReturnStmt(n)
+	case *ThrowStmt:
+		return v.VisitThrowStmt(n)
+	case *TryStmt:
+		return v.VisitTryStmt(n)
+	case *IfStmt:
+		return v.VisitIfStmt(n)
+	case *WhileStmt:
+		return v.VisitWhileStmt(n)
+	case *ForStmt:
+		return v.VisitForStmt(n)
+	case *SwitchCase:
+		return v.VisitSwitchCase(n)
+	case *SwitchStmt:
+		return v.VisitSwitchStmt(n)
+	case *ValStmt:
+		return v.VisitValStmt(n)
+	case *LetStmt:
+		return v.VisitLetStmt(n)
+	case *GlobalStmt:
+		return v.VisitGlobalStmt(n)
+	case *FunctionStmt:
+		return v.VisitFunctionStmt(n)
+	case *ClassStmt:
+		return v.VisitClassStmt(n)
+	case *ConstructorStmt:
+		return v.VisitConstructorStmt(n)
+	case *InterfaceStmt:
+		return v.VisitInterfaceStmt(n)
+	case *StructStmt:
+		return v.VisitStructStmt(n)
+	case *EnumStmt:
+		return v.VisitEnumStmt(n)
+	case *DataStmt:
+		return v.VisitDataStmt(n)
+	case *ImportStmt:
+		return v.VisitImportStmt(n)
+	case *ExportStmt:
+		return v.VisitExportStmt(n)
+	case *BadStmt:
+		return v.VisitBadStmt(n)
+	}
+	panic(fmt.Sprintf("ast.VisitStmt: unexpected node %T", stmt))
+}
+
+// Accept is the untyped form of VisitExpr and VisitStmt, for visitors
+// whose results are of mixed types.
+
+func (il *IntLiteral) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitIntLiteral(il)
 }
 
-func (fl *FloatLiteral) Accept(visitor ExprVisitor) any {
+func (fl *FloatLiteral) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitFloatLiteral(fl)
 }
 
-func (sl *StringLiteral) Accept(visitor ExprVisitor) any {
+func (sl *StringLiteral) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitStringLiteral(sl)
 }
 
-func (bl *BoolLiteral) Accept(visitor ExprVisitor) any {
+func (is *InterpolatedString) Accept(visitor ExprVisitor[any]) any {
+	return visitor.VisitInterpolatedString(is)
+}
+
+func (cl *CharLiteral) Accept(visitor ExprVisitor[any]) any {
+	return visitor.VisitCharLiteral(cl)
+}
+
+func (bl *BoolLiteral) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitBoolLiteral(bl)
 }
 
-func (al *ArrayLiteral) Accept(visitor ExprVisitor) any {
+func (nl *NilLiteral) Accept(visitor ExprVisitor[any]) any {
+	return visitor.VisitNilLiteral(nl)
+}
+
+func (al *ArrayLiteral) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitArrayLiteral(al)
 }
 
-func (ase *AssignExpr) Accept(visitor ExprVisitor) any {
+func (ase *AssignExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitAssignExpr(ase)
 }
 
-func (be *BinaryExpr) Accept(visitor ExprVisitor) any {
+func (mae *MemberAssignExpr) Accept(visitor ExprVisitor[any]) any {
+	return visitor.VisitMemberAssignExpr(mae)
+}
+
+func (be *BinaryExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitBinaryExpr(be)
 }
 
-func (ce *CallExpr) Accept(visitor ExprVisitor) any {
+func (ce *CallExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitCallExpr(ce)
 }
 
-func (ge *GroupingExpr) Accept(visitor ExprVisitor) any {
+func (ge *GroupingExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitGroupingExpr(ge)
 }
 
-func (ie *InstanceOfExpr) Accept(visitor ExprVisitor) any {
+func (ie *InstanceOfExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitInstanceOfExpr(ie)
 }
 
-func (le *LambdaExpr) Accept(visitor ExprVisitor) any {
+func (le *LambdaExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitLambdaExpr(le)
 }
 
-func (le *LogicalExpr) Accept(visitor ExprVisitor) any {
+func (le *LogicalExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitLogicalExpr(le)
 }
 
-func (ne *NewExpr) Accept(visitor ExprVisitor) any {
+func (ne *NewExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitNewExpr(ne)
 }
 
-func (pue *PostfixUnaryExpr) Accept(visitor ExprVisitor) any {
+func (pue *PostfixUnaryExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitPostfixUnaryExpr(pue)
 }
 
-func (se *SuperExpr) Accept(visitor ExprVisitor) any {
+func (se *SuperExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitSuperExpr(se)
 }
 
-func (te *ThisExpr) Accept(visitor ExprVisitor) any {
+func (te *ThisExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitThisExpr(te)
 }
 
-func (ue *UnaryExpr) Accept(visitor ExprVisitor) any {
+func (ue *UnaryExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitUnaryExpr(ue)
 }
 
-func (ve *VariableExpr) Accept(visitor ExprVisitor) any {
+func (ve *VariableExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitVariableExpr(ve)
 }
 
-func (me *MemberExpr) Accept(visitor ExprVisitor) any {
+func (me *MemberExpr) Accept(visitor ExprVisitor[any]) any {
 	return visitor.VisitMemberExpr(me)
 }
 
-func (bs *BlockStmt) Accept(visitor StmtVisitor) any {
+func (re *RangeExpr) Accept(visitor ExprVisitor[any]) any {
+	return visitor.VisitRangeExpr(re)
+}
+
+func (id *Identifier) Accept(visitor ExprVisitor[any]) any {
+	return visitor.VisitIdentifier(id)
+}
+
+func (be *BadExpr) Accept(visitor ExprVisitor[any]) any {
+	return visitor.VisitBadExpr(be)
+}
+
+func (bs *BlockStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitBlockStmt(bs)
 }
 
-func (brs *BreakStmt) Accept(visitor StmtVisitor) any {
+func (brs *BreakStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitBreakStmt(brs)
 }
 
-func (cs *ContinueStmt) Accept(visitor StmtVisitor) any {
+func (cs *ContinueStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitContinueStmt(cs)
 }
 
-func (es *ExpressionStmt) Accept(visitor StmtVisitor) any {
+func (es *ExpressionStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitExpressionStmt(es)
 }
 
-func (rs *ReturnStmt) Accept(visitor StmtVisitor) any {
+func (rs *ReturnStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitReturnStmt(rs)
 }
 
-func (ts *ThrowStmt) Accept(visitor StmtVisitor) any {
+func (ts *ThrowStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitThrowStmt(ts)
 }
 
-func (trs *TryStmt) Accept(visitor StmtVisitor) any {
+func (trs *TryStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitTryStmt(trs)
 }
 
-func (ifs *IfStmt) Accept(visitor StmtVisitor) any {
+func (ifs *IfStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitIfStmt(ifs)
 }
 
-func (ws *WhileStmt) Accept(visitor StmtVisitor) any {
+func (ws *WhileStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitWhileStmt(ws)
 }
 
-func (fs *ForStmt) Accept(visitor StmtVisitor) any {
+func (fs *ForStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitForStmt(fs)
 }
 
-func (sc *SwitchCase) Accept(visitor StmtVisitor) any {
+func (sc *SwitchCase) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitSwitchCase(sc)
 }
 
-func (ss *SwitchStmt) Accept(visitor StmtVisitor) any {
+func (ss *SwitchStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitSwitchStmt(ss)
 }
 
-func (vs *ValStmt) Accept(visitor StmtVisitor) any {
+func (vs *ValStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitValStmt(vs)
 }
 
-func (ls *LetStmt) Accept(visitor StmtVisitor) any {
+func (ls *LetStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitLetStmt(ls)
 }
 
-func (gs *GlobalStmt) Accept(visitor StmtVisitor) any {
+func (gs *GlobalStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitGlobalStmt(gs)
 }
 
-func (fs *FunctionStmt) Accept(visitor StmtVisitor) any {
+func (fs *FunctionStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitFunctionStmt(fs)
 }
 
-func (cs *ClassStmt) Accept(visitor StmtVisitor) any {
+func (cs *ClassStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitClassStmt(cs)
 }
 
-func (cons *ConstructorStmt) Accept(visitor StmtVisitor) any {
+func (cons *ConstructorStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitConstructorStmt(cons)
 }
 
-func (is *InterfaceStmt) Accept(visitor StmtVisitor) any {
+func (is *InterfaceStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitInterfaceStmt(is)
 }
 
-func (ss *StructStmt) Accept(visitor StmtVisitor) any {
+func (ss *StructStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitStructStmt(ss)
 }
 
-func (es *EnumStmt) Accept(visitor StmtVisitor) any {
+func (es *EnumStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitEnumStmt(es)
 }
 
-func (ds *DataStmt) Accept(visitor StmtVisitor) any {
+func (ds *DataStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitDataStmt(ds)
 }
 
-func (is *ImportStmt) Accept(visitor StmtVisitor) any {
+func (is *ImportStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitImportStmt(is)
 }
 
-func (es *ExportStmt) Accept(visitor StmtVisitor) any {
+func (es *ExportStmt) Accept(visitor StmtVisitor[any]) any {
 	return visitor.VisitExportStmt(es)
 }
+
+func (bs *BadStmt) Accept(visitor StmtVisitor[any]) any {
+	return visitor.VisitBadStmt(bs)
+}
@@ -0,0 +1,207 @@
+package ast_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dotFun/internal/ast"
+)
+
+// names is a visitor of every node that returns the name of the method
+// called, converted to R.
+type names[R any] struct {
+	result func(method string) R
+}
+
+func (n names[R]) VisitIntLiteral(*ast.IntLiteral) R         { return n.result("VisitIntLiteral") }
+func (n names[R]) VisitFloatLiteral(*ast.FloatLiteral) R     { return n.result("VisitFloatLiteral") }
+func (n names[R]) VisitStringLiteral(*ast.StringLiteral) R   { return n.result("VisitStringLiteral") }
+func (n names[R]) VisitCharLiteral(*ast.CharLiteral) R       { return n.result("VisitCharLiteral") }
+func (n names[R]) VisitBoolLiteral(*ast.BoolLiteral) R       { return n.result("VisitBoolLiteral") }
+func (n names[R]) VisitNilLiteral(*ast.NilLiteral) R         { return n.result("VisitNilLiteral") }
+func (n names[R]) VisitArrayLiteral(*ast.ArrayLiteral) R     { return n.result("VisitArrayLiteral") }
+func (n names[R]) VisitAssignExpr(*ast.AssignExpr) R         { return n.result("VisitAssignExpr") }
+func (n names[R]) VisitBinaryExpr(*ast.BinaryExpr) R         { return n.result("VisitBinaryExpr") }
+func (n names[R]) VisitCallExpr(*ast.CallExpr) R             { return n.result("VisitCallExpr") }
+func (n names[R]) VisitGroupingExpr(*ast.GroupingExpr) R     { return n.result("VisitGroupingExpr") }
+func (n names[R]) VisitInstanceOfExpr(*ast.InstanceOfExpr) R { return n.result("VisitInstanceOfExpr") }
+func (n names[R]) VisitLambdaExpr(*ast.LambdaExpr) R         { return n.result("VisitLambdaExpr") }
+func (n names[R]) VisitLogicalExpr(*ast.LogicalExpr) R       { return n.result("VisitLogicalExpr") }
+func (n names[R]) VisitNewExpr(*ast.NewExpr) R               { return n.result("VisitNewExpr") }
+func (n names[R]) VisitSuperExpr(*ast.SuperExpr) R           { return n.result("VisitSuperExpr") }
+func (n names[R]) VisitThisExpr(*ast.ThisExpr) R             { return n.result("VisitThisExpr") }
+func (n names[R]) VisitUnaryExpr(*ast.UnaryExpr) R           { return n.result("VisitUnaryExpr") }
+func (n names[R]) VisitVariableExpr(*ast.VariableExpr) R     { return n.result("VisitVariableExpr") }
+func (n names[R]) VisitMemberExpr(*ast.MemberExpr) R         { return n.result("VisitMemberExpr") }
+func (n names[R]) VisitRangeExpr(*ast.RangeExpr) R           { return n.result("VisitRangeExpr") }
+func (n names[R]) VisitIdentifier(*ast.Identifier) R         { return n.result("VisitIdentifier") }
+func (n names[R]) VisitBadExpr(*ast.BadExpr) R               { return n.result("VisitBadExpr") }
+func (n names[R]) VisitBlockStmt(*ast.BlockStmt) R           { return n.result("VisitBlockStmt") }
+func (n names[R]) VisitBreakStmt(*ast.BreakStmt) R           { return n.result("VisitBreakStmt") }
+func (n names[R]) VisitContinueStmt(*ast.ContinueStmt) R     { return n.result("VisitContinueStmt") }
+func (n names[R]) VisitExpressionStmt(*ast.ExpressionStmt) R { return n.result("VisitExpressionStmt") }
+func (n names[R]) VisitReturnStmt(*ast.ReturnStmt) R         { return n.result("VisitReturnStmt") }
+func (n names[R]) VisitThrowStmt(*ast.ThrowStmt) R           { return n.result("VisitThrowStmt") }
+func (n names[R]) VisitTryStmt(*ast.TryStmt) R               { return n.result("VisitTryStmt") }
+func (n names[R]) VisitIfStmt(*ast.IfStmt) R                 { return n.result("VisitIfStmt") }
+func (n names[R]) VisitWhileStmt(*ast.WhileStmt) R           { return n.result("VisitWhileStmt") }
+func (n names[R]) VisitForStmt(*ast.ForStmt) R               { return n.result("VisitForStmt") }
+func (n names[R]) VisitSwitchCase(*ast.SwitchCase) R         { return n.result("VisitSwitchCase") }
+func (n names[R]) VisitSwitchStmt(*ast.SwitchStmt) R         { return n.result("VisitSwitchStmt") }
+func (n names[R]) VisitValStmt(*ast.ValStmt) R               { return n.result("VisitValStmt") }
+func (n names[R]) VisitLetStmt(*ast.LetStmt) R               { return n.result("VisitLetStmt") }
+func (n names[R]) VisitGlobalStmt(*ast.GlobalStmt) R         { return n.result("VisitGlobalStmt") }
+func (n names[R]) VisitFunctionStmt(*ast.FunctionStmt) R     { return n.result("VisitFunctionStmt") }
+func (n names[R]) VisitClassStmt(*ast.ClassStmt) R           { return n.result("VisitClassStmt") }
+func (n names[R]) VisitInterfaceStmt(*ast.InterfaceStmt) R   { return n.result("VisitInterfaceStmt") }
+func (n names[R]) VisitStructStmt(*ast.StructStmt) R         { return n.result("VisitStructStmt") }
+func (n names[R]) VisitEnumStmt(*ast.EnumStmt) R             { return n.result("VisitEnumStmt") }
+func (n names[R]) VisitDataStmt(*ast.DataStmt) R             { return n.result("VisitDataStmt") }
+func (n names[R]) VisitImportStmt(*ast.ImportStmt) R         { return n.result("VisitImportStmt") }
+func (n names[R]) VisitExportStmt(*ast.ExportStmt) R         { return n.result("VisitExportStmt") }
+func (n names[R]) VisitBadStmt(*ast.BadStmt) R               { return n.result("VisitBadStmt") }
+
+func (n names[R]) VisitInterpolatedString(*ast.InterpolatedString) R {
+	return n.result("VisitInterpolatedString")
+}
+
+func (n names[R]) VisitMemberAssignExpr(*ast.MemberAssignExpr) R {
+	return n.result("VisitMemberAssignExpr")
+}
+
+func (n names[R]) VisitPostfixUnaryExpr(*ast.PostfixUnaryExpr) R {
+	return n.result("VisitPostfixUnaryExpr")
+}
+
+func (n names[R]) VisitConstructorStmt(*ast.ConstructorStmt) R {
+	return n.result("VisitConstructorStmt")
+}
+
+var (
+	exprs = []ast.Expr{
+		&ast.IntLiteral{}, &ast.FloatLiteral{}, &ast.StringLiteral{}, &ast.InterpolatedString{},
+		&ast.CharLiteral{}, &ast.BoolLiteral{}, &ast.NilLiteral{}, &ast.ArrayLiteral{},
+		&ast.AssignExpr{}, &ast.MemberAssignExpr{}, &ast.BinaryExpr{}, &ast.CallExpr{},
+		&ast.GroupingExpr{}, &ast.InstanceOfExpr{}, &ast.LambdaExpr{}, &ast.LogicalExpr{},
+		&ast.NewExpr{}, &ast.PostfixUnaryExpr{}, &ast.SuperExpr{}, &ast.ThisExpr{},
+		&ast.UnaryExpr{}, &ast.VariableExpr{}, &ast.MemberExpr{}, &ast.RangeExpr{},
+		&ast.Identifier{}, &ast.BadExpr{},
+	}
+	stmts = []ast.Stmt{
+		&ast.BlockStmt{}, &ast.BreakStmt{}, &ast.ContinueStmt{}, &ast.ExpressionStmt{},
+		&ast.ReturnStmt{}, &ast.ThrowStmt{}, &ast.TryStmt{}, &ast.IfStmt{},
+		&ast.WhileStmt{}, &ast.ForStmt{}, &ast.SwitchCase{}, &ast.SwitchStmt{},
+		&ast.ValStmt{}, &ast.LetStmt{}, &ast.GlobalStmt{}, &ast.FunctionStmt{},
+		&ast.ClassStmt{}, &ast.ConstructorStmt{}, &ast.InterfaceStmt{}, &ast.StructStmt{},
+		&ast.EnumStmt{}, &ast.DataStmt{}, &ast.ImportStmt{}, &ast.ExportStmt{},
+		&ast.BadStmt{},
+	}
+)
+
+// method is the visitor method for node, named after its type.
+func method(node ast.Node) string {
+	return "Visit" + strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+}
+
+// TestVisitDispatch checks that VisitExpr, VisitStmt and Accept call the
+// method for each type of node and return its result.
+func TestVisitDispatch(t *testing.T) {
+	typed := names[string]{result: func(m string) string { return m }}
+	untyped := names[any]{result: func(m string) any { return m }}
+
+	if n := reflect.TypeOf((*ast.ExprVisitor[string])(nil)).Elem().NumMethod(); n != len(exprs) {
+		t.Errorf("ExprVisitor has %d methods, but %d expressions are tested", n, len(exprs))
+	}
+	if n := reflect.TypeOf((*ast.StmtVisitor[string])(nil)).Elem().NumMethod(); n != len(stmts) {
+		t.Errorf("StmtVisitor has %d methods, but %d statements are tested", n, len(stmts))
+	}
+
+	for _, expr := range exprs {
+		want := method(expr)
+		if got := ast.VisitExpr[string](typed, expr); got != want {
+			t.Errorf("VisitExpr(%T) called %s, want %s", expr, got, want)
+		}
+		if got := expr.Accept(untyped); got != want {
+			t.Errorf("%T.Accept called %v, want %s", expr, got, want)
+		}
+	}
+	for _, stmt := range stmts {
+		want := method(stmt)
+		if got := ast.VisitStmt[string](typed, stmt); got != want {
+			t.Errorf("VisitStmt(%T) called %s, want %s", stmt, got, want)
+		}
+		if got := stmt.Accept(untyped); got != want {
+			t.Errorf("%T.Accept called %v, want %s", stmt, got, want)
+		}
+	}
+}
+
+func TestVisitUnknown(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "unexpected node <nil>") {
+			t.Errorf("VisitExpr(nil) panicked with %v", r)
+		}
+	}()
+	ast.VisitExpr[string](names[string]{}, nil)
+}
+
+// folder folds constant integer expressions, overriding the methods of
+// names for the nodes it understands.
+type folder struct {
+	names[int]
+}
+
+func (f folder) VisitIntLiteral(expr *ast.IntLiteral) int {
+	return int(expr.Value)
+}
+
+func (f folder) VisitGroupingExpr(expr *ast.GroupingExpr) int {
+	return ast.VisitExpr[int](f, expr.Expression)
+}
+
+func (f folder) VisitUnaryExpr(expr *ast.UnaryExpr) int {
+	return -ast.VisitExpr[int](f, expr.Right)
+}
+
+func (f folder) VisitBinaryExpr(expr *ast.BinaryExpr) int {
+	left, right := ast.VisitExpr[int](f, expr.Left), ast.VisitExpr[int](f, expr.Right)
+	switch expr.Operator {
+	case "+":
+		return left + right
+	case "-":
+		return left - right
+	case "*":
+		return left * right
+	}
+	panic("unexpected operator " + expr.Operator)
+}
+
+// TestVisitResult checks a visitor that returns a value computed from its
+// children, as the interpreter does.
+func TestVisitResult(t *testing.T) {
+	var unexpected []string
+	f := folder{names[int]{result: func(m string) int {
+		unexpected = append(unexpected, m)
+		return 0
+	}}}
+	tests := []struct {
+		src  string
+		want int
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"-(4 - 6) * -2", -4},
+		{"x + 1", 1},
+	}
+	for _, tt := range tests {
+		expr := parse(t, tt.src)[0].(*ast.ExpressionStmt).Expression
+		if got := ast.VisitExpr[int](f, expr); got != tt.want {
+			t.Errorf("%q folded to %d, want %d", tt.src, got, tt.want)
+		}
+	}
+	if want := []string{"VisitVariableExpr"}; !reflect.DeepEqual(unexpected, want) {
+		t.Errorf("fell back to %v, want %v", unexpected, want)
+	}
+}
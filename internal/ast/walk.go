@@ -0,0 +1,183 @@
+package ast
+
+// Visitor is called by Walk for each node. If Visit returns a non-nil w,
+// Walk visits the children of node with w and then calls w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree rooted at node depth-first, visiting children
+// in source order.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *IntLiteral, *FloatLiteral, *StringLiteral, *CharLiteral, *BoolLiteral, *NilLiteral,
+		*ThisExpr, *Identifier, *BreakStmt, *ContinueStmt, *Parameter,
+		*EnumStmt, *ImportStmt, *ExportStmt, *BadExpr, *BadStmt:
+
+	case *InterpolatedString:
+		walkExprs(v, n.Parts)
+	case *ArrayLiteral:
+		walkExprs(v, n.Elements)
+	case *AssignExpr:
+		Walk(v, n.Name)
+		Walk(v, n.Value)
+	case *MemberAssignExpr:
+		Walk(v, n.Object)
+		Walk(v, n.Property)
+		Walk(v, n.Value)
+	case *BinaryExpr:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *CallExpr:
+		Walk(v, n.Callee)
+		walkExprs(v, n.Arguments)
+	case *GroupingExpr:
+		Walk(v, n.Expression)
+	case *InstanceOfExpr:
+		Walk(v, n.Object)
+	case *LambdaExpr:
+		Walk(v, n.Body)
+	case *LogicalExpr:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *NewExpr:
+		walkExprs(v, n.Args)
+	case *PostfixUnaryExpr:
+		Walk(v, n.Operand)
+	case *SuperExpr:
+		if n.Method != nil {
+			Walk(v, n.Method)
+		}
+	case *UnaryExpr:
+		Walk(v, n.Right)
+	case *VariableExpr:
+		Walk(v, n.Name)
+	case *MemberExpr:
+		Walk(v, n.Object)
+		Walk(v, n.Property)
+	case *RangeExpr:
+		Walk(v, n.Start)
+		Walk(v, n.Stop)
+
+	case *BlockStmt:
+		walkStmts(v, n.Statements)
+	case *ExpressionStmt:
+		Walk(v, n.Expression)
+	case *ReturnStmt:
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *ThrowStmt:
+		Walk(v, n.Value)
+	case *TryStmt:
+		Walk(v, n.TryBlock)
+		if n.CatchBlock != nil {
+			Walk(v, n.CatchBlock)
+		}
+		if n.FinallyBlock != nil {
+			Walk(v, n.FinallyBlock)
+		}
+	case *IfStmt:
+		Walk(v, n.Condition)
+		Walk(v, n.ThenBlock)
+		if n.ElseBlock != nil {
+			Walk(v, n.ElseBlock)
+		}
+	case *WhileStmt:
+		Walk(v, n.Condition)
+		Walk(v, n.Body)
+	case *ForStmt:
+		if n.Init != nil {
+			Walk(v, n.Init)
+		}
+		if n.Condition != nil {
+			Walk(v, n.Condition)
+		}
+		if n.Post != nil {
+			Walk(v, n.Post)
+		}
+		Walk(v, n.Body)
+	case *SwitchCase:
+		walkExprs(v, n.CaseExprs)
+		Walk(v, n.Body)
+	case *SwitchStmt:
+		Walk(v, n.Expr)
+		for _, c := range n.Cases {
+			Walk(v, c)
+		}
+		if n.Default != nil {
+			Walk(v, n.Default)
+		}
+	case *ValStmt:
+		if n.Initializer != nil {
+			Walk(v, n.Initializer)
+		}
+	case *LetStmt:
+		if n.Initializer != nil {
+			Walk(v, n.Initializer)
+		}
+	case *GlobalStmt:
+		if n.Initializer != nil {
+			Walk(v, n.Initializer)
+		}
+	case *FunctionStmt:
+		walkParams(v, n.Parameters)
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *ClassStmt:
+		walkStmts(v, n.Members)
+	case *ConstructorStmt:
+		walkParams(v, n.Parameters)
+		Walk(v, n.Body)
+	case *InterfaceStmt:
+		walkStmts(v, n.Members)
+	case *StructStmt:
+		walkStmts(v, n.Members)
+	case *DataStmt:
+		walkParams(v, n.Fields)
+
+	default:
+		panic("ast.Walk: unexpected node type")
+	}
+
+	v.Visit(nil)
+}
+
+func walkExprs(v Visitor, exprs []Expr) {
+	for _, e := range exprs {
+		Walk(v, e)
+	}
+}
+
+func walkStmts(v Visitor, stmts []Stmt) {
+	for _, s := range stmts {
+		Walk(v, s)
+	}
+}
+
+func walkParams(v Visitor, params []Parameter) {
+	for i := range params {
+		Walk(v, &params[i])
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree rooted at node, calling f for each node and
+// then f(nil) once its children are done. The children of a node are
+// skipped when f returns false for it.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
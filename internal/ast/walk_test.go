@@ -0,0 +1,177 @@
+package ast_test
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/lexer"
+	"dotFun/internal/parser"
+)
+
+func parse(t *testing.T, src string) []ast.Stmt {
+	t.Helper()
+	tokens, err := lexer.NewLexer(src).Lex()
+	if err != nil {
+		t.Fatalf("lexing %q: %v", src, err)
+	}
+	statements, err := parser.NewParser(tokens).Parse()
+	if err != nil {
+		t.Fatalf("parsing %q: %v", src, err)
+	}
+	return statements
+}
+
+func dump(statements []ast.Stmt) string {
+	var buf bytes.Buffer
+	ast.Fprint(&buf, statements)
+	return buf.String()
+}
+
+// recorder writes each node it visits as its type name, and the end of
+// its children as ")".
+type recorder struct {
+	out   *[]string
+	prune string
+}
+
+func (r recorder) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		*r.out = append(*r.out, ")")
+		return nil
+	}
+	name := strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+	*r.out = append(*r.out, name)
+	if name == r.prune {
+		return nil
+	}
+	return r
+}
+
+func TestWalkOrder(t *testing.T) {
+	stmt := parse(t, "if a { return f(b, -c) }")[0]
+	var got []string
+	ast.Walk(recorder{out: &got}, stmt)
+	want := "IfStmt VariableExpr Identifier ) ) BlockStmt ReturnStmt CallExpr VariableExpr Identifier ) ) " +
+		"VariableExpr Identifier ) ) UnaryExpr VariableExpr Identifier ) ) ) ) ) ) )"
+	if strings.Join(got, " ") != want {
+		t.Errorf("visited\n%s\nwant\n%s", strings.Join(got, " "), want)
+	}
+}
+
+func TestWalkPrune(t *testing.T) {
+	stmt := parse(t, "if a { return f(b, -c) }")[0]
+	var got []string
+	ast.Walk(recorder{out: &got, prune: "CallExpr"}, stmt)
+	want := "IfStmt VariableExpr Identifier ) ) BlockStmt ReturnStmt CallExpr ) ) )"
+	if strings.Join(got, " ") != want {
+		t.Errorf("visited\n%s\nwant\n%s", strings.Join(got, " "), want)
+	}
+
+	count := 0
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if n != nil {
+			count++
+		}
+		_, block := n.(*ast.BlockStmt)
+		return !block
+	})
+	if count != 4 {
+		t.Errorf("Inspect visited %d nodes outside the block, want 4", count)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	statements := parse(t, "let x = a + b\nif c { print(a) }\nwhile d { e() }")
+	before := dump(statements)
+
+	rewritten := make([]ast.Stmt, len(statements))
+	for i, stmt := range statements {
+		rewritten[i] = ast.Rewrite(stmt, func(n ast.Node) ast.Node {
+			if id, ok := n.(*ast.Identifier); ok && id.Name == "a" {
+				return &ast.Identifier{Span: id.Span, Name: "z"}
+			}
+			return n
+		}).(ast.Stmt)
+	}
+
+	if dump(statements) != before {
+		t.Errorf("Rewrite changed its input:\n%s", dump(statements))
+	}
+	want := dump(parse(t, "let x = z + b\nif c { print(z) }\nwhile d { e() }"))
+	if got := dump(rewritten); got != want {
+		t.Errorf("rewritten to\n%s\nwant\n%s", got, want)
+	}
+	if rewritten[0] == statements[0] || rewritten[1] == statements[1] {
+		t.Error("changed statements were not copied")
+	}
+	if rewritten[2] != statements[2] {
+		t.Error("unchanged statement was copied")
+	}
+}
+
+func TestShift(t *testing.T) {
+	stmt := parse(t, "fun f(a) {\n  return a + 1\n}")[0]
+	spans := func(node ast.Node) []lexer.Span {
+		var spans []lexer.Span
+		ast.Inspect(node, func(n ast.Node) bool {
+			if n != nil {
+				spans = append(spans, lexer.Span{StartOffset: n.Pos().Offset, EndOffset: n.End().Offset, StartLine: n.Pos().Line, EndLine: n.End().Line})
+			}
+			return true
+		})
+		return spans
+	}
+	before := spans(stmt)
+	shifted := spans(ast.Shift(stmt, 10, 2))
+
+	if !reflect.DeepEqual(spans(stmt), before) {
+		t.Error("Shift changed its input")
+	}
+	if len(shifted) != len(before) {
+		t.Fatalf("shifted tree has %d nodes, want %d", len(shifted), len(before))
+	}
+	for i, s := range before {
+		s.StartOffset += 10
+		s.EndOffset += 10
+		s.StartLine += 2
+		s.EndLine += 2
+		if shifted[i] != s {
+			t.Errorf("node %d: span %+v, want %+v", i, shifted[i], s)
+		}
+	}
+}
+
+// TestNilChildren checks that optional children left nil are skipped.
+func TestNilChildren(t *testing.T) {
+	nodes := []ast.Stmt{
+		&ast.ValStmt{Name: "v"},
+		&ast.LetStmt{Name: "l"},
+		&ast.GlobalStmt{Name: "g"},
+		&ast.ReturnStmt{},
+		&ast.IfStmt{Condition: &ast.BoolLiteral{Value: true}, ThenBlock: &ast.BlockStmt{}},
+		&ast.ForStmt{Body: &ast.BlockStmt{}},
+		&ast.TryStmt{TryBlock: &ast.BlockStmt{}},
+		&ast.SwitchStmt{Expr: &ast.NilLiteral{}},
+		&ast.FunctionStmt{Name: "f"},
+		&ast.ExpressionStmt{Expression: &ast.SuperExpr{}},
+	}
+	for _, node := range nodes {
+		count := 0
+		ast.Inspect(node, func(n ast.Node) bool {
+			if n != nil {
+				count++
+			}
+			return true
+		})
+		if count == 0 {
+			t.Errorf("%T: no nodes visited", node)
+		}
+		if got := ast.Rewrite(node, func(n ast.Node) ast.Node { return n }); got != node {
+			t.Errorf("%T: Rewrite copied a node it kept", node)
+		}
+	}
+}
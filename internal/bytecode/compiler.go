@@ -1 +1,811 @@
 package bytecode
+
+import (
+	"fmt"
+	"strings"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/runtime"
+)
+
+type CompileError struct {
+	Message string
+	Span    ast.Span
+}
+
+func (e *CompileError) Error() string {
+	return e.Message
+}
+
+func (e *CompileError) Position() (int, int) {
+	return e.Span.StartLine, e.Span.StartCol
+}
+
+func (e *CompileError) Offsets() (int, int) {
+	return e.Span.StartOffset, e.Span.EndOffset
+}
+
+type local struct {
+	name  string
+	depth int
+	// captured is set when a nested function refers to the local, which
+	// must then be moved off the stack when it goes out of scope.
+	captured bool
+}
+
+// upvalue is a variable a function captures from an enclosing one: either
+// a local of the function directly enclosing it, or one of its upvalues.
+type upvalue struct {
+	index   byte
+	isLocal bool
+}
+
+type loop struct {
+	scopeDepth    int
+	breakJumps    []int
+	continueJumps []int
+}
+
+type Compiler struct {
+	enclosing  *Compiler
+	function   *Function
+	locals     []local
+	upvalues   []upvalue
+	scopeDepth int
+	loops      []*loop
+	// span is the node being compiled, used for line numbers and to
+	// position errors.
+	span ast.Span
+}
+
+func newCompiler(enclosing *Compiler, name string) *Compiler {
+	return &Compiler{
+		enclosing: enclosing,
+		function:  &Function{Name: name, Chunk: &Chunk{}},
+		// Slot zero holds the function being called.
+		locals: []local{{name: "", depth: 0}},
+	}
+}
+
+// Compile turns a program into the top-level script function run by the VM.
+func Compile(statements []ast.Stmt) (fn *Function, err error) {
+	c := newCompiler(nil, "")
+	defer func() {
+		if r := recover(); r != nil {
+			compileErr, ok := r.(*CompileError)
+			if !ok {
+				panic(r)
+			}
+			fn, err = nil, compileErr
+		}
+	}()
+
+	for _, stmt := range statements {
+		c.statement(stmt)
+	}
+	c.emitReturn()
+	return c.function, nil
+}
+
+func (c *Compiler) fail(format string, args ...any) {
+	panic(&CompileError{Message: fmt.Sprintf(format, args...), Span: c.span})
+}
+
+func (c *Compiler) unsupported(what string) {
+	c.fail("%s is not supported by the bytecode compiler", what)
+}
+
+func (c *Compiler) chunk() *Chunk {
+	return c.function.Chunk
+}
+
+func (c *Compiler) emit(bytes ...byte) {
+	for _, b := range bytes {
+		c.chunk().Write(b, c.span.StartLine)
+	}
+}
+
+func (c *Compiler) emitOp(op OpCode, operands ...byte) {
+	c.emit(byte(op))
+	c.emit(operands...)
+}
+
+func (c *Compiler) emitShort(op OpCode, operand int) {
+	c.emit(byte(op), byte(operand>>8), byte(operand))
+}
+
+func (c *Compiler) emitConstant(value runtime.Value) {
+	c.emitShort(OpConstant, c.makeConstant(value))
+}
+
+func (c *Compiler) makeConstant(value runtime.Value) int {
+	index := c.chunk().AddConstant(value)
+	if index > 0xFFFF {
+		c.fail("Too many constants in one function")
+	}
+	return index
+}
+
+func (c *Compiler) emitJump(op OpCode) int {
+	c.emit(byte(op), 0xFF, 0xFF)
+	return len(c.chunk().Code) - 2
+}
+
+func (c *Compiler) patchJump(offset int) {
+	jump := len(c.chunk().Code) - offset - 2
+	if jump > 0xFFFF {
+		c.fail("Too much code to jump over")
+	}
+	c.chunk().Code[offset] = byte(jump >> 8)
+	c.chunk().Code[offset+1] = byte(jump)
+}
+
+func (c *Compiler) emitLoop(start int) {
+	offset := len(c.chunk().Code) - start + 3
+	if offset > 0xFFFF {
+		c.fail("Loop body too large")
+	}
+	c.emitShort(OpLoop, offset)
+}
+
+func (c *Compiler) emitReturn() {
+	c.emitOp(OpNil)
+	c.emitOp(OpReturn)
+}
+
+func (c *Compiler) beginScope() {
+	c.scopeDepth++
+}
+
+func (c *Compiler) endScope() {
+	c.scopeDepth--
+	for len(c.locals) > 0 && c.locals[len(c.locals)-1].depth > c.scopeDepth {
+		c.popLocal(c.locals[len(c.locals)-1])
+		c.locals = c.locals[:len(c.locals)-1]
+	}
+}
+
+// popLocalsAbove emits pops for locals deeper than depth without forgetting
+// them, for jumps that leave a scope early.
+func (c *Compiler) popLocalsAbove(depth int) {
+	for i := len(c.locals) - 1; i >= 0 && c.locals[i].depth > depth; i-- {
+		c.popLocal(c.locals[i])
+	}
+}
+
+func (c *Compiler) popLocal(l local) {
+	if l.captured {
+		c.emitOp(OpCloseUpvalue)
+	} else {
+		c.emitOp(OpPop)
+	}
+}
+
+func (c *Compiler) addLocal(name string) {
+	for i := len(c.locals) - 1; i >= 0 && c.locals[i].depth == c.scopeDepth; i-- {
+		if c.locals[i].name == name {
+			c.fail("Variable '%s' is already declared in this scope", name)
+		}
+	}
+	if len(c.locals) > 0xFF {
+		c.fail("Too many local variables in function")
+	}
+	c.locals = append(c.locals, local{name: name, depth: c.scopeDepth})
+}
+
+func (c *Compiler) resolveLocal(name string) int {
+	for i := len(c.locals) - 1; i > 0; i-- {
+		if c.locals[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolveUpvalue returns the index of the upvalue through which the
+// function reaches a local of an enclosing one, adding it if needed, or
+// -1 if no enclosing function declares name.
+func (c *Compiler) resolveUpvalue(name string) int {
+	if c.enclosing == nil {
+		return -1
+	}
+	if slot := c.enclosing.resolveLocal(name); slot != -1 {
+		c.enclosing.locals[slot].captured = true
+		return c.addUpvalue(byte(slot), true)
+	}
+	if index := c.enclosing.resolveUpvalue(name); index != -1 {
+		return c.addUpvalue(byte(index), false)
+	}
+	return -1
+}
+
+func (c *Compiler) addUpvalue(index byte, isLocal bool) int {
+	up := upvalue{index: index, isLocal: isLocal}
+	for i, existing := range c.upvalues {
+		if existing == up {
+			return i
+		}
+	}
+	if len(c.upvalues) > 0xFF {
+		c.fail("Too many captured variables in function")
+	}
+	c.upvalues = append(c.upvalues, up)
+	c.function.Upvalues = len(c.upvalues)
+	return len(c.upvalues) - 1
+}
+
+func (c *Compiler) defineVariable(name string) {
+	if c.scopeDepth > 0 {
+		c.addLocal(name)
+		return
+	}
+	c.emitShort(OpDefineGlobal, c.makeConstant(runtime.String(name)))
+}
+
+func (c *Compiler) getVariable(name string) {
+	if slot := c.resolveLocal(name); slot != -1 {
+		c.emitOp(OpGetLocal, byte(slot))
+		return
+	}
+	if index := c.resolveUpvalue(name); index != -1 {
+		c.emitOp(OpGetUpvalue, byte(index))
+		return
+	}
+	c.emitShort(OpGetGlobal, c.makeConstant(runtime.String(name)))
+}
+
+func (c *Compiler) setVariable(name string) {
+	if slot := c.resolveLocal(name); slot != -1 {
+		c.emitOp(OpSetLocal, byte(slot))
+		return
+	}
+	if index := c.resolveUpvalue(name); index != -1 {
+		c.emitOp(OpSetUpvalue, byte(index))
+		return
+	}
+	c.emitShort(OpSetGlobal, c.makeConstant(runtime.String(name)))
+}
+
+func (c *Compiler) statement(stmt ast.Stmt) {
+	enclosing := c.span
+	c.span = stmt.SourceSpan()
+	ast.VisitStmt[struct{}](c, stmt)
+	c.span = enclosing
+}
+
+func (c *Compiler) expression(expr ast.Expr) {
+	enclosing := c.span
+	c.span = expr.SourceSpan()
+	ast.VisitExpr[struct{}](c, expr)
+	c.span = enclosing
+}
+
+// compileFunction compiles a nested function and emits the closure that
+// creates it, followed by where each of its upvalues is captured from.
+func (c *Compiler) compileFunction(name string, params []string, body func(*Compiler)) {
+	fc := newCompiler(c, name)
+	fc.span = c.span
+	fc.function.Params = len(params)
+	fc.beginScope()
+	for _, param := range params {
+		fc.addLocal(param)
+	}
+	body(fc)
+	c.emitShort(OpClosure, c.makeConstant(fc.function))
+	for _, up := range fc.upvalues {
+		isLocal := byte(0)
+		if up.isLocal {
+			isLocal = 1
+		}
+		c.emit(isLocal, up.index)
+	}
+}
+
+func (c *Compiler) VisitBlockStmt(stmt *ast.BlockStmt) struct{} {
+	c.beginScope()
+	for _, s := range stmt.Statements {
+		c.statement(s)
+	}
+	c.endScope()
+	return struct{}{}
+}
+
+func (c *Compiler) VisitBreakStmt(stmt *ast.BreakStmt) struct{} {
+	if len(c.loops) == 0 {
+		c.fail("Cannot use 'break' outside of a loop")
+	}
+	l := c.loops[len(c.loops)-1]
+	c.popLocalsAbove(l.scopeDepth)
+	l.breakJumps = append(l.breakJumps, c.emitJump(OpJump))
+	return struct{}{}
+}
+
+func (c *Compiler) VisitContinueStmt(stmt *ast.ContinueStmt) struct{} {
+	if len(c.loops) == 0 {
+		c.fail("Cannot use 'continue' outside of a loop")
+	}
+	l := c.loops[len(c.loops)-1]
+	c.popLocalsAbove(l.scopeDepth)
+	l.continueJumps = append(l.continueJumps, c.emitJump(OpJump))
+	return struct{}{}
+}
+
+func (c *Compiler) VisitExpressionStmt(stmt *ast.ExpressionStmt) struct{} {
+	c.expression(stmt.Expression)
+	c.emitOp(OpPop)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitReturnStmt(stmt *ast.ReturnStmt) struct{} {
+	if c.enclosing == nil {
+		c.fail("Cannot return from top-level code")
+	}
+	if stmt.Value == nil {
+		c.emitOp(OpNil)
+	} else {
+		c.expression(stmt.Value)
+	}
+	c.emitOp(OpReturn)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitThrowStmt(stmt *ast.ThrowStmt) struct{} {
+	c.expression(stmt.Value)
+	c.emitOp(OpThrow)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitTryStmt(stmt *ast.TryStmt) struct{} {
+	c.unsupported("try")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitIfStmt(stmt *ast.IfStmt) struct{} {
+	c.expression(stmt.Condition)
+	thenJump := c.emitJump(OpJumpIfFalse)
+	c.emitOp(OpPop)
+	c.VisitBlockStmt(stmt.ThenBlock)
+
+	elseJump := c.emitJump(OpJump)
+	c.patchJump(thenJump)
+	c.emitOp(OpPop)
+	if stmt.ElseBlock != nil {
+		c.statement(stmt.ElseBlock)
+	}
+	c.patchJump(elseJump)
+	return struct{}{}
+}
+
+func (c *Compiler) loopBody(body *ast.BlockStmt) *loop {
+	l := &loop{scopeDepth: c.scopeDepth}
+	c.loops = append(c.loops, l)
+	c.VisitBlockStmt(body)
+	c.loops = c.loops[:len(c.loops)-1]
+	for _, jump := range l.continueJumps {
+		c.patchJump(jump)
+	}
+	return l
+}
+
+func (c *Compiler) VisitWhileStmt(stmt *ast.WhileStmt) struct{} {
+	start := len(c.chunk().Code)
+	c.expression(stmt.Condition)
+	exitJump := c.emitJump(OpJumpIfFalse)
+	c.emitOp(OpPop)
+
+	l := c.loopBody(stmt.Body)
+	c.emitLoop(start)
+
+	c.patchJump(exitJump)
+	c.emitOp(OpPop)
+	for _, jump := range l.breakJumps {
+		c.patchJump(jump)
+	}
+	return struct{}{}
+}
+
+func (c *Compiler) VisitForStmt(stmt *ast.ForStmt) struct{} {
+	c.beginScope()
+	if stmt.Init != nil {
+		c.statement(stmt.Init)
+	}
+
+	start := len(c.chunk().Code)
+	exitJump := -1
+	if stmt.Condition != nil {
+		c.expression(stmt.Condition)
+		exitJump = c.emitJump(OpJumpIfFalse)
+		c.emitOp(OpPop)
+	}
+
+	l := c.loopBody(stmt.Body)
+	if stmt.Post != nil {
+		c.statement(stmt.Post)
+	}
+	c.emitLoop(start)
+
+	if exitJump != -1 {
+		c.patchJump(exitJump)
+		c.emitOp(OpPop)
+	}
+	for _, jump := range l.breakJumps {
+		c.patchJump(jump)
+	}
+	c.endScope()
+	return struct{}{}
+}
+
+func (c *Compiler) VisitSwitchCase(stmt *ast.SwitchCase) struct{} {
+	c.unsupported("turn")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitSwitchStmt(stmt *ast.SwitchStmt) struct{} {
+	c.unsupported("turn")
+	return struct{}{}
+}
+
+func (c *Compiler) variable(name string, initializer ast.Expr) {
+	if initializer == nil {
+		c.emitOp(OpNil)
+	} else {
+		c.expression(initializer)
+	}
+	c.defineVariable(name)
+}
+
+func (c *Compiler) VisitValStmt(stmt *ast.ValStmt) struct{} {
+	c.variable(stmt.Name, stmt.Initializer)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitLetStmt(stmt *ast.LetStmt) struct{} {
+	c.variable(stmt.Name, stmt.Initializer)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitGlobalStmt(stmt *ast.GlobalStmt) struct{} {
+	if stmt.Initializer == nil {
+		c.emitOp(OpNil)
+	} else {
+		c.expression(stmt.Initializer)
+	}
+	c.emitShort(OpDefineGlobal, c.makeConstant(runtime.String(stmt.Name)))
+	return struct{}{}
+}
+
+func (c *Compiler) VisitFunctionStmt(stmt *ast.FunctionStmt) struct{} {
+	params := make([]string, len(stmt.Parameters))
+	for i, param := range stmt.Parameters {
+		params[i] = param.Name
+	}
+	if c.scopeDepth > 0 {
+		// Declare first so the body can call itself recursively.
+		c.emitOp(OpNil)
+		c.addLocal(stmt.Name)
+	}
+	c.compileFunction(stmt.Name, params, func(fc *Compiler) {
+		for _, s := range stmt.Body.Statements {
+			fc.statement(s)
+		}
+		fc.emitReturn()
+	})
+	if c.scopeDepth > 0 {
+		c.setVariable(stmt.Name)
+		c.emitOp(OpPop)
+		return struct{}{}
+	}
+	c.defineVariable(stmt.Name)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitClassStmt(stmt *ast.ClassStmt) struct{} {
+	c.unsupported("class")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitConstructorStmt(stmt *ast.ConstructorStmt) struct{} {
+	c.unsupported("constructor")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitInterfaceStmt(stmt *ast.InterfaceStmt) struct{} {
+	c.unsupported("interface")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitStructStmt(stmt *ast.StructStmt) struct{} {
+	c.unsupported("struct")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitEnumStmt(stmt *ast.EnumStmt) struct{} {
+	c.unsupported("enum")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitDataStmt(stmt *ast.DataStmt) struct{} {
+	c.unsupported("data")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitImportStmt(stmt *ast.ImportStmt) struct{} {
+	c.unsupported("import")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitExportStmt(stmt *ast.ExportStmt) struct{} {
+	c.unsupported("export")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitBadStmt(stmt *ast.BadStmt) struct{} {
+	c.fail("Cannot compile a statement with syntax errors")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitIntLiteral(expr *ast.IntLiteral) struct{} {
+	c.emitConstant(runtime.Int(expr.Value))
+	return struct{}{}
+}
+
+func (c *Compiler) VisitFloatLiteral(expr *ast.FloatLiteral) struct{} {
+	c.emitConstant(runtime.Float(expr.Value))
+	return struct{}{}
+}
+
+func (c *Compiler) VisitStringLiteral(expr *ast.StringLiteral) struct{} {
+	c.emitConstant(runtime.String(expr.Value))
+	return struct{}{}
+}
+
+func (c *Compiler) VisitInterpolatedString(expr *ast.InterpolatedString) struct{} {
+	for _, part := range expr.Parts {
+		c.expression(part)
+	}
+	if len(expr.Parts) > 0xFFFF {
+		c.fail("Too many parts in interpolated string")
+	}
+	c.emitShort(OpInterpolate, len(expr.Parts))
+	return struct{}{}
+}
+
+func (c *Compiler) VisitCharLiteral(expr *ast.CharLiteral) struct{} {
+	c.emitConstant(runtime.Char(expr.Value))
+	return struct{}{}
+}
+
+func (c *Compiler) VisitBoolLiteral(expr *ast.BoolLiteral) struct{} {
+	if expr.Value {
+		c.emitOp(OpTrue)
+	} else {
+		c.emitOp(OpFalse)
+	}
+	return struct{}{}
+}
+
+func (c *Compiler) VisitNilLiteral(expr *ast.NilLiteral) struct{} {
+	c.emitOp(OpNil)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitArrayLiteral(expr *ast.ArrayLiteral) struct{} {
+	for _, element := range expr.Elements {
+		c.expression(element)
+	}
+	if len(expr.Elements) > 0xFFFF {
+		c.fail("Too many elements in array literal")
+	}
+	c.emitShort(OpArray, len(expr.Elements))
+	return struct{}{}
+}
+
+func (c *Compiler) VisitAssignExpr(expr *ast.AssignExpr) struct{} {
+	if expr.Operator == "=" {
+		c.expression(expr.Value)
+		c.setVariable(expr.Name.Name)
+		return struct{}{}
+	}
+	op, ok := binaryOpcodes[strings.TrimSuffix(expr.Operator, "=")]
+	if !ok {
+		c.fail("Unknown assignment operator '%s'", expr.Operator)
+	}
+	c.getVariable(expr.Name.Name)
+	c.expression(expr.Value)
+	c.emitOp(op)
+	c.setVariable(expr.Name.Name)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitMemberAssignExpr(expr *ast.MemberAssignExpr) struct{} {
+	c.expression(expr.Object)
+	name := c.makeConstant(runtime.String(expr.Property.Name))
+	if expr.Operator == "=" {
+		c.expression(expr.Value)
+		c.emitShort(OpSetProperty, name)
+		return struct{}{}
+	}
+	op, ok := binaryOpcodes[strings.TrimSuffix(expr.Operator, "=")]
+	if !ok {
+		c.fail("Unknown assignment operator '%s'", expr.Operator)
+	}
+	c.emitOp(OpDup)
+	c.emitShort(OpGetProperty, name)
+	c.expression(expr.Value)
+	c.emitOp(op)
+	c.emitShort(OpSetProperty, name)
+	return struct{}{}
+}
+
+var binaryOpcodes = map[string]OpCode{
+	"+":  OpAdd,
+	"-":  OpSubtract,
+	"*":  OpMultiply,
+	"/":  OpDivide,
+	"%":  OpModulo,
+	"**": OpPower,
+	"&":  OpBitAnd,
+	"|":  OpBitOr,
+	"^":  OpBitXor,
+	"<<": OpShiftLeft,
+	">>": OpShiftRight,
+	"==": OpEqual,
+	"!=": OpNotEqual,
+	">":  OpGreater,
+	">=": OpGreaterEqual,
+	"<":  OpLess,
+	"<=": OpLessEqual,
+}
+
+func (c *Compiler) VisitBinaryExpr(expr *ast.BinaryExpr) struct{} {
+	op, ok := binaryOpcodes[expr.Operator]
+	if !ok {
+		c.fail("Unknown binary operator '%s'", expr.Operator)
+	}
+	c.expression(expr.Left)
+	c.expression(expr.Right)
+	c.emitOp(op)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitCallExpr(expr *ast.CallExpr) struct{} {
+	c.expression(expr.Callee)
+	for _, arg := range expr.Arguments {
+		c.expression(arg)
+	}
+	if len(expr.Arguments) > 0xFF {
+		c.fail("Cannot have more than 255 arguments")
+	}
+	c.emitOp(OpCall, byte(len(expr.Arguments)))
+	return struct{}{}
+}
+
+func (c *Compiler) VisitGroupingExpr(expr *ast.GroupingExpr) struct{} {
+	c.expression(expr.Expression)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitInstanceOfExpr(expr *ast.InstanceOfExpr) struct{} {
+	c.expression(expr.Object)
+	c.emitShort(OpInstanceOf, c.makeConstant(runtime.String(expr.Type.TypeName())))
+	return struct{}{}
+}
+
+func (c *Compiler) VisitLambdaExpr(expr *ast.LambdaExpr) struct{} {
+	c.compileFunction("", expr.Params, func(fc *Compiler) {
+		fc.expression(expr.Body)
+		fc.emitOp(OpReturn)
+	})
+	return struct{}{}
+}
+
+func (c *Compiler) VisitLogicalExpr(expr *ast.LogicalExpr) struct{} {
+	c.expression(expr.Left)
+	switch expr.Operator {
+	case "??":
+		endJump := c.emitJump(OpJumpIfNotNil)
+		c.emitOp(OpPop)
+		c.expression(expr.Right)
+		c.patchJump(endJump)
+	case "||", "or":
+		elseJump := c.emitJump(OpJumpIfFalse)
+		endJump := c.emitJump(OpJump)
+		c.patchJump(elseJump)
+		c.emitOp(OpPop)
+		c.expression(expr.Right)
+		c.patchJump(endJump)
+	default:
+		endJump := c.emitJump(OpJumpIfFalse)
+		c.emitOp(OpPop)
+		c.expression(expr.Right)
+		c.patchJump(endJump)
+	}
+	return struct{}{}
+}
+
+func (c *Compiler) VisitNewExpr(expr *ast.NewExpr) struct{} {
+	c.unsupported("new")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitPostfixUnaryExpr(expr *ast.PostfixUnaryExpr) struct{} {
+	variable, ok := expr.Operand.(*ast.VariableExpr)
+	if !ok {
+		c.fail("Invalid operand for '%s'", expr.Operator)
+	}
+	c.getVariable(variable.Name.Name)
+	c.getVariable(variable.Name.Name)
+	c.emitConstant(runtime.Int(1))
+	if expr.Operator == "--" {
+		c.emitOp(OpSubtract)
+	} else {
+		c.emitOp(OpAdd)
+	}
+	c.setVariable(variable.Name.Name)
+	c.emitOp(OpPop)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitSuperExpr(expr *ast.SuperExpr) struct{} {
+	c.unsupported("super")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitThisExpr(expr *ast.ThisExpr) struct{} {
+	c.unsupported("this")
+	return struct{}{}
+}
+
+func (c *Compiler) VisitUnaryExpr(expr *ast.UnaryExpr) struct{} {
+	c.expression(expr.Right)
+	switch expr.Operator {
+	case "-":
+		c.emitOp(OpNegate)
+	case "!", "not":
+		c.emitOp(OpNot)
+	case "~":
+		c.emitOp(OpBitNot)
+	default:
+		c.fail("Unknown unary operator '%s'", expr.Operator)
+	}
+	return struct{}{}
+}
+
+func (c *Compiler) VisitVariableExpr(expr *ast.VariableExpr) struct{} {
+	c.getVariable(expr.Name.Name)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitMemberExpr(expr *ast.MemberExpr) struct{} {
+	c.expression(expr.Object)
+	name := c.makeConstant(runtime.String(expr.Property.Name))
+	if !expr.Optional {
+		c.emitShort(OpGetProperty, name)
+		return struct{}{}
+	}
+	nilJump := c.emitJump(OpJumpIfNil)
+	c.emitShort(OpGetProperty, name)
+	c.patchJump(nilJump)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitRangeExpr(expr *ast.RangeExpr) struct{} {
+	c.expression(expr.Start)
+	c.expression(expr.Stop)
+	inclusive := byte(0)
+	if expr.Inclusive {
+		inclusive = 1
+	}
+	c.emitOp(OpRange, inclusive)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitIdentifier(expr *ast.Identifier) struct{} {
+	c.getVariable(expr.Name)
+	return struct{}{}
+}
+
+func (c *Compiler) VisitBadExpr(expr *ast.BadExpr) struct{} {
+	c.fail("Cannot compile an expression with syntax errors")
+	return struct{}{}
+}
@@ -1 +1,73 @@
 package bytecode
+
+import (
+	"fmt"
+	"io"
+
+	"dotFun/internal/runtime"
+)
+
+// Disassemble writes a listing of fn and every function nested in its
+// constant pool.
+func Disassemble(w io.Writer, fn *Function) {
+	fmt.Fprintf(w, "== %s ==\n", fn.String())
+	for offset := 0; offset < len(fn.Chunk.Code); {
+		offset = DisassembleInstruction(w, fn.Chunk, offset)
+	}
+	for _, constant := range fn.Chunk.Constants {
+		if nested, ok := constant.(*Function); ok {
+			fmt.Fprintln(w)
+			Disassemble(w, nested)
+		}
+	}
+}
+
+// DisassembleInstruction writes the instruction at offset and returns the
+// offset of the next one.
+func DisassembleInstruction(w io.Writer, chunk *Chunk, offset int) int {
+	fmt.Fprintf(w, "%04d ", offset)
+	if offset > 0 && chunk.Lines[offset] == chunk.Lines[offset-1] {
+		fmt.Fprint(w, "   | ")
+	} else {
+		fmt.Fprintf(w, "%4d ", chunk.Lines[offset])
+	}
+
+	op := OpCode(chunk.Code[offset])
+	switch op {
+	case OpConstant, OpDefineGlobal, OpGetGlobal, OpSetGlobal, OpInstanceOf, OpGetProperty,
+		OpSetProperty:
+		index := chunk.ReadShort(offset + 1)
+		fmt.Fprintf(w, "%-18s %4d %s\n", op, index, runtime.Inspect(chunk.Constants[index]))
+		return offset + 3
+	case OpGetLocal, OpSetLocal, OpGetUpvalue, OpSetUpvalue, OpCall, OpRange:
+		fmt.Fprintf(w, "%-18s %4d\n", op, chunk.Code[offset+1])
+		return offset + 2
+	case OpClosure:
+		index := chunk.ReadShort(offset + 1)
+		fmt.Fprintf(w, "%-18s %4d %s\n", op, index, runtime.Inspect(chunk.Constants[index]))
+		offset += 3
+		for i := 0; i < chunk.Constants[index].(*Function).Upvalues; i++ {
+			kind := "upvalue"
+			if chunk.Code[offset] == 1 {
+				kind = "local"
+			}
+			fmt.Fprintf(w, "%04d    |                     %s %d\n", offset, kind, chunk.Code[offset+1])
+			offset += 2
+		}
+		return offset
+	case OpArray, OpInterpolate:
+		fmt.Fprintf(w, "%-18s %4d\n", op, chunk.ReadShort(offset+1))
+		return offset + 3
+	case OpJump, OpJumpIfFalse, OpJumpIfNil, OpJumpIfNotNil:
+		jump := chunk.ReadShort(offset + 1)
+		fmt.Fprintf(w, "%-18s %4d -> %d\n", op, offset, offset+3+jump)
+		return offset + 3
+	case OpLoop:
+		jump := chunk.ReadShort(offset + 1)
+		fmt.Fprintf(w, "%-18s %4d -> %d\n", op, offset, offset+3-jump)
+		return offset + 3
+	default:
+		fmt.Fprintf(w, "%s\n", op)
+		return offset + 1
+	}
+}
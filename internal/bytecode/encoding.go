@@ -0,0 +1,205 @@
+package bytecode
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"dotFun/internal/runtime"
+)
+
+// Magic starts every compiled dotFun file.
+const Magic = "DFBC"
+
+const formatVersion = 4
+
+const (
+	tagNil byte = iota
+	tagInt
+	tagFloat
+	tagString
+	tagBool
+	tagFunction
+	tagChar
+)
+
+func Encode(w io.Writer, fn *Function) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(Magic)
+	bw.WriteByte(formatVersion)
+	e := &encoder{w: bw}
+	e.function(fn)
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+func Decode(r io.Reader) (*Function, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(Magic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("Not a compiled dotFun file: %w", err)
+	}
+	if string(header[:len(Magic)]) != Magic {
+		return nil, errors.New("Not a compiled dotFun file")
+	}
+	if header[len(Magic)] != formatVersion {
+		return nil, fmt.Errorf("Unsupported bytecode version %d", header[len(Magic)])
+	}
+	d := &decoder{r: br}
+	fn := d.function()
+	if d.err != nil {
+		return nil, fmt.Errorf("Corrupt compiled file: %w", d.err)
+	}
+	return fn, nil
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) uvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	e.bytes(buf[:n])
+}
+
+func (e *encoder) bytes(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.bytes([]byte(s))
+}
+
+func (e *encoder) function(fn *Function) {
+	e.string(fn.Name)
+	e.uvarint(uint64(fn.Params))
+	e.uvarint(uint64(fn.Upvalues))
+	e.uvarint(uint64(len(fn.Chunk.Code)))
+	e.bytes(fn.Chunk.Code)
+	for _, line := range fn.Chunk.Lines {
+		e.uvarint(uint64(line))
+	}
+	e.uvarint(uint64(len(fn.Chunk.Constants)))
+	for _, constant := range fn.Chunk.Constants {
+		e.constant(constant)
+	}
+}
+
+func (e *encoder) constant(v runtime.Value) {
+	switch v := v.(type) {
+	case runtime.Nil:
+		e.bytes([]byte{tagNil})
+	case runtime.Int:
+		e.bytes([]byte{tagInt})
+		e.uvarint(uint64(v))
+	case runtime.Float:
+		e.bytes([]byte{tagFloat})
+		e.uvarint(math.Float64bits(float64(v)))
+	case runtime.String:
+		e.bytes([]byte{tagString})
+		e.string(string(v))
+	case runtime.Char:
+		e.bytes([]byte{tagChar})
+		e.uvarint(uint64(v))
+	case runtime.Bool:
+		b := byte(0)
+		if v {
+			b = 1
+		}
+		e.bytes([]byte{tagBool, b})
+	case *Function:
+		e.bytes([]byte{tagFunction})
+		e.function(v)
+	default:
+		if e.err == nil {
+			e.err = fmt.Errorf("Cannot encode constant of type %s", v.Type())
+		}
+	}
+}
+
+type decoder struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(d.r)
+	d.err = err
+	return v
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	b, err := d.r.ReadByte()
+	d.err = err
+	return b
+}
+
+func (d *decoder) bytes(n uint64) []byte {
+	if d.err != nil {
+		return nil
+	}
+	buf := make([]byte, n)
+	_, d.err = io.ReadFull(d.r, buf)
+	return buf
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes(d.uvarint()))
+}
+
+func (d *decoder) function() *Function {
+	fn := &Function{Chunk: &Chunk{}}
+	fn.Name = d.string()
+	fn.Params = int(d.uvarint())
+	fn.Upvalues = int(d.uvarint())
+	fn.Chunk.Code = d.bytes(d.uvarint())
+	fn.Chunk.Lines = make([]int, len(fn.Chunk.Code))
+	for i := range fn.Chunk.Lines {
+		fn.Chunk.Lines[i] = int(d.uvarint())
+	}
+	count := d.uvarint()
+	for i := uint64(0); i < count && d.err == nil; i++ {
+		fn.Chunk.Constants = append(fn.Chunk.Constants, d.constant())
+	}
+	return fn
+}
+
+func (d *decoder) constant() runtime.Value {
+	switch tag := d.byte(); tag {
+	case tagNil:
+		return runtime.NilValue
+	case tagInt:
+		return runtime.Int(int64(d.uvarint()))
+	case tagFloat:
+		return runtime.Float(math.Float64frombits(d.uvarint()))
+	case tagString:
+		return runtime.String(d.string())
+	case tagBool:
+		return runtime.Bool(d.byte() == 1)
+	case tagFunction:
+		return d.function()
+	case tagChar:
+		return runtime.Char(rune(d.uvarint()))
+	default:
+		if d.err == nil {
+			d.err = fmt.Errorf("unknown constant tag %d", tag)
+		}
+		return runtime.NilValue
+	}
+}
@@ -1 +1,202 @@
 package bytecode
+
+import "dotFun/internal/runtime"
+
+type OpCode byte
+
+const (
+	OpConstant OpCode = iota
+	OpNil
+	OpTrue
+	OpFalse
+	OpPop
+	OpDup
+
+	OpGetLocal
+	OpSetLocal
+	OpDefineGlobal
+	OpGetGlobal
+	OpSetGlobal
+	OpGetUpvalue
+	OpSetUpvalue
+	OpCloseUpvalue
+
+	OpAdd
+	OpSubtract
+	OpMultiply
+	OpDivide
+	OpModulo
+	OpPower
+	OpBitAnd
+	OpBitOr
+	OpBitXor
+	OpShiftLeft
+	OpShiftRight
+
+	OpEqual
+	OpNotEqual
+	OpGreater
+	OpGreaterEqual
+	OpLess
+	OpLessEqual
+
+	OpNot
+	OpNegate
+	OpBitNot
+	OpInstanceOf
+
+	OpJump
+	OpJumpIfFalse
+	OpJumpIfNil
+	OpJumpIfNotNil
+	OpLoop
+
+	OpCall
+	OpClosure
+	OpReturn
+	OpThrow
+	OpArray
+	OpInterpolate
+	OpGetProperty
+	OpSetProperty
+	OpRange
+)
+
+var opNames = map[OpCode]string{
+	OpConstant: "OP_CONSTANT",
+	OpNil:      "OP_NIL",
+	OpTrue:     "OP_TRUE",
+	OpFalse:    "OP_FALSE",
+	OpPop:      "OP_POP",
+	OpDup:      "OP_DUP",
+
+	OpGetLocal:     "OP_GET_LOCAL",
+	OpSetLocal:     "OP_SET_LOCAL",
+	OpDefineGlobal: "OP_DEFINE_GLOBAL",
+	OpGetGlobal:    "OP_GET_GLOBAL",
+	OpSetGlobal:    "OP_SET_GLOBAL",
+	OpGetUpvalue:   "OP_GET_UPVALUE",
+	OpSetUpvalue:   "OP_SET_UPVALUE",
+	OpCloseUpvalue: "OP_CLOSE_UPVALUE",
+
+	OpAdd:        "OP_ADD",
+	OpSubtract:   "OP_SUBTRACT",
+	OpMultiply:   "OP_MULTIPLY",
+	OpDivide:     "OP_DIVIDE",
+	OpModulo:     "OP_MODULO",
+	OpPower:      "OP_POWER",
+	OpBitAnd:     "OP_BIT_AND",
+	OpBitOr:      "OP_BIT_OR",
+	OpBitXor:     "OP_BIT_XOR",
+	OpShiftLeft:  "OP_SHIFT_LEFT",
+	OpShiftRight: "OP_SHIFT_RIGHT",
+
+	OpEqual:        "OP_EQUAL",
+	OpNotEqual:     "OP_NOT_EQUAL",
+	OpGreater:      "OP_GREATER",
+	OpGreaterEqual: "OP_GREATER_EQUAL",
+	OpLess:         "OP_LESS",
+	OpLessEqual:    "OP_LESS_EQUAL",
+
+	OpNot:        "OP_NOT",
+	OpNegate:     "OP_NEGATE",
+	OpBitNot:     "OP_BIT_NOT",
+	OpInstanceOf: "OP_INSTANCEOF",
+
+	OpJump:         "OP_JUMP",
+	OpJumpIfFalse:  "OP_JUMP_IF_FALSE",
+	OpJumpIfNil:    "OP_JUMP_IF_NIL",
+	OpJumpIfNotNil: "OP_JUMP_IF_NOT_NIL",
+	OpLoop:         "OP_LOOP",
+
+	OpCall:        "OP_CALL",
+	OpClosure:     "OP_CLOSURE",
+	OpReturn:      "OP_RETURN",
+	OpThrow:       "OP_THROW",
+	OpArray:       "OP_ARRAY",
+	OpInterpolate: "OP_INTERPOLATE",
+	OpGetProperty: "OP_GET_PROPERTY",
+	OpSetProperty: "OP_SET_PROPERTY",
+	OpRange:       "OP_RANGE",
+}
+
+func (op OpCode) String() string {
+	if name, ok := opNames[op]; ok {
+		return name
+	}
+	return "OP_UNKNOWN"
+}
+
+// BinaryOperators maps the binary opcodes to the runtime operator they
+// evaluate.
+var BinaryOperators = map[OpCode]string{
+	OpAdd:          "+",
+	OpSubtract:     "-",
+	OpMultiply:     "*",
+	OpDivide:       "/",
+	OpModulo:       "%",
+	OpPower:        "**",
+	OpBitAnd:       "&",
+	OpBitOr:        "|",
+	OpBitXor:       "^",
+	OpShiftLeft:    "<<",
+	OpShiftRight:   ">>",
+	OpEqual:        "==",
+	OpNotEqual:     "!=",
+	OpGreater:      ">",
+	OpGreaterEqual: ">=",
+	OpLess:         "<",
+	OpLessEqual:    "<=",
+}
+
+type Chunk struct {
+	Code      []byte
+	Constants []runtime.Value
+	Lines     []int
+}
+
+func (c *Chunk) Write(b byte, line int) {
+	c.Code = append(c.Code, b)
+	c.Lines = append(c.Lines, line)
+}
+
+func (c *Chunk) AddConstant(value runtime.Value) int {
+	for i, existing := range c.Constants {
+		if sameConstant(existing, value) {
+			return i
+		}
+	}
+	c.Constants = append(c.Constants, value)
+	return len(c.Constants) - 1
+}
+
+func (c *Chunk) ReadShort(offset int) int {
+	return int(c.Code[offset])<<8 | int(c.Code[offset+1])
+}
+
+func sameConstant(a, b runtime.Value) bool {
+	switch a.(type) {
+	case runtime.Int, runtime.Float, runtime.String, runtime.Char, runtime.Bool, runtime.Nil:
+		return a == b
+	}
+	return false
+}
+
+// Function is compiled code. The VM runs it as a closure, which supplies
+// the Upvalues variables it captures.
+type Function struct {
+	Name     string
+	Params   int
+	Upvalues int
+	Chunk    *Chunk
+}
+
+func (f *Function) Type() string { return "Function" }
+func (f *Function) Arity() int   { return f.Params }
+
+func (f *Function) String() string {
+	if f.Name == "" {
+		return "<script>"
+	}
+	return "<fun " + f.Name + ">"
+}
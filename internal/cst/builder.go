@@ -0,0 +1,62 @@
+package cst
+
+import (
+	"dotFun/internal/ast"
+	"dotFun/internal/lexer"
+)
+
+// Builder assembles a green tree bottom-up. Tokens are added in source
+// order, and a node is made by taking a checkpoint where it starts and
+// finishing it once all of it has been added, so a node can be wrapped
+// around children added before its kind was known, such as the left
+// operand of a binary expression.
+type Builder struct {
+	children []Green
+	links    map[*GreenNode]ast.Node
+}
+
+func (b *Builder) Token(tok lexer.Token) {
+	b.children = append(b.children, GreenTokenOf(tok))
+}
+
+func (b *Builder) Add(g Green) {
+	b.children = append(b.children, g)
+}
+
+func (b *Builder) Checkpoint() int {
+	return len(b.children)
+}
+
+// Finish wraps everything added since cp in a node of the given kind and
+// returns it. If that is already a single node of the kind, it is left as
+// it is.
+func (b *Builder) Finish(cp int, kind Kind) *GreenNode {
+	if cp == len(b.children)-1 {
+		if g, ok := b.children[cp].(*GreenNode); ok && g.Kind == kind {
+			return g
+		}
+	}
+	children := append([]Green{}, b.children[cp:]...)
+	g := NewGreenNode(kind, children)
+	b.children = append(b.children[:cp], g)
+	return g
+}
+
+// Elements returns what has been added and not yet wrapped in a node.
+func (b *Builder) Elements() []Green {
+	return b.children
+}
+
+// Link records that node was derived from g, for the red tree to report.
+func (b *Builder) Link(g *GreenNode, node ast.Node) {
+	if b.links == nil {
+		b.links = make(map[*GreenNode]ast.Node)
+	}
+	b.links[g] = node
+}
+
+// Root wraps everything added in a SourceFile node and returns it as the
+// root of a red tree, with the links made so far.
+func (b *Builder) Root() *Node {
+	return NewRoot(NewGreenNode(SourceFile, append([]Green{}, b.children...)), b.links)
+}
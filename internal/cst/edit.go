@@ -0,0 +1,39 @@
+package cst
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Edit replaces the source from Offset up to End with Text.
+type Edit struct {
+	Offset int
+	End    int
+	Text   string
+}
+
+// Replace returns the edit replacing the text of e, leaving its trivia.
+func Replace(e Element, text string) Edit {
+	return Edit{Offset: e.Offset(), End: e.End(), Text: text}
+}
+
+// Apply returns src with edits applied. The edits may come in any order
+// but must not overlap.
+func Apply(src string, edits []Edit) (string, error) {
+	edits = append([]Edit{}, edits...)
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].Offset < edits[j].Offset })
+
+	var sb strings.Builder
+	pos := 0
+	for _, e := range edits {
+		if e.Offset < pos || e.End < e.Offset || e.End > len(src) {
+			return "", fmt.Errorf("edit %d..%d overlaps another edit or is out of range", e.Offset, e.End)
+		}
+		sb.WriteString(src[pos:e.Offset])
+		sb.WriteString(e.Text)
+		pos = e.End
+	}
+	sb.WriteString(src[pos:])
+	return sb.String(), nil
+}
@@ -0,0 +1,116 @@
+// Package cst holds lossless concrete syntax trees. A green tree records
+// only kinds, text and shape, so its nodes know their width but not where
+// they are; a red tree wraps one with parent links and absolute offsets
+// for navigation. Every byte of the source, trivia included, belongs to
+// exactly one token, so the text of a tree is the source it was parsed
+// from.
+package cst
+
+import (
+	"strings"
+
+	"dotFun/internal/lexer"
+)
+
+// Kind names what a node is.
+type Kind string
+
+type Trivia struct {
+	Kind lexer.TriviaKind
+	Text string
+}
+
+// Green is a *GreenNode or a *GreenToken.
+type Green interface {
+	Width() int
+	writeText(sb *strings.Builder)
+}
+
+type GreenToken struct {
+	Type     lexer.TokenType
+	Text     string
+	Leading  []Trivia
+	Trailing []Trivia
+	width    int
+}
+
+func NewGreenToken(typ lexer.TokenType, text string, leading, trailing []Trivia) *GreenToken {
+	t := &GreenToken{Type: typ, Text: text, Leading: leading, Trailing: trailing}
+	t.width = t.leadingWidth() + len(text)
+	for _, tr := range trailing {
+		t.width += len(tr.Text)
+	}
+	return t
+}
+
+// GreenTokenOf converts tok, keeping its trivia.
+func GreenTokenOf(tok lexer.Token) *GreenToken {
+	return NewGreenToken(tok.Type, tok.Lexeme, triviaOf(tok.Leading), triviaOf(tok.Trailing))
+}
+
+func triviaOf(trivia []lexer.Trivia) []Trivia {
+	if len(trivia) == 0 {
+		return nil
+	}
+	list := make([]Trivia, len(trivia))
+	for i, t := range trivia {
+		list[i] = Trivia{Kind: t.Kind, Text: t.Text}
+	}
+	return list
+}
+
+// Width is the length of the token's text and trivia in bytes.
+func (t *GreenToken) Width() int {
+	return t.width
+}
+
+func (t *GreenToken) leadingWidth() int {
+	width := 0
+	for _, tr := range t.Leading {
+		width += len(tr.Text)
+	}
+	return width
+}
+
+func (t *GreenToken) writeText(sb *strings.Builder) {
+	for _, tr := range t.Leading {
+		sb.WriteString(tr.Text)
+	}
+	sb.WriteString(t.Text)
+	for _, tr := range t.Trailing {
+		sb.WriteString(tr.Text)
+	}
+}
+
+type GreenNode struct {
+	Kind     Kind
+	Children []Green
+	width    int
+}
+
+func NewGreenNode(kind Kind, children []Green) *GreenNode {
+	n := &GreenNode{Kind: kind, Children: children}
+	for _, c := range children {
+		n.width += c.Width()
+	}
+	return n
+}
+
+// Width is the length of the node's text in bytes.
+func (n *GreenNode) Width() int {
+	return n.width
+}
+
+// Text returns the source the node was parsed from.
+func (n *GreenNode) Text() string {
+	var sb strings.Builder
+	sb.Grow(n.width)
+	n.writeText(&sb)
+	return sb.String()
+}
+
+func (n *GreenNode) writeText(sb *strings.Builder) {
+	for _, c := range n.Children {
+		c.writeText(sb)
+	}
+}
@@ -0,0 +1,64 @@
+package cst
+
+// SourceFile is the kind of the root. Every other node has the kind of the
+// ast node derived from it, named after its Go type.
+const SourceFile Kind = "SourceFile"
+
+// Expressions.
+const (
+	IntLiteral         Kind = "IntLiteral"
+	FloatLiteral       Kind = "FloatLiteral"
+	StringLiteral      Kind = "StringLiteral"
+	InterpolatedString Kind = "InterpolatedString"
+	CharLiteral        Kind = "CharLiteral"
+	BoolLiteral        Kind = "BoolLiteral"
+	NilLiteral         Kind = "NilLiteral"
+	ArrayLiteral       Kind = "ArrayLiteral"
+	AssignExpr         Kind = "AssignExpr"
+	MemberAssignExpr   Kind = "MemberAssignExpr"
+	BinaryExpr         Kind = "BinaryExpr"
+	CallExpr           Kind = "CallExpr"
+	GroupingExpr       Kind = "GroupingExpr"
+	InstanceOfExpr     Kind = "InstanceOfExpr"
+	LambdaExpr         Kind = "LambdaExpr"
+	LogicalExpr        Kind = "LogicalExpr"
+	NewExpr            Kind = "NewExpr"
+	PostfixUnaryExpr   Kind = "PostfixUnaryExpr"
+	SuperExpr          Kind = "SuperExpr"
+	ThisExpr           Kind = "ThisExpr"
+	UnaryExpr          Kind = "UnaryExpr"
+	VariableExpr       Kind = "VariableExpr"
+	MemberExpr         Kind = "MemberExpr"
+	RangeExpr          Kind = "RangeExpr"
+	BadExpr            Kind = "BadExpr"
+)
+
+// Statements and declarations.
+const (
+	BlockStmt       Kind = "BlockStmt"
+	BreakStmt       Kind = "BreakStmt"
+	ContinueStmt    Kind = "ContinueStmt"
+	ExpressionStmt  Kind = "ExpressionStmt"
+	ReturnStmt      Kind = "ReturnStmt"
+	ThrowStmt       Kind = "ThrowStmt"
+	TryStmt         Kind = "TryStmt"
+	IfStmt          Kind = "IfStmt"
+	WhileStmt       Kind = "WhileStmt"
+	ForStmt         Kind = "ForStmt"
+	SwitchCase      Kind = "SwitchCase"
+	SwitchStmt      Kind = "SwitchStmt"
+	ValStmt         Kind = "ValStmt"
+	LetStmt         Kind = "LetStmt"
+	GlobalStmt      Kind = "GlobalStmt"
+	FunctionStmt    Kind = "FunctionStmt"
+	Parameter       Kind = "Parameter"
+	ClassStmt       Kind = "ClassStmt"
+	ConstructorStmt Kind = "ConstructorStmt"
+	InterfaceStmt   Kind = "InterfaceStmt"
+	StructStmt      Kind = "StructStmt"
+	EnumStmt        Kind = "EnumStmt"
+	DataStmt        Kind = "DataStmt"
+	ImportStmt      Kind = "ImportStmt"
+	ExportStmt      Kind = "ExportStmt"
+	BadStmt         Kind = "BadStmt"
+)
@@ -0,0 +1,39 @@
+package cst
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Fprint writes the tree under n to w, one node, token or piece of trivia
+// per line, indented by depth and labelled with its byte range.
+func Fprint(w io.Writer, n *Node) error {
+	bw := bufio.NewWriter(w)
+	fprint(bw, n, 0)
+	return bw.Flush()
+}
+
+func fprint(w *bufio.Writer, n *Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(w, "%s%s@%d..%d\n", indent, n.Kind(), n.FullOffset(), n.FullEnd())
+	for _, c := range n.Children() {
+		switch c := c.(type) {
+		case *Node:
+			fprint(w, c, depth+1)
+		case *Token:
+			offset := c.FullOffset()
+			for _, t := range c.Leading() {
+				fmt.Fprintf(w, "%s  (%s)@%d..%d %q\n", indent, t.Kind, offset, offset+len(t.Text), t.Text)
+				offset += len(t.Text)
+			}
+			fmt.Fprintf(w, "%s  %s@%d..%d %q\n", indent, c.Type(), c.Offset(), c.End(), c.Text())
+			offset = c.End()
+			for _, t := range c.Trailing() {
+				fmt.Fprintf(w, "%s  (%s)@%d..%d %q\n", indent, t.Kind, offset, offset+len(t.Text), t.Text)
+				offset += len(t.Text)
+			}
+		}
+	}
+}
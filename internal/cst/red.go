@@ -0,0 +1,263 @@
+package cst
+
+import (
+	"strings"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/lexer"
+)
+
+// Element is a *Node or a *Token of a red tree. Offset and End bound its
+// text without the trivia before its first token and after its last one;
+// FullOffset and FullEnd include that trivia.
+type Element interface {
+	Parent() *Node
+	Offset() int
+	End() int
+	FullOffset() int
+	FullEnd() int
+	Text() string
+	FullText() string
+}
+
+// Node is a green node placed in the source, with a link to its parent.
+type Node struct {
+	green  *GreenNode
+	parent *Node
+	offset int
+	// links maps the green nodes of the tree to the ast nodes derived
+	// from them. It is kept here rather than in the green nodes, which
+	// carry no positions and could be shared by trees whose ast nodes
+	// have different spans.
+	links map[*GreenNode]ast.Node
+}
+
+// NewRoot returns the root of a red tree for green, starting at offset 0.
+// links gives the ast node derived from each green node, if any.
+func NewRoot(green *GreenNode, links map[*GreenNode]ast.Node) *Node {
+	return &Node{green: green, links: links}
+}
+
+func (n *Node) Kind() Kind {
+	return n.green.Kind
+}
+
+func (n *Node) Green() *GreenNode {
+	return n.green
+}
+
+// AST returns the ast node derived from n, or nil for the root and for
+// the nodes inside a bad node.
+func (n *Node) AST() ast.Node {
+	return n.links[n.green]
+}
+
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+func (n *Node) FullOffset() int {
+	return n.offset
+}
+
+func (n *Node) FullEnd() int {
+	return n.offset + n.green.width
+}
+
+func (n *Node) Offset() int {
+	if t := n.FirstToken(); t != nil {
+		return t.Offset()
+	}
+	return n.offset
+}
+
+func (n *Node) End() int {
+	if t := n.LastToken(); t != nil {
+		return t.End()
+	}
+	return n.offset
+}
+
+func (n *Node) Text() string {
+	return n.FullText()[n.Offset()-n.offset : n.End()-n.offset]
+}
+
+func (n *Node) FullText() string {
+	return n.green.Text()
+}
+
+// Children returns the nodes and tokens directly under n in source order.
+func (n *Node) Children() []Element {
+	children := make([]Element, len(n.green.Children))
+	offset := n.offset
+	for i, g := range n.green.Children {
+		switch g := g.(type) {
+		case *GreenNode:
+			children[i] = &Node{green: g, parent: n, offset: offset, links: n.links}
+		case *GreenToken:
+			children[i] = &Token{green: g, parent: n, offset: offset}
+		}
+		offset += g.Width()
+	}
+	return children
+}
+
+// Nodes returns the nodes directly under n.
+func (n *Node) Nodes() []*Node {
+	var nodes []*Node
+	for _, c := range n.Children() {
+		if c, ok := c.(*Node); ok {
+			nodes = append(nodes, c)
+		}
+	}
+	return nodes
+}
+
+// Tokens returns every token under n in source order.
+func (n *Node) Tokens() []*Token {
+	var tokens []*Token
+	for _, c := range n.Children() {
+		switch c := c.(type) {
+		case *Node:
+			tokens = append(tokens, c.Tokens()...)
+		case *Token:
+			tokens = append(tokens, c)
+		}
+	}
+	return tokens
+}
+
+func (n *Node) FirstToken() *Token {
+	for _, c := range n.Children() {
+		switch c := c.(type) {
+		case *Node:
+			if t := c.FirstToken(); t != nil {
+				return t
+			}
+		case *Token:
+			return c
+		}
+	}
+	return nil
+}
+
+func (n *Node) LastToken() *Token {
+	children := n.Children()
+	for i := len(children) - 1; i >= 0; i-- {
+		switch c := children[i].(type) {
+		case *Node:
+			if t := c.LastToken(); t != nil {
+				return t
+			}
+		case *Token:
+			return c
+		}
+	}
+	return nil
+}
+
+// TokenAt returns the token whose text or trivia holds the byte at offset,
+// or the last token if offset is at the end of n.
+func (n *Node) TokenAt(offset int) *Token {
+	if offset < n.FullOffset() || offset > n.FullEnd() {
+		return nil
+	}
+	var last *Token
+	for _, c := range n.Children() {
+		switch c := c.(type) {
+		case *Node:
+			if offset < c.FullEnd() {
+				return c.TokenAt(offset)
+			}
+			if t := c.LastToken(); t != nil {
+				last = t
+			}
+		case *Token:
+			if offset < c.FullEnd() {
+				return c
+			}
+			last = c
+		}
+	}
+	return last
+}
+
+// Covering returns the innermost node under n, n included, whose text
+// holds the range from start to end.
+func (n *Node) Covering(start, end int) *Node {
+	for _, c := range n.Nodes() {
+		if c.Offset() <= start && end <= c.End() {
+			return c.Covering(start, end)
+		}
+	}
+	return n
+}
+
+// Find returns the node parsed into target, or nil if there is none.
+func (n *Node) Find(target ast.Node) *Node {
+	if n.AST() == target {
+		return n
+	}
+	span := target.SourceSpan()
+	for _, c := range n.Nodes() {
+		if c.FullOffset() <= span.EndOffset && span.StartOffset <= c.FullEnd() {
+			if found := c.Find(target); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// Token is a green token placed in the source, with a link to its parent.
+type Token struct {
+	green  *GreenToken
+	parent *Node
+	offset int
+}
+
+func (t *Token) Type() lexer.TokenType {
+	return t.green.Type
+}
+
+func (t *Token) Green() *GreenToken {
+	return t.green
+}
+
+func (t *Token) Parent() *Node {
+	return t.parent
+}
+
+func (t *Token) FullOffset() int {
+	return t.offset
+}
+
+func (t *Token) FullEnd() int {
+	return t.offset + t.green.width
+}
+
+func (t *Token) Offset() int {
+	return t.offset + t.green.leadingWidth()
+}
+
+func (t *Token) End() int {
+	return t.Offset() + len(t.green.Text)
+}
+
+func (t *Token) Text() string {
+	return t.green.Text
+}
+
+func (t *Token) FullText() string {
+	var sb strings.Builder
+	t.green.writeText(&sb)
+	return sb.String()
+}
+
+func (t *Token) Leading() []Trivia {
+	return t.green.Leading
+}
+
+func (t *Token) Trailing() []Trivia {
+	return t.green.Trailing
+}
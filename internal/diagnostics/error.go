@@ -1 +1,51 @@
 package diagnostics
+
+import "fmt"
+
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityNote
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityNote:
+		return "note"
+	default:
+		return "error"
+	}
+}
+
+type Phase string
+
+const (
+	PhaseLexer    Phase = "lexer"
+	PhaseParser   Phase = "parser"
+	PhaseResolver Phase = "resolver"
+	PhaseCompiler Phase = "compiler"
+	PhaseRuntime  Phase = "runtime"
+)
+
+type Diagnostic struct {
+	Severity Severity
+	Phase    Phase
+	Message  string
+	File     string
+	Line     int
+	Column   int
+	// Length is the number of source bytes the problem covers; 0 marks a
+	// single point.
+	Length int
+}
+
+func (d *Diagnostic) Error() string {
+	if d.Line == 0 {
+		return d.Message
+	}
+	return fmt.Sprintf("%d:%d: %s", d.Line, d.Column, d.Message)
+}
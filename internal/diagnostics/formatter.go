@@ -1 +1,87 @@
 package diagnostics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+type Formatter struct {
+	Source string
+	lines  []string
+}
+
+func NewFormatter(source string) *Formatter {
+	return &Formatter{
+		Source: source,
+		lines:  strings.Split(source, "\n"),
+	}
+}
+
+func (f *Formatter) Format(d *Diagnostic) string {
+	var sb strings.Builder
+
+	if d.File != "" {
+		sb.WriteString(d.File)
+		sb.WriteString(":")
+	}
+	if d.Line > 0 && d.Column > 0 {
+		fmt.Fprintf(&sb, "%d:%d:", d.Line, d.Column)
+	} else if d.Line > 0 {
+		fmt.Fprintf(&sb, "%d:", d.Line)
+	}
+	if sb.Len() > 0 {
+		sb.WriteString(" ")
+	}
+	fmt.Fprintf(&sb, "%s", d.Severity)
+	if d.Phase != "" {
+		fmt.Fprintf(&sb, "[%s]", d.Phase)
+	}
+	sb.WriteString(": ")
+	sb.WriteString(d.Message)
+	sb.WriteString("\n")
+
+	if d.Line > 0 && d.Line <= len(f.lines) {
+		text := strings.TrimRight(f.lines[d.Line-1], "\r")
+		gutter := fmt.Sprintf("%d", d.Line)
+		fmt.Fprintf(&sb, " %s | %s\n", gutter, text)
+		if d.Column > 0 {
+			pad := strings.Repeat(" ", len(gutter))
+			indent, width := underline(text, d.Column, d.Length)
+			fmt.Fprintf(&sb, " %s | %s%s\n", pad, indent, strings.Repeat("^", width))
+		}
+	}
+
+	return sb.String()
+}
+
+func (f *Formatter) Print(w io.Writer, diagnostics []*Diagnostic) {
+	for _, d := range diagnostics {
+		io.WriteString(w, f.Format(d))
+	}
+}
+
+// underline returns the padding that lines a caret up under the given rune
+// column of text, keeping tabs so the alignment survives, and the number
+// of runes the length bytes from there cover on this line.
+func underline(text string, column, length int) (string, int) {
+	var indent strings.Builder
+	start := len(text)
+	for i, r := range text {
+		if column--; column == 0 {
+			start = i
+			break
+		}
+		if r == '\t' {
+			indent.WriteByte('\t')
+		} else {
+			indent.WriteByte(' ')
+		}
+	}
+	if column > 1 {
+		indent.WriteString(strings.Repeat(" ", column-1))
+	}
+	end := min(len(text), start+length)
+	return indent.String(), max(1, utf8.RuneCountInString(text[start:end]))
+}
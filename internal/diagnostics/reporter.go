@@ -1 +1,115 @@
 package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+type positioned interface {
+	Position() (line, column int)
+}
+
+type offsetted interface {
+	Offsets() (start, end int)
+}
+
+type Reporter struct {
+	File        string
+	diagnostics []*Diagnostic
+}
+
+func NewReporter(file string) *Reporter {
+	return &Reporter{File: file}
+}
+
+func (r *Reporter) Report(d *Diagnostic) {
+	if d.File == "" {
+		d.File = r.File
+	}
+	r.diagnostics = append(r.diagnostics, d)
+}
+
+func (r *Reporter) Errorf(phase Phase, line, column int, format string, args ...any) {
+	r.Report(&Diagnostic{
+		Severity: SeverityError,
+		Phase:    phase,
+		Message:  fmt.Sprintf(format, args...),
+		Line:     line,
+		Column:   column,
+	})
+}
+
+// ReportError records err under the given phase, keeping any position the
+// error carries.
+func (r *Reporter) ReportError(phase Phase, err error) {
+	var d *Diagnostic
+	if errors.As(err, &d) {
+		r.Report(d)
+		return
+	}
+	line, column, length := 0, 0, 0
+	var p positioned
+	if errors.As(err, &p) {
+		line, column = p.Position()
+	}
+	var o offsetted
+	if errors.As(err, &o) {
+		start, end := o.Offsets()
+		length = end - start
+	}
+	r.Report(&Diagnostic{
+		Severity: SeverityError,
+		Phase:    phase,
+		Message:  err.Error(),
+		Line:     line,
+		Column:   column,
+		Length:   length,
+	})
+}
+
+// ReportErrors records each error in errs, which is typically a list
+// returned by a phase that recovers and keeps going.
+func ReportErrors[E error](r *Reporter, phase Phase, errs []E) {
+	for _, err := range errs {
+		r.ReportError(phase, err)
+	}
+}
+
+func (r *Reporter) HasErrors() bool {
+	for _, d := range r.diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reporter) Diagnostics() []*Diagnostic {
+	return r.diagnostics
+}
+
+// Sort orders the diagnostics for each file by position, so those from
+// different phases are interleaved in source order. Files keep the order
+// they were first reported in, and diagnostics without a position go last
+// in their file.
+func (r *Reporter) Sort() {
+	files := map[string]int{}
+	for _, d := range r.diagnostics {
+		if _, ok := files[d.File]; !ok {
+			files[d.File] = len(files)
+		}
+	}
+	sort.SliceStable(r.diagnostics, func(i, j int) bool {
+		a, b := r.diagnostics[i], r.diagnostics[j]
+		switch {
+		case a.File != b.File:
+			return files[a.File] < files[b.File]
+		case (a.Line == 0) != (b.Line == 0):
+			return b.Line == 0
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
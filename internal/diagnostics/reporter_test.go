@@ -0,0 +1,21 @@
+package diagnostics
+
+import "testing"
+
+func TestSort(t *testing.T) {
+	r := NewReporter("a.fun")
+	r.Errorf(PhaseLexer, 7, 3, "lexer 7")
+	r.Errorf(PhaseLexer, 8, 1, "lexer 8")
+	r.Report(&Diagnostic{Phase: PhaseRuntime, Message: "no position"})
+	r.Report(&Diagnostic{Phase: PhaseRuntime, File: "b.fun", Line: 1, Column: 1, Message: "other file"})
+	r.Errorf(PhaseParser, 2, 5, "parser 2")
+	r.Errorf(PhaseParser, 7, 1, "parser 7")
+	r.Sort()
+
+	want := []string{"parser 2", "parser 7", "lexer 7", "lexer 8", "no position", "other file"}
+	for i, d := range r.Diagnostics() {
+		if d.Message != want[i] {
+			t.Errorf("diagnostic %d is %q, want %q", i, d.Message, want[i])
+		}
+	}
+}
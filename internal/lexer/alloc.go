@@ -0,0 +1,62 @@
+package lexer
+
+// The lexer allocates in blocks shared by many tokens rather than once per
+// token: decoded text is written to an arena, and interpolation parts,
+// their tokens and trivia lists are copied into slices carved from larger
+// ones. Nothing written is changed afterwards, so the tokens can keep
+// referring to it.
+
+const (
+	// arenaSize is the size of each block of decoded text.
+	arenaSize = 32 << 10
+	// Blocks of parts, tokens or trivia start with minBlock elements and
+	// double in size up to maxBlock, so small inputs stay small.
+	minBlock = 64
+	maxBlock = 4096
+)
+
+// beginText starts a string in the arena. The text written to l.arena
+// until endText is called with the returned mark becomes the string.
+func (l *Lexer) beginText() int {
+	if l.arena.Cap()-l.arena.Len() < arenaSize/8 {
+		// Start a new block; the strings already taken keep the old one.
+		l.arena.Reset()
+		l.arena.Grow(arenaSize)
+	}
+	return l.arena.Len()
+}
+
+// endText returns the text written to the arena since mark.
+func (l *Lexer) endText(mark int) string {
+	return l.arena.String()[mark:]
+}
+
+// keep copies items into *block, starting a new one when it is full, and
+// returns the copy, or nil if there are no items. The copy has no spare
+// capacity, so appending to it cannot overwrite the next one.
+func keep[T any](block *[]T, items []T) []T {
+	if len(items) == 0 {
+		return nil
+	}
+	if cap(*block)-len(*block) < len(items) {
+		*block = make([]T, 0, max(min(2*cap(*block), maxBlock), minBlock, len(items)))
+	}
+	start := len(*block)
+	*block = append(*block, items...)
+	return (*block)[start:len(*block):len(*block)]
+}
+
+// tokenBuffer returns an empty token slice for an interpolation to scan
+// into, reusing one given back by releaseTokens if there is one.
+func (l *Lexer) tokenBuffer() []Token {
+	if n := len(l.spare); n > 0 {
+		buf := l.spare[n-1]
+		l.spare = l.spare[:n-1]
+		return buf[:0]
+	}
+	return make([]Token, 0, 16)
+}
+
+func (l *Lexer) releaseTokens(buf []Token) {
+	l.spare = append(l.spare, buf)
+}
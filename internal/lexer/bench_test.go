@@ -0,0 +1,84 @@
+package lexer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// generated returns about size bytes of varied dotFun source, such as a
+// code generator might write.
+func generated(size int) string {
+	var sb strings.Builder
+	for i := 0; sb.Len() < size; i++ {
+		fmt.Fprintf(&sb, `/// Returns item %d.
+fun item%d(a: Int, b: Int): Int {
+    let total = a * %d + b ** 2 - 0x%X // running total
+    if total >= 1_000 && not (a == b) { return total }
+    return "item ${a} of $b: \t%d".length + 'c'.length + %d.5
+}
+`, i, i, i, i, i, i)
+	}
+	return sb.String()
+}
+
+var benchSizes = []int{1 << 20, 4 << 20}
+
+func BenchmarkLex(b *testing.B) {
+	for _, size := range benchSizes {
+		src := generated(size)
+		b.Run(fmt.Sprintf("%dMB", size>>20), func(b *testing.B) {
+			b.SetBytes(int64(len(src)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				NewLexer(src).Lex()
+			}
+		})
+	}
+}
+
+func BenchmarkLexTrivia(b *testing.B) {
+	for _, size := range benchSizes {
+		src := generated(size)
+		b.Run(fmt.Sprintf("%dMB", size>>20), func(b *testing.B) {
+			b.SetBytes(int64(len(src)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l := NewLexer(src)
+				l.SetMode(KeepTrivia)
+				l.Lex()
+			}
+		})
+	}
+}
+
+func BenchmarkNext(b *testing.B) {
+	for _, size := range benchSizes {
+		src := generated(size)
+		b.Run(fmt.Sprintf("%dMB", size>>20), func(b *testing.B) {
+			b.SetBytes(int64(len(src)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l := NewLexer(src)
+				for l.Next().Type != EOF_TOKEN {
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkReader(b *testing.B) {
+	for _, size := range benchSizes {
+		src := []byte(generated(size))
+		b.Run(fmt.Sprintf("%dMB", size>>20), func(b *testing.B) {
+			b.SetBytes(int64(len(src)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l := NewReaderLexer("", bytes.NewReader(src))
+				for l.Next().Type != EOF_TOKEN {
+				}
+			}
+		})
+	}
+}
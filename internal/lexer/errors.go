@@ -0,0 +1,78 @@
+package lexer
+
+import "fmt"
+
+// Error is a problem found while lexing. StartOffset and EndOffset are
+// byte offsets into the source; Line and Column locate StartOffset.
+type Error struct {
+	Message     string
+	Line        int
+	Column      int
+	StartOffset int
+	EndOffset   int
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Position() (int, int) {
+	return e.Line, e.Column
+}
+
+func (e *Error) Offsets() (int, int) {
+	return e.StartOffset, e.EndOffset
+}
+
+// ErrorList collects every error from one Lex call, in source order.
+type ErrorList []*Error
+
+func (el ErrorList) Error() string {
+	switch len(el) {
+	case 0:
+		return "no errors"
+	case 1:
+		return fmt.Sprintf("%d:%d: %s", el[0].Line, el[0].Column, el[0].Message)
+	}
+	return fmt.Sprintf("%d:%d: %s (and %d more errors)", el[0].Line, el[0].Column, el[0].Message, len(el)-1)
+}
+
+// Err returns el as an error, or nil when it is empty.
+func (el ErrorList) Err() error {
+	if len(el) == 0 {
+		return nil
+	}
+	return el
+}
+
+// errorf creates an error starting at the current token. Its end is set
+// when it is reported, once the lexer has skipped the bad input.
+func (l *Lexer) errorf(format string, args ...any) *Error {
+	return l.errorAt(l.start, -1, format, args...)
+}
+
+func (l *Lexer) errorAt(start, end int, format string, args ...any) *Error {
+	line, column := l.positionAt(start)
+	return &Error{
+		Message:     fmt.Sprintf(format, args...),
+		Line:        line,
+		Column:      column,
+		StartOffset: start,
+		EndOffset:   end,
+	}
+}
+
+func (l *Lexer) report(err *Error) {
+	if err.EndOffset < 0 {
+		err.EndOffset = l.current
+	}
+	// The error ending an interpolation is reported after those found
+	// inside it, though it starts before them.
+	i := len(l.errors)
+	for i > 0 && l.errors[i-1].StartOffset > err.StartOffset {
+		i--
+	}
+	l.errors = append(l.errors, nil)
+	copy(l.errors[i+1:], l.errors[i:])
+	l.errors[i] = err
+}
@@ -0,0 +1,181 @@
+package lexer
+
+// Edit replaces the bytes Start:End of a source with Text.
+type Edit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+func (e Edit) Apply(source string) string {
+	return source[:e.Start] + e.Text + source[e.End:]
+}
+
+// relexLookahead is how many bytes past the end of a token the lexer may
+// look while scanning it.
+const relexLookahead = 4
+
+// Relex returns a lexer over l's source with edit applied, for editors that
+// lex the same file after every keystroke. l must have finished Lex. Only
+// the tokens around the edit are scanned again; the others are reused and
+// moved to their new position. Lex on the result returns exactly what it
+// would for the new source lexed from scratch.
+func (l *Lexer) Relex(edit Edit) *Lexer {
+	n := NewFileLexer(l.file, edit.Apply(l.source))
+	n.mode = l.mode
+	old := l.tokens
+
+	// Keep the tokens ending well before the edit. A kept token must not
+	// be ILLEGAL, since an unterminated literal depends on all the input
+	// after it, nor NEWLINE, since a doc comment before it is still
+	// pending for the next token.
+	r := 0
+	for r < len(old) && old[r].Type != ILLEGAL && old[r].Span.EndOffset+relexLookahead <= edit.Start {
+		r++
+	}
+	for r > 0 && old[r-1].Type == NEWLINE {
+		r--
+	}
+	n.tokens = make([]Token, r, len(old)+len(edit.Text))
+	copy(n.tokens, old[:r])
+	restart := 0
+	if r > 0 {
+		last := &n.tokens[r-1]
+		last.Trailing = nil
+		restart = last.Span.EndOffset
+		n.line, n.column = last.Span.EndLine, last.Span.EndCol
+		n.trailing = last.Type != NEWLINE
+	}
+	n.current = restart
+	for i := range n.tokens {
+		n.replayBracket(n.tokens[:i], n.tokens[i])
+	}
+	for _, err := range l.errors {
+		if err.StartOffset < restart {
+			n.errors = append(n.errors, err)
+		}
+	}
+
+	// Scan until a new token lines up with an old one past the edit, in
+	// the same lexer state, after which the old tokens are reused.
+	delta := len(edit.Text) - (edit.End - edit.Start)
+	replay := &Lexer{}
+	j := 0
+	for !n.isAtEnd() {
+		count := len(n.tokens)
+		n.scan()
+		if len(n.tokens) == count {
+			continue
+		}
+		tok := &n.tokens[len(n.tokens)-1]
+		for j < len(old) && old[j].Span.StartOffset+delta < tok.Span.StartOffset {
+			replay.replayBracket(old[:j], old[j])
+			j++
+		}
+		if j == len(old) || !n.canResync(tok, old[:j+1], edit, delta, replay) {
+			continue
+		}
+
+		lines := tok.Span.StartLine - old[j].Span.StartLine
+		tok.Trailing = shiftTrivia(old[j].Trailing, delta, lines)
+		for _, t := range old[j+1:] {
+			n.tokens = append(n.tokens, t.shift(delta, lines))
+		}
+		for _, err := range l.errors {
+			if err.StartOffset >= old[j].Span.EndOffset {
+				n.errors = append(n.errors, err.shift(delta, lines))
+			}
+		}
+		eof := n.tokens[len(n.tokens)-1]
+		n.current, n.line, n.column = len(n.source), eof.Span.EndLine, eof.Span.EndCol
+		n.eof = true
+		break
+	}
+	return n
+}
+
+// canResync reports whether tok, just scanned, is the last of old moved
+// by delta with the lexer in the state it was in after it, so that
+// everything after it would be scanned the same. replay holds the
+// brackets open before it.
+func (l *Lexer) canResync(tok *Token, old []Token, edit Edit, delta int, replay *Lexer) bool {
+	last := &old[len(old)-1]
+	if last.Span.StartOffset < edit.End || last.Span.StartOffset+delta != tok.Span.StartOffset {
+		return false
+	}
+	if tok.Type == NEWLINE || tok.Type != last.Type || tok.Lexeme != last.Lexeme || tok.Span.StartCol != last.Span.StartCol {
+		return false
+	}
+	after := &Lexer{brackets: append([]byte(nil), replay.brackets...)}
+	after.replayBracket(old[:len(old)-1], *last)
+	return string(l.brackets) == string(after.brackets)
+}
+
+// replayBracket updates the bracket stack for tok, scanned earlier after
+// the tokens in before.
+func (l *Lexer) replayBracket(before []Token, tok Token) {
+	switch tok.Type {
+	case LEFT_PAREN:
+		l.openBracket('(')
+	case LEFT_BRACKET:
+		l.openBracket('[')
+	case LEFT_BRACE:
+		l.openBracket('{')
+	case RIGHT_PAREN:
+		l.closeBracket(')')
+	case RIGHT_BRACKET:
+		l.closeBracket(']')
+	case RIGHT_BRACE:
+		l.closeBracket('}')
+	default:
+		var prev *Token
+		if len(before) > 0 {
+			prev = &before[len(before)-1]
+		}
+		if statementKeywords[tok.Type] && startsLine(prev, tok.Span.StartLine) {
+			l.closeStatement()
+		}
+	}
+}
+
+func (t Token) shift(offset, lines int) Token {
+	t.Span = t.Span.Shift(offset, lines)
+	t.Leading = shiftTrivia(t.Leading, offset, lines)
+	t.Trailing = shiftTrivia(t.Trailing, offset, lines)
+	t.Line += lines
+	if parts := t.Literal.Parts; parts != nil {
+		shifted := make([]StringPart, len(parts))
+		for i, part := range parts {
+			part.Span = part.Span.Shift(offset, lines)
+			if part.Tokens != nil {
+				part.Tokens = make([]Token, len(parts[i].Tokens))
+				for k, inner := range parts[i].Tokens {
+					part.Tokens[k] = inner.shift(offset, lines)
+				}
+			}
+			shifted[i] = part
+		}
+		t.Literal.Parts = shifted
+	}
+	return t
+}
+
+func shiftTrivia(trivia []Trivia, offset, lines int) []Trivia {
+	if trivia == nil {
+		return nil
+	}
+	shifted := make([]Trivia, len(trivia))
+	for i, t := range trivia {
+		t.Span = t.Span.Shift(offset, lines)
+		shifted[i] = t
+	}
+	return shifted
+}
+
+func (e *Error) shift(offset, lines int) *Error {
+	shifted := *e
+	shifted.Line += lines
+	shifted.StartOffset += offset
+	shifted.EndOffset += offset
+	return &shifted
+}
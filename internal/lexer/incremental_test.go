@@ -0,0 +1,85 @@
+package lexer
+
+import (
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// corpus is the program the parser tests use, which has every kind of
+// token and trivia.
+func corpus(t *testing.T) string {
+	data, err := os.ReadFile("../parser/testdata/corpus.fun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func lex(src string, mode Mode) ([]Token, ErrorList) {
+	l := NewLexer(src)
+	l.SetMode(mode)
+	tokens, _ := l.Lex()
+	return tokens, l.Errors()
+}
+
+// randomEdit returns an edit of up to three bytes of src, replaced by
+// one of texts.
+func randomEdit(rng *rand.Rand, src string, texts []string) Edit {
+	start := rng.Intn(len(src) + 1)
+	end := min(start+rng.Intn(4), len(src))
+	return Edit{Start: start, End: end, Text: texts[rng.Intn(len(texts))]}
+}
+
+// TestRelex checks that relexing after random edits gives the same tokens
+// and errors as lexing the edited source from scratch.
+func TestRelex(t *testing.T) {
+	src := corpus(t)
+	texts := []string{"", "x", " ", "\n", "\"", "'", "(", ")", "[", "{", "}", "/*", "*/", "//", "///",
+		"1", ".", "$", "${", "+", "\"\"\"", "r\"", "0x", "ab\ncd", "\nlet ", "@"}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 3000; i++ {
+		edit := randomEdit(rng, src, texts)
+		for _, mode := range []Mode{0, KeepTrivia} {
+			l := NewLexer(src)
+			l.SetMode(mode)
+			l.Lex()
+			relexed := l.Relex(edit)
+			got, _ := relexed.Lex()
+
+			want, wantErrs := lex(edit.Apply(src), mode)
+			if !reflect.DeepEqual(got, want) {
+				for k := range want {
+					if k >= len(got) || !reflect.DeepEqual(got[k], want[k]) {
+						t.Fatalf("edit %+v, mode %d: token %d differs", edit, mode, k)
+					}
+				}
+				t.Fatalf("edit %+v, mode %d: %d tokens, want %d", edit, mode, len(got), len(want))
+			}
+			if gotErrs := relexed.Errors(); len(gotErrs)+len(wantErrs) > 0 && !reflect.DeepEqual(gotErrs, wantErrs) {
+				t.Fatalf("edit %+v, mode %d: errors %v, want %v", edit, mode, gotErrs, wantErrs)
+			}
+		}
+	}
+}
+
+// TestNext checks that Next returns the tokens Lex does.
+func TestNext(t *testing.T) {
+	src := corpus(t)
+	for _, mode := range []Mode{0, KeepTrivia} {
+		want, _ := lex(src, mode)
+		l := NewLexer(src)
+		l.SetMode(mode)
+		var got []Token
+		for {
+			got = append(got, l.Next())
+			if got[len(got)-1].Type == EOF_TOKEN {
+				break
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("mode %d: Next and Lex differ", mode)
+		}
+	}
+}
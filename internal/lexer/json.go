@@ -0,0 +1,124 @@
+package lexer
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+)
+
+// JSONVersion is the version of the JSON token and syntax tree formats
+// described in docs/json.md.
+const JSONVersion = 1
+
+// JSONSpan is the JSON form of a Span. The file is recorded once per
+// document rather than in every span.
+type JSONSpan struct {
+	StartOffset int `json:"startOffset"`
+	EndOffset   int `json:"endOffset"`
+	StartLine   int `json:"startLine"`
+	StartCol    int `json:"startCol"`
+	EndLine     int `json:"endLine"`
+	EndCol      int `json:"endCol"`
+}
+
+func (s Span) JSON() JSONSpan {
+	return JSONSpan{s.StartOffset, s.EndOffset, s.StartLine, s.StartCol, s.EndLine, s.EndCol}
+}
+
+// Span returns s as a Span in file.
+func (s JSONSpan) Span(file string) Span {
+	return Span{file, s.StartOffset, s.EndOffset, s.StartLine, s.StartCol, s.EndLine, s.EndCol}
+}
+
+type jsonTokens struct {
+	Version int         `json:"version"`
+	File    string      `json:"file"`
+	Tokens  []jsonToken `json:"tokens"`
+}
+
+type jsonToken struct {
+	Type     string       `json:"type"`
+	Lexeme   string       `json:"lexeme"`
+	Literal  any          `json:"literal,omitempty"`
+	Span     JSONSpan     `json:"span"`
+	Doc      string       `json:"doc,omitempty"`
+	Leading  []jsonTrivia `json:"leading,omitempty"`
+	Trailing []jsonTrivia `json:"trailing,omitempty"`
+}
+
+type jsonTrivia struct {
+	Kind string   `json:"kind"`
+	Text string   `json:"text"`
+	Span JSONSpan `json:"span"`
+}
+
+type jsonStringPart struct {
+	Text   string      `json:"text,omitempty"`
+	Tokens []jsonToken `json:"tokens,omitempty"`
+	Span   JSONSpan    `json:"span"`
+}
+
+// EncodeJSON writes tokens lexed from file to w as an indented JSON
+// document.
+func EncodeJSON(w io.Writer, file string, tokens []Token) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonTokens{JSONVersion, file, encodeTokens(tokens)})
+}
+
+func encodeTokens(tokens []Token) []jsonToken {
+	out := make([]jsonToken, len(tokens))
+	for i, tok := range tokens {
+		out[i] = jsonToken{
+			Type:     tok.Type.String(),
+			Lexeme:   tok.Lexeme,
+			Literal:  encodeLiteral(tok),
+			Span:     tok.Span.JSON(),
+			Doc:      tok.Doc,
+			Leading:  encodeTrivia(tok.Leading),
+			Trailing: encodeTrivia(tok.Trailing),
+		}
+	}
+	return out
+}
+
+func encodeLiteral(tok Token) any {
+	switch tok.Type {
+	case INT_LITERAL:
+		return tok.Literal.Int
+	case FLOAT_LITERAL:
+		return JSONFloat(tok.Literal.Float)
+	case CHAR_LITERAL:
+		return string(rune(tok.Literal.Int))
+	case STRING_LITERAL:
+		return tok.Literal.Text
+	case INTERPOLATED_STRING:
+		parts := make([]jsonStringPart, len(tok.Literal.Parts))
+		for i, part := range tok.Literal.Parts {
+			parts[i] = jsonStringPart{Text: part.Text, Tokens: encodeTokens(part.Tokens), Span: part.Span.JSON()}
+		}
+		return parts
+	}
+	return nil
+}
+
+func encodeTrivia(trivia []Trivia) []jsonTrivia {
+	if len(trivia) == 0 {
+		return nil
+	}
+	out := make([]jsonTrivia, len(trivia))
+	for i, t := range trivia {
+		out[i] = jsonTrivia{t.Kind.String(), t.Text, t.Span.JSON()}
+	}
+	return out
+}
+
+// JSONFloat returns v as a JSON value: a number, or for infinities and
+// NaN, which JSON numbers cannot hold, the string "+Inf", "-Inf" or "NaN".
+func JSONFloat(v float64) any {
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return v
+}
@@ -1,42 +1,167 @@
 package lexer
 
 import (
-	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
 )
 
+// Lexer turns source text into tokens. line and column always describe
+// the position of current; startLine and startColumn that of start.
+// Columns count Unicode code points, not bytes.
 type Lexer struct {
-	source  string
-	start   int
-	current int
-	line    int
-	column  int
-	tokens  []Token
+	file        string
+	source      string
+	start       int
+	startLine   int
+	startColumn int
+	current     int
+	line        int
+	column      int
+	tokens      []Token
+	errors      ErrorList
+	// brackets holds the open '(', '[' and '{' around current. Newlines
+	// only end statements when it is empty or its innermost entry is '{',
+	// or when the next line starts with a statement keyword.
+	brackets []byte
+	mode     Mode
+	// doc is the pending doc comment for the next token.
+	doc string
+	// trivia is the pending leading trivia for the next token, and
+	// trailing reports whether trivia still belongs to the last token, in
+	// which case it is pending in trailingTrivia.
+	trivia         []Trivia
+	trailing       bool
+	trailingTrivia []Trivia
+	// next is the index in tokens of the token Next returns, and eof
+	// reports whether EOF_TOKEN has been added.
+	next int
+	eof  bool
+	// reader, when not nil, holds the input not yet appended to source.
+	// input holds what has been read, and chunk is the buffer reads go to.
+	reader io.Reader
+	input  strings.Builder
+	chunk  []byte
+	// arena, the blocks and spare are where tokens are allocated from; see
+	// alloc.go. parts and lines hold the parts and, for multi-line ones,
+	// the lines of the strings being scanned.
+	arena       strings.Builder
+	partBlock   []StringPart
+	tokenBlock  []Token
+	triviaBlock []Trivia
+	parts       []StringPart
+	lines       []textLine
+	spare       [][]Token
 }
 
 func NewLexer(source string) *Lexer {
+	return NewFileLexer("", source)
+}
+
+// NewFileLexer is like NewLexer but records file in every token's Span.
+func NewFileLexer(file, source string) *Lexer {
 	return &Lexer{
-		source: source,
-		line:   1,
-		column: 1,
-		tokens: []Token{},
+		file:        file,
+		source:      source,
+		startLine:   1,
+		startColumn: 1,
+		line:        1,
+		column:      1,
+		tokens:      []Token{},
 	}
 }
 
+// Lex scans the whole source. It never stops early: input that cannot be
+// lexed becomes an ILLEGAL token, and the returned error is an ErrorList
+// holding every problem found.
 func (l *Lexer) Lex() ([]Token, error) {
+	if len(l.tokens) == 0 {
+		// Most source has a token every four bytes or more; growing the
+		// slice from empty would copy it many times over.
+		l.tokens = make([]Token, 0, len(l.source)/4+1)
+	}
 	for !l.isAtEnd() {
-		l.start = l.current
-		err := l.scanToken()
-		if err != nil {
-			return nil, err
+		l.scan()
+	}
+	if !l.eof {
+		l.tokens = append(l.tokens, l.eofToken())
+		l.eof = true
+	}
+	return l.tokens, l.errors.Err()
+}
+
+func (l *Lexer) Errors() ErrorList {
+	return l.errors
+}
+
+// scan lexes one token, turning a failure into an error report plus an
+// ILLEGAL token covering the skipped input.
+func (l *Lexer) scan() {
+	l.mark()
+	count := len(l.tokens)
+	err := l.scanToken()
+	if err == nil {
+		if l.mode&KeepTrivia != 0 && len(l.tokens) == count && l.current > l.start {
+			l.addTrivia()
 		}
+		return
+	}
+	lexErr, ok := err.(*Error)
+	if !ok {
+		lexErr = l.errorf("%s", err)
+	}
+	if l.current == l.start {
+		l.advance()
+	}
+	l.report(lexErr)
+	l.addToken(ILLEGAL)
+}
+
+// mark starts a new token at the current position.
+func (l *Lexer) mark() {
+	l.start = l.current
+	l.startLine = l.line
+	l.startColumn = l.column
+}
+
+func (l *Lexer) eofToken() Token {
+	l.endTrailing()
+	return Token{
+		Type:    EOF_TOKEN,
+		Lexeme:  "",
+		Line:    l.line,
+		Column:  l.column,
+		Span:    l.spanAt(l.current, l.current),
+		Leading: l.takeTrivia(),
+	}
+}
+
+// tokenSpan is the span from start to current.
+func (l *Lexer) tokenSpan() Span {
+	return Span{
+		File:        l.file,
+		StartOffset: l.start,
+		EndOffset:   l.current,
+		StartLine:   l.startLine,
+		StartCol:    l.startColumn,
+		EndLine:     l.line,
+		EndCol:      l.column,
+	}
+}
+
+// spanAt builds a span for an arbitrary byte range.
+func (l *Lexer) spanAt(start, end int) Span {
+	startLine, startCol := l.positionAt(start)
+	endLine, endCol := l.positionAt(end)
+	return Span{
+		File:        l.file,
+		StartOffset: start,
+		EndOffset:   end,
+		StartLine:   startLine,
+		StartCol:    startCol,
+		EndLine:     endLine,
+		EndCol:      endCol,
 	}
-	l.tokens = append(l.tokens, Token{
-		Type:   EOF_TOKEN,
-		Lexeme: "",
-		Line:   l.line,
-		Column: l.column,
-	})
-	return l.tokens, nil
 }
 
 func (l *Lexer) scanToken() error {
@@ -46,8 +171,9 @@ func (l *Lexer) scanToken() error {
 	case ' ', '\r', '\t':
 		return nil
 	case '\n':
-		l.line++
-		l.column = 0
+		if l.newlineEndsStatement() {
+			return l.addToken(NEWLINE)
+		}
 		return nil
 
 	case '/':
@@ -55,32 +181,44 @@ func (l *Lexer) scanToken() error {
 			for !l.isAtEnd() && l.peek() != '\n' {
 				l.advance()
 			}
+			if comment := l.source[l.start:l.current]; isDocComment(comment) {
+				l.addDoc(comment)
+			}
 			return nil
 		} else if l.match('*') {
-			for {
-				if l.isAtEnd() {
-					return fmt.Errorf("Unterminated block comment at line %d", l.line)
-				}
-				if l.peek() == '*' && l.peekNext() == '/' {
-					l.advance()
-					l.advance()
-					break
-				}
-				if l.peek() == '\n' {
-					l.line++
-					l.column = 0
-				}
-				l.advance()
+			if err := l.blockComment(); err != nil {
+				return err
+			}
+			if comment := l.source[l.start:l.current]; isDocComment(comment) {
+				l.addDoc(comment)
 			}
 			return nil
 		}
+		if l.match('=') {
+			return l.addToken(SLASH_EQUAL)
+		}
 		return l.addToken(SLASH)
 
+	case '#':
+		// A shebang line lets scripts be run directly.
+		if l.start == 0 && l.match('!') {
+			for !l.isAtEnd() && l.peek() != '\n' {
+				l.advance()
+			}
+			return nil
+		}
+
 	case '"':
-		return l.string()
+		return l.string(false)
 
 	case '\'':
-		return l.string()
+		return l.char()
+
+	case 'r':
+		if l.match('"') {
+			return l.string(true)
+		}
+		return l.identifier()
 
 	default:
 		if isDigit(c) {
@@ -89,6 +227,10 @@ func (l *Lexer) scanToken() error {
 		if isAlpha(c) || c == '_' {
 			return l.identifier()
 		}
+		if r, _ := l.runeAt(l.start); c >= utf8.RuneSelf && isIdentStart(r) {
+			l.advanceRune()
+			return l.identifier()
+		}
 	}
 
 	switch c {
@@ -96,6 +238,9 @@ func (l *Lexer) scanToken() error {
 		if l.match('+') {
 			return l.addToken(PLUS_PLUS)
 		}
+		if l.match('=') {
+			return l.addToken(PLUS_EQUAL)
+		}
 		return l.addToken(PLUS)
 	case '-':
 		if l.match('-') {
@@ -104,36 +249,64 @@ func (l *Lexer) scanToken() error {
 		if l.match('>') {
 			return l.addToken(ARROW)
 		}
+		if l.match('=') {
+			return l.addToken(MINUS_EQUAL)
+		}
 		return l.addToken(MINUS)
 	case '*':
+		if l.match('*') {
+			return l.addToken(STAR_STAR)
+		}
+		if l.match('=') {
+			return l.addToken(STAR_EQUAL)
+		}
 		return l.addToken(STAR)
 	case '%':
+		if l.match('=') {
+			return l.addToken(PERCENT_EQUAL)
+		}
 		return l.addToken(PERCENT)
 	case '=':
 		if l.match('=') {
 			return l.addToken(EQUAL_EQUAL)
 		}
+		if l.match('>') {
+			return l.addToken(FAT_ARROW)
+		}
 		return l.addToken(EQUAL)
 	case '!':
 		if l.match('=') {
-			return l.addToken(NOT_BANG)
+			return l.addToken(BANG_EQUAL)
 		}
 		return l.addToken(NOT_BANG)
 	case '&':
 		if l.match('&') {
 			return l.addToken(AND_AND)
 		}
+		if l.match('=') {
+			return l.addToken(BIT_AND_EQUAL)
+		}
 		return l.addToken(BIT_AND)
 	case '|':
 		if l.match('|') {
 			return l.addToken(OR_OR)
 		}
+		if l.match('=') {
+			return l.addToken(BIT_OR_EQUAL)
+		}
 		return l.addToken(BIT_OR)
+	case '^':
+		return l.addToken(BIT_XOR)
+	case '~':
+		return l.addToken(TILDE)
 	case '>':
 		if l.match('=') {
 			return l.addToken(GREATER_EQUAL)
 		}
 		if l.match('>') {
+			if l.match('=') {
+				return l.addToken(SHIFT_RIGHT_EQUAL)
+			}
 			return l.addToken(SHIFT_RIGHT)
 		}
 		return l.addToken(GREATER)
@@ -142,6 +315,9 @@ func (l *Lexer) scanToken() error {
 			return l.addToken(LESS_EQUAL)
 		}
 		if l.match('<') {
+			if l.match('=') {
+				return l.addToken(SHIFT_LEFT_EQUAL)
+			}
 			return l.addToken(SHIFT_LEFT)
 		}
 		return l.addToken(LESS)
@@ -151,10 +327,22 @@ func (l *Lexer) scanToken() error {
 		}
 		return l.addToken(COLON)
 	case '?':
+		if l.match('.') {
+			return l.addToken(QUESTION_DOT)
+		}
+		if l.match('?') {
+			return l.addToken(QUESTION_QUESTION)
+		}
 		return l.addToken(QUESTION)
 	case '.':
-		if l.match('.') && l.match('.') {
-			return l.addToken(ELLIPSIS)
+		if l.match('.') {
+			if l.match('.') {
+				return l.addToken(ELLIPSIS)
+			}
+			if l.match('<') {
+				return l.addToken(DOT_DOT_LESS)
+			}
+			return l.addToken(DOT_DOT)
 		}
 		return l.addToken(DOT)
 	case ',':
@@ -162,53 +350,171 @@ func (l *Lexer) scanToken() error {
 	case ';':
 		return l.addToken(SEMICOLON)
 	case '(':
+		l.openBracket(c)
 		return l.addToken(LEFT_PAREN)
 	case ')':
+		l.closeBracket(c)
 		return l.addToken(RIGHT_PAREN)
 	case '{':
+		l.openBracket(c)
 		return l.addToken(LEFT_BRACE)
 	case '}':
+		l.closeBracket(c)
 		return l.addToken(RIGHT_BRACE)
 	case '[':
+		l.openBracket(c)
 		return l.addToken(LEFT_BRACKET)
 	case ']':
+		l.closeBracket(c)
 		return l.addToken(RIGHT_BRACKET)
 	case '$':
 		return l.addToken(DOLLAR)
 	default:
-		return fmt.Errorf("Unexpected character '%c' at line %d column %d", c, l.line, l.column)
+		l.advanceRune()
+		r, size := l.runeAt(l.start)
+		if r == utf8.RuneError && size == 1 {
+			return l.errorf("Invalid UTF-8 byte 0x%02X", l.source[l.start])
+		}
+		return l.errorf("Unexpected character '%c'", r)
 	}
+}
 
-	return nil
+func (l *Lexer) openBracket(c byte) {
+	l.brackets = append(l.brackets, c)
+}
+
+// closeBracket pops the bracket closed by c. A '}' also closes any '(' or
+// '[' left open inside its block, and a ')' or ']' never closes a '{'.
+func (l *Lexer) closeBracket(c byte) {
+	n := len(l.brackets)
+	if c == '}' {
+		for n > 0 && l.brackets[n-1] != '{' {
+			n--
+		}
+	}
+	if n > 0 && (c == '}' || l.brackets[n-1] != '{') {
+		n--
+	}
+	l.brackets = l.brackets[:n]
+}
+
+// closeStatement drops the '(' and '[' left open in the current block,
+// which a statement keyword at the start of a line shows were never
+// closed.
+func (l *Lexer) closeStatement() {
+	n := len(l.brackets)
+	for n > 0 && l.brackets[n-1] != '{' {
+		n--
+	}
+	l.brackets = l.brackets[:n]
+}
+
+// statementKeywords can only start a statement or declaration, so one at
+// the start of a line inside '(' or '[' means a bracket was left open.
+var statementKeywords = map[TokenType]bool{
+	FUN: true, LET: true, VAL: true,
+	CLASS: true, INTERFACE: true, STRUCT: true, ENUM: true,
+	PUBLIC: true, PROTECTED: true, PRIVATE: true, OVERRIDE: true, ASYNC: true,
+	IF: true, WHILE: true, FOR: true, RETURN: true, BREAK: true, CONTINUE: true,
+	TRY: true, THROW: true, IMPORT: true, EXPORT: true,
+}
+
+// startsLine reports whether a token on line, scanned after prev, is the
+// first on its line. prev is nil for the first token.
+func startsLine(prev *Token, line int) bool {
+	return prev == nil || prev.Type == NEWLINE || prev.Span.EndLine < line
+}
+
+// newlineEndsStatement applies automatic statement termination: a line
+// break outside parentheses and brackets ends the statement when the last
+// token on the line could end one. Inside them it only does so when the
+// next line starts with a statement keyword.
+func (l *Lexer) newlineEndsStatement() bool {
+	if n := len(l.brackets); n > 0 && l.brackets[n-1] != '{' && !l.statementKeywordFollows() {
+		return false
+	}
+	if len(l.tokens) == 0 {
+		return false
+	}
+	switch l.tokens[len(l.tokens)-1].Type {
+	case IDENTIFIER, INT_LITERAL, FLOAT_LITERAL, STRING_LITERAL, CHAR_LITERAL, INTERPOLATED_STRING,
+		TRUE, FALSE, NIL, THIS, SUPER,
+		RIGHT_PAREN, RIGHT_BRACKET, RIGHT_BRACE,
+		PLUS_PLUS, MINUS_MINUS,
+		BREAK, CONTINUE, RETURN,
+		ILLEGAL:
+		return true
+	}
+	return false
+}
+
+// statementKeywordFollows reports whether the first word after the blank
+// space from current is a statement keyword.
+func (l *Lexer) statementKeywordFollows() bool {
+	i := l.current
+	for l.fill(i+1) && (l.source[i] == ' ' || l.source[i] == '\t' || l.source[i] == '\r' || l.source[i] == '\n') {
+		i++
+	}
+	j := i
+	for l.fill(j+1) && (isAlphaNumeric(l.source[j]) || l.source[j] == '_') {
+		j++
+	}
+	if l.fill(j+1) && l.source[j] >= utf8.RuneSelf {
+		return false
+	}
+	return statementKeywords[lookupKeyword(l.source[i:j])]
+}
+
+func (l *Lexer) lastToken() *Token {
+	if len(l.tokens) == 0 {
+		return nil
+	}
+	return &l.tokens[len(l.tokens)-1]
 }
 
 func (l *Lexer) addToken(t TokenType) error {
+	return l.addLiteralToken(t, Literal{})
+}
+
+func (l *Lexer) addLiteralToken(t TokenType, literal Literal) error {
+	l.endTrailing()
 	text := l.source[l.start:l.current]
-	l.tokens = append(l.tokens, Token{
-		Type:   t,
-		Lexeme: text,
-		Line:   l.line,
-		Column: l.column,
-	})
+	tok := Token{
+		Type:    t,
+		Lexeme:  text,
+		Literal: literal,
+		Line:    l.startLine,
+		Column:  l.startColumn,
+		Span:    l.tokenSpan(),
+	}
+	if t != NEWLINE {
+		tok.Doc, l.doc = l.doc, ""
+	}
+	if l.mode&KeepTrivia != 0 {
+		tok.Leading = l.takeTrivia()
+		l.trailing = t != NEWLINE
+	}
+	l.tokens = append(l.tokens, tok)
 	return nil
 }
 
 func (l *Lexer) advance() byte {
 	c := l.source[l.current]
 	l.current++
-	l.column++
+	if c == '\n' {
+		l.line++
+		l.column = 1
+	} else if utf8.RuneStart(c) {
+		l.column++
+	}
 	return c
 }
 
 func (l *Lexer) match(expected byte) bool {
-	if l.isAtEnd() {
-		return false
-	}
-	if l.source[l.current] != expected {
+	if l.isAtEnd() || l.source[l.current] != expected {
 		return false
 	}
-	l.current++
-	l.column++
+	l.advance()
 	return true
 }
 
@@ -220,61 +526,83 @@ func (l *Lexer) peek() byte {
 }
 
 func (l *Lexer) peekNext() byte {
-	if l.current+1 >= len(l.source) {
+	return l.peekAt(1)
+}
+
+func (l *Lexer) peekAt(offset int) byte {
+	if !l.fill(l.current + offset + 1) {
 		return 0
 	}
-	return l.source[l.current+1]
+	return l.source[l.current+offset]
 }
 
 func (l *Lexer) isAtEnd() bool {
-	return l.current >= len(l.source)
+	return l.current >= len(l.source) && !l.fill(l.current+1)
+}
+
+func (l *Lexer) string(raw bool) error {
+	if l.peek() == '"' && l.peekNext() == '"' {
+		l.advance()
+		l.advance()
+		return l.stringBody(raw, true)
+	}
+	return l.stringBody(raw, false)
 }
 
-func (l *Lexer) string() error {
+func (l *Lexer) char() error {
+	contentStart := l.current
 	for {
-		if l.isAtEnd() {
-			return fmt.Errorf("Unterminated string literal at line %d", l.line)
+		if l.isAtEnd() || l.peek() == '\n' {
+			return l.errorf("Unterminated character literal")
 		}
-		if l.peek() == '"' {
-			l.advance()
+		if l.peek() == '\'' {
 			break
 		}
-		if l.peek() == '\n' {
-			l.line++
-			l.column = 0
+		if l.peek() == '\\' {
+			l.advance()
+			if l.isAtEnd() || l.peek() == '\n' {
+				continue
+			}
 		}
 		l.advance()
 	}
-	return l.addToken(STRING_LITERAL)
-}
+	content := l.source[contentStart:l.current]
+	l.advance()
 
-func (l *Lexer) number() error {
-	for isDigit(l.peek()) {
-		l.advance()
+	value := content
+	if strings.Contains(content, "\\") {
+		mark := l.beginText()
+		l.unescape(&l.arena, content, contentStart)
+		value = l.endText(mark)
 	}
-
-	if l.peek() == '.' && isDigit(l.peekNext()) {
-		l.advance()
-
-		for isDigit(l.peek()) {
-			l.advance()
-		}
+	if utf8.RuneCountInString(value) != 1 {
+		return l.errorf("Character literal must contain exactly one character")
 	}
-
-	return l.addToken(NUMBER_LITERAL)
+	r, _ := utf8.DecodeRuneInString(value)
+	return l.addLiteralToken(CHAR_LITERAL, Literal{Int: int64(r)})
 }
 
 func (l *Lexer) identifier() error {
-	for isAlphaNumeric(l.peek()) || l.peek() == '_' {
-		l.advance()
+	for r, size := l.peekRune(); size > 0 && isIdentPart(r); r, size = l.peekRune() {
+		for ; size > 0; size-- {
+			l.advance()
+		}
 	}
 
 	text := l.source[l.start:l.current]
 
 	tokType := lookupKeyword(text)
+	if statementKeywords[tokType] && startsLine(l.lastToken(), l.startLine) {
+		l.closeStatement()
+	}
 	return l.addToken(tokType)
 }
 
+// lookupKeyword returns the token type of a hard keyword, or IDENTIFIER.
+// The contextual keywords constructor, data, default, global and turn are
+// identifiers here; the parser recognises them by their lexeme where a
+// declaration or clause can start, so they stay usable as names. in, is
+// and of are plain identifiers, as no part of the grammar uses them.
 func lookupKeyword(ident string) TokenType {
 	switch ident {
 	case "class":
@@ -305,10 +633,6 @@ func lookupKeyword(ident string) TokenType {
 		return NEW
 	case "super":
 		return SUPER
-	case "constructor":
-		return CONSTRUCTOR
-	case "data":
-		return DATA
 	case "instanceof":
 		return INSTANCEOF
 	case "if":
@@ -343,20 +667,12 @@ func lookupKeyword(ident string) TokenType {
 		return LET
 	case "val":
 		return VAL
-	case "global":
-		return GLOBAL
 	case "and":
 		return AND
 	case "or":
 		return OR
 	case "not":
 		return NOT
-	case "is":
-		return IS
-	case "in":
-		return IN
-	case "of":
-		return OF
 	case "try":
 		return TRY
 	case "catch":
@@ -365,12 +681,8 @@ func lookupKeyword(ident string) TokenType {
 		return FINALLY
 	case "throw":
 		return THROW
-	case "turn":
-		return TURN
 	case "case":
 		return CASE
-	case "default":
-		return DEFAULT
 	default:
 		return IDENTIFIER
 	}
@@ -387,3 +699,27 @@ func isAlpha(c byte) bool {
 func isAlphaNumeric(c byte) bool {
 	return isAlpha(c) || isDigit(c)
 }
+
+// blockComment consumes a block comment whose opening "/*" has been
+// consumed. Block comments nest, so code that already holds one can be
+// commented out.
+func (l *Lexer) blockComment() error {
+	depth := 1
+	for depth > 0 {
+		switch {
+		case l.isAtEnd():
+			return l.errorf("Unterminated block comment")
+		case l.peek() == '/' && l.peekNext() == '*':
+			l.advance()
+			l.advance()
+			depth++
+		case l.peek() == '*' && l.peekNext() == '/':
+			l.advance()
+			l.advance()
+			depth--
+		default:
+			l.advance()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,820 @@
+package lexer
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// types lexes src and returns its token types, with the lexeme of each
+// IDENTIFIER and keyword, separated by spaces.
+func types(src string) string {
+	tokens, _ := NewLexer(src).Lex()
+	var words []string
+	for _, tok := range tokens {
+		switch {
+		case tok.Type == EOF_TOKEN:
+		case tok.Type == IDENTIFIER || statementKeywords[tok.Type]:
+			words = append(words, tok.Lexeme)
+		default:
+			words = append(words, tok.Type.String())
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func TestNewlineAfterIllegal(t *testing.T) {
+	for _, src := range []string{
+		"let z = \"unterminated\nlet a = 1",
+		"let z = 0b12\nlet a = 1",
+		"let z = ''\nlet a = 1",
+		"let z = 12px\nlet a = 1",
+		"let z = 1 @\nlet a = 1",
+	} {
+		got := types(src)
+		if !strings.Contains(got, "ILLEGAL NEWLINE let a") {
+			t.Errorf("%q: no NEWLINE after ILLEGAL in %s", src, got)
+		}
+	}
+}
+
+func TestUnclosedBracket(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		// A statement keyword at the start of a line closes what is open.
+		{"print(a\nlet x = 1\nx", "print LEFT_PAREN a NEWLINE let x EQUAL INT_LITERAL NEWLINE x"},
+		{"let a = [1, 2\n\nreturn a\na", "let a EQUAL LEFT_BRACKET INT_LITERAL COMMA INT_LITERAL NEWLINE return a NEWLINE a"},
+		{"f(1,\nfun g() {}\ng", "f LEFT_PAREN INT_LITERAL COMMA fun g LEFT_PAREN RIGHT_PAREN LEFT_BRACE RIGHT_BRACE NEWLINE g"},
+		// A '}' closes what was left open in its block.
+		{"{ f(1\n}\na\nb", "LEFT_BRACE f LEFT_PAREN INT_LITERAL RIGHT_BRACE NEWLINE a NEWLINE b"},
+		// Calls may still span lines, and keywords later on a line or
+		// other words do not close anything.
+		{"f(1,\n  2)\nx", "f LEFT_PAREN INT_LITERAL COMMA INT_LITERAL RIGHT_PAREN NEWLINE x"},
+		{"f(a\n  , b)\nx", "f LEFT_PAREN a COMMA b RIGHT_PAREN NEWLINE x"},
+		{"f(x => {\n  return x\n})\ny", "f LEFT_PAREN x FAT_ARROW LEFT_BRACE return x NEWLINE RIGHT_BRACE RIGHT_PAREN NEWLINE y"},
+		{"f(lets\n)\ny", "f LEFT_PAREN lets RIGHT_PAREN NEWLINE y"},
+	}
+	for _, tt := range tests {
+		if got := types(tt.src); got != tt.want {
+			t.Errorf("%q:\n got %s\nwant %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+// tokenCase is a case for checkTokens. Every token of src but the final
+// EOF_TOKEN is compared with tokens, and every error with errors, which
+// are written as "span: message".
+type tokenCase struct {
+	src    string
+	tokens []tokenWant
+	errors []string
+}
+
+// tokenWant describes a token by its type, lexeme, span, written
+// "line:col-line:col", and decoded value, if it has one.
+type tokenWant struct {
+	typ    TokenType
+	lexeme string
+	span   string
+	value  string
+}
+
+func checkTokens(t *testing.T, mode Mode, tests []tokenCase) {
+	t.Helper()
+	for _, tt := range tests {
+		l := NewLexer(tt.src)
+		l.SetMode(mode)
+		tokens, _ := l.Lex()
+		checkSpans(t, tt.src, tokens)
+		var got []tokenWant
+		for _, tok := range tokens[:len(tokens)-1] {
+			got = append(got, tokenWant{tok.Type, tok.Lexeme, spanString(tok.Span), value(tok)})
+		}
+		if !reflect.DeepEqual(got, tt.tokens) {
+			t.Errorf("%q: tokens\n%s\nwant\n%s", tt.src, tokenList(got), tokenList(tt.tokens))
+		}
+
+		var errors []string
+		for _, err := range l.Errors() {
+			line, col := position(tt.src, err.EndOffset)
+			errors = append(errors, fmt.Sprintf("%d:%d-%d:%d: %s", err.Line, err.Column, line, col, err.Message))
+		}
+		if strings.Join(errors, "\n") != strings.Join(tt.errors, "\n") {
+			t.Errorf("%q: errors\n%s\nwant\n%s", tt.src, strings.Join(errors, "\n"), strings.Join(tt.errors, "\n"))
+		}
+	}
+}
+
+// checkSpans checks that each token's lexeme, and that of each token in an
+// interpolation, is the source its span covers.
+func checkSpans(t *testing.T, src string, tokens []Token) {
+	t.Helper()
+	for _, tok := range tokens {
+		if span := tok.Span; tok.Lexeme != src[span.StartOffset:span.EndOffset] {
+			t.Errorf("%q: %s covers %q", src, tok, src[span.StartOffset:span.EndOffset])
+		}
+		for _, part := range tok.Literal.Parts {
+			checkSpans(t, src, part.Tokens)
+		}
+	}
+}
+
+func spanString(s Span) string {
+	return fmt.Sprintf("%d:%d-%d:%d", s.StartLine, s.StartCol, s.EndLine, s.EndCol)
+}
+
+// value formats the decoded value of a literal token, writing the parts
+// of an interpolated string as quoted text and ${lexemes}.
+func value(tok Token) string {
+	switch tok.Type {
+	case INT_LITERAL:
+		return strconv.FormatInt(tok.Literal.Int, 10)
+	case FLOAT_LITERAL:
+		return strconv.FormatFloat(tok.Literal.Float, 'g', -1, 64)
+	case CHAR_LITERAL:
+		return strconv.QuoteRune(rune(tok.Literal.Int))
+	case STRING_LITERAL:
+		return strconv.Quote(tok.Literal.Text)
+	case INTERPOLATED_STRING:
+		var parts []string
+		for _, part := range tok.Literal.Parts {
+			if !part.IsExpr() {
+				parts = append(parts, strconv.Quote(part.Text))
+				continue
+			}
+			var lexemes []string
+			for _, inner := range part.Tokens[:len(part.Tokens)-1] {
+				lexemes = append(lexemes, inner.Lexeme)
+			}
+			parts = append(parts, "${"+strings.Join(lexemes, " ")+"}")
+		}
+		return strings.Join(parts, " ")
+	}
+	return ""
+}
+
+func tokenList(tokens []tokenWant) string {
+	var lines []string
+	for _, tok := range tokens {
+		lines = append(lines, fmt.Sprintf("\t{%s, %q, %q, %q},", tok.typ, tok.lexeme, tok.span, tok.value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// position returns the line and rune column of offset in src.
+func position(src string, offset int) (int, int) {
+	before := src[:offset]
+	line := strings.Count(before, "\n") + 1
+	return line, utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:]) + 1
+}
+
+func TestEscapes(t *testing.T) {
+	checkTokens(t, 0, []tokenCase{
+		{src: `"a\n\t\r\\\"\'\$\0b"`, tokens: []tokenWant{
+			{STRING_LITERAL, `"a\n\t\r\\\"\'\$\0b"`, "1:1-1:21", `"a\n\t\r\\\"'$\x00b"`},
+		}},
+		{src: `"\x41\u{1F600}\u{e9}"`, tokens: []tokenWant{
+			{STRING_LITERAL, `"\x41\u{1F600}\u{e9}"`, "1:1-1:22", `"A😀é"`},
+		}},
+		// A bad escape is decoded as U+FFFD and the string still ends.
+		{src: `"bad \q end"`, tokens: []tokenWant{
+			{STRING_LITERAL, `"bad \q end"`, "1:1-1:13", "\"bad \uFFFD end\""},
+		}, errors: []string{`1:6-1:8: Invalid escape sequence '\q'`}},
+		{src: `"\x4g"`, tokens: []tokenWant{
+			{STRING_LITERAL, `"\x4g"`, "1:1-1:7", "\"\uFFFD\""},
+		}, errors: []string{`1:2-1:6: Expected two hex digits after '\x'`}},
+		{src: `"\xff"`, tokens: []tokenWant{
+			{STRING_LITERAL, `"\xff"`, "1:1-1:7", "\"\uFFFD\""},
+		}, errors: []string{`1:2-1:6: Escape '\xff' is outside ASCII, use '\u{...}'`}},
+		{src: `"\u{}"`, tokens: []tokenWant{
+			{STRING_LITERAL, `"\u{}"`, "1:1-1:7", "\"\uFFFD\""},
+		}, errors: []string{"1:2-1:6: Unicode escape must have 1 to 6 hex digits"}},
+		{src: `"\u{1234567}"`, tokens: []tokenWant{
+			{STRING_LITERAL, `"\u{1234567}"`, "1:1-1:14", "\"\uFFFD\""},
+		}, errors: []string{"1:2-1:13: Unicode escape must have 1 to 6 hex digits"}},
+		{src: `"\u{D800}"`, tokens: []tokenWant{
+			{STRING_LITERAL, `"\u{D800}"`, "1:1-1:11", "\"\uFFFD\""},
+		}, errors: []string{"1:2-1:10: Invalid unicode code point U+D800"}},
+		{src: `"\u{12"`, tokens: []tokenWant{
+			{STRING_LITERAL, `"\u{12"`, "1:1-1:8", "\"\uFFFD\""},
+		}, errors: []string{"1:2-1:7: Unterminated unicode escape"}},
+		{src: `"\u{zz}"`, tokens: []tokenWant{
+			{STRING_LITERAL, `"\u{zz}"`, "1:1-1:9", "\"\uFFFD\""},
+		}, errors: []string{"1:2-1:8: Invalid hex digits 'zz' in unicode escape"}},
+		{src: `"ok" + "\é"`, tokens: []tokenWant{
+			{STRING_LITERAL, `"ok"`, "1:1-1:5", `"ok"`},
+			{PLUS, "+", "1:6-1:7", ""},
+			{STRING_LITERAL, `"\é"`, "1:8-1:12", "\"\uFFFD\""},
+		}, errors: []string{`1:9-1:11: Invalid escape sequence '\é'`}},
+	})
+}
+
+func TestRawAndMultilineStrings(t *testing.T) {
+	checkTokens(t, 0, []tokenCase{
+		{src: `r"raw \n $x \q"`, tokens: []tokenWant{
+			{STRING_LITERAL, `r"raw \n $x \q"`, "1:1-1:16", `"raw \\n $x \\q"`},
+		}},
+		{src: `"unterminated`, tokens: []tokenWant{
+			{ILLEGAL, `"unterminated`, "1:1-1:14", ""},
+		}, errors: []string{"1:1-1:14: Unterminated string literal"}},
+		// Indentation shared by the lines of a multi-line string is removed,
+		// along with the lines holding the quotes.
+		{src: "\"\"\"\n    hello\n      \"world\"\n    \"\"\"", tokens: []tokenWant{
+			{STRING_LITERAL, "\"\"\"\n    hello\n      \"world\"\n    \"\"\"", "1:1-4:8", `"hello\n  \"world\""`},
+		}},
+		{src: "r\"\"\"\n  raw \\n\n  $x\n  \"\"\"", tokens: []tokenWant{
+			{STRING_LITERAL, "r\"\"\"\n  raw \\n\n  $x\n  \"\"\"", "1:1-4:6", `"raw \\n\n$x"`},
+		}},
+		{src: `"""one "" two"""`, tokens: []tokenWant{
+			{STRING_LITERAL, `"""one "" two"""`, "1:1-1:17", `"one \"\" two"`},
+		}},
+		{src: `"""never closed`, tokens: []tokenWant{
+			{ILLEGAL, `"""`, "1:1-1:4", ""},
+			{IDENTIFIER, "never", "1:4-1:9", ""},
+			{IDENTIFIER, "closed", "1:10-1:16", ""},
+		}, errors: []string{"1:1-1:4: Unterminated multi-line string"}},
+		{src: "\"\"\"\n  a\\tb\n  \\u{41}\n\"\"\"", tokens: []tokenWant{
+			{STRING_LITERAL, "\"\"\"\n  a\\tb\n  \\u{41}\n\"\"\"", "1:1-4:4", `"a\tb\nA"`},
+		}},
+	})
+}
+
+func TestCharLiterals(t *testing.T) {
+	checkTokens(t, 0, []tokenCase{
+		{src: "'a' 'é' '😀'", tokens: []tokenWant{
+			{CHAR_LITERAL, "'a'", "1:1-1:4", "'a'"},
+			{CHAR_LITERAL, "'é'", "1:5-1:8", "'é'"},
+			{CHAR_LITERAL, "'😀'", "1:9-1:12", "'😀'"},
+		}},
+		{src: `'\n' '\'' '"' '\u{263A}' '\x41'`, tokens: []tokenWant{
+			{CHAR_LITERAL, `'\n'`, "1:1-1:5", `'\n'`},
+			{CHAR_LITERAL, `'\''`, "1:6-1:10", `'\''`},
+			{CHAR_LITERAL, `'"'`, "1:11-1:14", `'"'`},
+			{CHAR_LITERAL, `'\u{263A}'`, "1:15-1:25", "'☺'"},
+			{CHAR_LITERAL, `'\x41'`, "1:26-1:32", "'A'"},
+		}},
+		// A char literal holds exactly one code point, after escapes are decoded.
+		{src: "''", tokens: []tokenWant{
+			{ILLEGAL, "''", "1:1-1:3", ""},
+		}, errors: []string{"1:1-1:3: Character literal must contain exactly one character"}},
+		{src: "'ab'", tokens: []tokenWant{
+			{ILLEGAL, "'ab'", "1:1-1:5", ""},
+		}, errors: []string{"1:1-1:5: Character literal must contain exactly one character"}},
+		{src: `'\q'`, tokens: []tokenWant{
+			{CHAR_LITERAL, `'\q'`, "1:1-1:5", "'\uFFFD'"},
+		}, errors: []string{`1:2-1:4: Invalid escape sequence '\q'`}},
+		// An unterminated one ends at the line, which still ends the statement.
+		{src: "'a\nlet b = 1", tokens: []tokenWant{
+			{ILLEGAL, "'a", "1:1-1:3", ""},
+			{NEWLINE, "\n", "1:3-2:1", ""},
+			{LET, "let", "2:1-2:4", ""},
+			{IDENTIFIER, "b", "2:5-2:6", ""},
+			{EQUAL, "=", "2:7-2:8", ""},
+			{INT_LITERAL, "1", "2:9-2:10", "1"},
+		}, errors: []string{"1:1-1:3: Unterminated character literal"}},
+		{src: `'\u{1F600'`, tokens: []tokenWant{
+			{CHAR_LITERAL, `'\u{1F600'`, "1:1-1:11", "'\uFFFD'"},
+		}, errors: []string{"1:2-1:10: Unterminated unicode escape"}},
+	})
+}
+
+func TestInterpolation(t *testing.T) {
+	checkTokens(t, 0, []tokenCase{
+		{src: `"Hello $user, you owe ${total * 1.2}!"`, tokens: []tokenWant{
+			{INTERPOLATED_STRING, `"Hello $user, you owe ${total * 1.2}!"`, "1:1-1:39", `"Hello " ${user} ", you owe " ${total * 1.2} "!"`},
+		}},
+		{src: `"a ${ "b ${c + 1} d" } e"`, tokens: []tokenWant{
+			{INTERPOLATED_STRING, `"a ${ "b ${c + 1} d" } e"`, "1:1-1:26", `"a " ${"b ${c + 1} d"} " e"`},
+		}},
+		{src: `"${f({x: 1}) }"`, tokens: []tokenWant{
+			{INTERPOLATED_STRING, `"${f({x: 1}) }"`, "1:1-1:16", "${f ( { x : 1 } )}"},
+		}},
+		// A '$' not followed by a name or '{' is text.
+		{src: `"$1 $ \$x $é"`, tokens: []tokenWant{
+			{INTERPOLATED_STRING, `"$1 $ \$x $é"`, "1:1-1:14", `"$1 $ $x " ${é}`},
+		}},
+		// Errors are in source order, though the one that ends an
+		// interpolation is found after those inside it.
+		{src: `"${a"`, tokens: []tokenWant{
+			{ILLEGAL, `"${a"`, "1:1-1:6", ""},
+		}, errors: []string{"1:2-1:6: Unterminated interpolation", "1:5-1:6: Unterminated string literal"}},
+		{src: `"${}"`, tokens: []tokenWant{
+			{INTERPOLATED_STRING, `"${}"`, "1:1-1:6", "${}"},
+		}},
+		{src: `"x ${1 @ 2} y"`, tokens: []tokenWant{
+			{INTERPOLATED_STRING, `"x ${1 @ 2} y"`, "1:1-1:15", `"x " ${1 @ 2} " y"`},
+		}, errors: []string{"1:8-1:9: Unexpected character '@'"}},
+		{src: "\"\"\"\n  sum: ${a +\n    b}\n  \"\"\"", tokens: []tokenWant{
+			{INTERPOLATED_STRING, "\"\"\"\n  sum: ${a +\n    b}\n  \"\"\"", "1:1-4:6", `"sum: " ${a + b}`},
+		}},
+	})
+}
+
+func TestNumbers(t *testing.T) {
+	checkTokens(t, 0, []tokenCase{
+		{src: "0 42 1_000_000 0xFF 0Xff_ff 0b1010 0o17 0O7", tokens: []tokenWant{
+			{INT_LITERAL, "0", "1:1-1:2", "0"},
+			{INT_LITERAL, "42", "1:3-1:5", "42"},
+			{INT_LITERAL, "1_000_000", "1:6-1:15", "1000000"},
+			{INT_LITERAL, "0xFF", "1:16-1:20", "255"},
+			{INT_LITERAL, "0Xff_ff", "1:21-1:28", "65535"},
+			{INT_LITERAL, "0b1010", "1:29-1:35", "10"},
+			{INT_LITERAL, "0o17", "1:36-1:40", "15"},
+			{INT_LITERAL, "0O7", "1:41-1:44", "7"},
+		}},
+		{src: "1.5 1e-9 2.5E+3 1_0.2_5 6e2 0.0", tokens: []tokenWant{
+			{FLOAT_LITERAL, "1.5", "1:1-1:4", "1.5"},
+			{FLOAT_LITERAL, "1e-9", "1:5-1:9", "1e-09"},
+			{FLOAT_LITERAL, "2.5E+3", "1:10-1:16", "2500"},
+			{FLOAT_LITERAL, "1_0.2_5", "1:17-1:24", "10.25"},
+			{FLOAT_LITERAL, "6e2", "1:25-1:28", "600"},
+			{FLOAT_LITERAL, "0.0", "1:29-1:32", "0"},
+		}},
+		// Values that do not fit are errors, not rounded.
+		{src: "9223372036854775807 9223372036854775808", tokens: []tokenWant{
+			{INT_LITERAL, "9223372036854775807", "1:1-1:20", "9223372036854775807"},
+			{ILLEGAL, "9223372036854775808", "1:21-1:40", ""},
+		}, errors: []string{"1:21-1:40: Integer literal '9223372036854775808' overflows Int"}},
+		{src: "1e400", tokens: []tokenWant{
+			{ILLEGAL, "1e400", "1:1-1:6", ""},
+		}, errors: []string{"1:1-1:6: Float literal '1e400' is out of range"}},
+		// Malformed literals become a single ILLEGAL token.
+		{src: "0x 0b12 0o8", tokens: []tokenWant{
+			{ILLEGAL, "0x", "1:1-1:3", ""},
+			{ILLEGAL, "0b12", "1:4-1:8", ""},
+			{ILLEGAL, "0o8", "1:9-1:12", ""},
+		}, errors: []string{"1:1-1:3: Expected hex digits after '0x'", "1:4-1:8: Invalid digit '2' in binary literal", "1:9-1:12: Expected octal digits after '0o'"}},
+		{src: "1__0 1_ 0x_1", tokens: []tokenWant{
+			{ILLEGAL, "1__0", "1:1-1:5", ""},
+			{ILLEGAL, "1_", "1:6-1:8", ""},
+			{INT_LITERAL, "0x_1", "1:9-1:13", "1"},
+		}, errors: []string{"1:1-1:5: Digit separator '_' must be between digits", "1:6-1:8: Digit separator '_' must be between digits"}},
+		// A '.' not followed by a digit is not part of the number.
+		{src: "1. 1.e5 1e 1e+", tokens: []tokenWant{
+			{INT_LITERAL, "1", "1:1-1:2", "1"},
+			{DOT, ".", "1:2-1:3", ""},
+			{INT_LITERAL, "1", "1:4-1:5", "1"},
+			{DOT, ".", "1:5-1:6", ""},
+			{IDENTIFIER, "e5", "1:6-1:8", ""},
+			{ILLEGAL, "1e", "1:9-1:11", ""},
+			{ILLEGAL, "1e+", "1:12-1:15", ""},
+		}, errors: []string{"1:9-1:11: Expected digits in the exponent of '1e'", "1:12-1:15: Expected digits in the exponent of '1e+'"}},
+		{src: "12px 3.x", tokens: []tokenWant{
+			{ILLEGAL, "12px", "1:1-1:5", ""},
+			{INT_LITERAL, "3", "1:6-1:7", "3"},
+			{DOT, ".", "1:7-1:8", ""},
+			{IDENTIFIER, "x", "1:8-1:9", ""},
+		}, errors: []string{"1:1-1:5: Invalid character 'p' in number literal"}},
+		{src: "1..5 a.0", tokens: []tokenWant{
+			{INT_LITERAL, "1", "1:1-1:2", "1"},
+			{DOT_DOT, "..", "1:2-1:4", ""},
+			{INT_LITERAL, "5", "1:4-1:5", "5"},
+			{IDENTIFIER, "a", "1:6-1:7", ""},
+			{DOT, ".", "1:7-1:8", ""},
+			{INT_LITERAL, "0", "1:8-1:9", "0"},
+		}},
+		{src: "0777 00", tokens: []tokenWant{
+			{ILLEGAL, "0777", "1:1-1:5", ""},
+			{ILLEGAL, "00", "1:6-1:8", ""},
+		}, errors: []string{"1:1-1:5: Leading zeros are not allowed in '0777', use 0o for octal", "1:6-1:8: Leading zeros are not allowed in '00', use 0o for octal"}},
+		{src: "1ex 2E-", tokens: []tokenWant{
+			{ILLEGAL, "1ex", "1:1-1:4", ""},
+			{ILLEGAL, "2E-", "1:5-1:8", ""},
+		}, errors: []string{"1:1-1:4: Expected digits in the exponent of '1e'", "1:5-1:8: Expected digits in the exponent of '2E-'"}},
+		{src: "1.5.3", tokens: []tokenWant{
+			{FLOAT_LITERAL, "1.5", "1:1-1:4", "1.5"},
+			{DOT, ".", "1:4-1:5", ""},
+			{INT_LITERAL, "3", "1:5-1:6", "3"},
+		}},
+	})
+}
+
+func TestErrorRecovery(t *testing.T) {
+	checkTokens(t, 0, []tokenCase{
+		{src: "let a = 1 @ 2\nlet b = # + 0b2\nlet c = \"open\nlet d = 'xy' ~ `", tokens: []tokenWant{
+			{LET, "let", "1:1-1:4", ""},
+			{IDENTIFIER, "a", "1:5-1:6", ""},
+			{EQUAL, "=", "1:7-1:8", ""},
+			{INT_LITERAL, "1", "1:9-1:10", "1"},
+			{ILLEGAL, "@", "1:11-1:12", ""},
+			{INT_LITERAL, "2", "1:13-1:14", "2"},
+			{NEWLINE, "\n", "1:14-2:1", ""},
+			{LET, "let", "2:1-2:4", ""},
+			{IDENTIFIER, "b", "2:5-2:6", ""},
+			{EQUAL, "=", "2:7-2:8", ""},
+			{ILLEGAL, "#", "2:9-2:10", ""},
+			{PLUS, "+", "2:11-2:12", ""},
+			{ILLEGAL, "0b2", "2:13-2:16", ""},
+			{NEWLINE, "\n", "2:16-3:1", ""},
+			{LET, "let", "3:1-3:4", ""},
+			{IDENTIFIER, "c", "3:5-3:6", ""},
+			{EQUAL, "=", "3:7-3:8", ""},
+			{ILLEGAL, `"open`, "3:9-3:14", ""},
+			{NEWLINE, "\n", "3:14-4:1", ""},
+			{LET, "let", "4:1-4:4", ""},
+			{IDENTIFIER, "d", "4:5-4:6", ""},
+			{EQUAL, "=", "4:7-4:8", ""},
+			{ILLEGAL, "'xy'", "4:9-4:13", ""},
+			{TILDE, "~", "4:14-4:15", ""},
+			{ILLEGAL, "`", "4:16-4:17", ""},
+		}, errors: []string{"1:11-1:12: Unexpected character '@'", "2:9-2:10: Unexpected character '#'", "2:13-2:16: Expected binary digits after '0b'", "3:9-3:14: Unterminated string literal", "4:9-4:13: Character literal must contain exactly one character", "4:16-4:17: Unexpected character '`'"}},
+	})
+}
+
+// TestManyErrors checks that one Lex call reports every error however many
+// there are, and how the list describes itself.
+func TestManyErrors(t *testing.T) {
+	tokens, err := NewLexer(strings.Repeat("a @ b\n", 1000)).Lex()
+	list, ok := err.(ErrorList)
+	if !ok || len(list) != 1000 {
+		t.Fatalf("got %d errors (%v), want 1000", len(list), err)
+	}
+	for i, e := range list {
+		if e.Line != i+1 || e.Column != 3 || e.StartOffset != 6*i+2 || e.EndOffset != 6*i+3 {
+			t.Fatalf("error %d at %d:%d, offsets %d-%d", i, e.Line, e.Column, e.StartOffset, e.EndOffset)
+		}
+	}
+	if len(tokens) != 4*1000+1 {
+		t.Errorf("got %d tokens, want %d", len(tokens), 4*1000+1)
+	}
+
+	if got, want := list.Error(), "1:3: Unexpected character '@' (and 999 more errors)"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if got, want := list[:1].Error(), "1:3: Unexpected character '@'"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if err := ErrorList(nil).Err(); err != nil {
+		t.Errorf("Err() of an empty list = %v, want nil", err)
+	}
+}
+
+func TestSpans(t *testing.T) {
+	checkTokens(t, 0, []tokenCase{
+		// Skipped input moves the next token's span but is not in any.
+		{src: "let x = a /* one\ntwo */ + b", tokens: []tokenWant{
+			{LET, "let", "1:1-1:4", ""},
+			{IDENTIFIER, "x", "1:5-1:6", ""},
+			{EQUAL, "=", "1:7-1:8", ""},
+			{IDENTIFIER, "a", "1:9-1:10", ""},
+			{PLUS, "+", "2:8-2:9", ""},
+			{IDENTIFIER, "b", "2:10-2:11", ""},
+		}},
+		{src: "f(1,\n  2)", tokens: []tokenWant{
+			{IDENTIFIER, "f", "1:1-1:2", ""},
+			{LEFT_PAREN, "(", "1:2-1:3", ""},
+			{INT_LITERAL, "1", "1:3-1:4", "1"},
+			{COMMA, ",", "1:4-1:5", ""},
+			{INT_LITERAL, "2", "2:3-2:4", "2"},
+			{RIGHT_PAREN, ")", "2:4-2:5", ""},
+		}},
+		// A tab is one column.
+		{src: "a\tb", tokens: []tokenWant{
+			{IDENTIFIER, "a", "1:1-1:2", ""},
+			{IDENTIFIER, "b", "1:3-1:4", ""},
+		}},
+		// A NEWLINE token ends at the start of the next line.
+		{src: "a\n  \nb", tokens: []tokenWant{
+			{IDENTIFIER, "a", "1:1-1:2", ""},
+			{NEWLINE, "\n", "1:2-2:1", ""},
+			{IDENTIFIER, "b", "3:1-3:2", ""},
+		}},
+		{src: "\"\"\"\nx\n\"\"\"; y", tokens: []tokenWant{
+			{STRING_LITERAL, "\"\"\"\nx\n\"\"\"", "1:1-3:4", `"x"`},
+			{SEMICOLON, ";", "3:4-3:5", ""},
+			{IDENTIFIER, "y", "3:6-3:7", ""},
+		}},
+		{src: "a\nb\n", tokens: []tokenWant{
+			{IDENTIFIER, "a", "1:1-1:2", ""},
+			{NEWLINE, "\n", "1:2-2:1", ""},
+			{IDENTIFIER, "b", "2:1-2:2", ""},
+			{NEWLINE, "\n", "2:2-3:1", ""},
+		}},
+		// The '\r' of a CRLF is skipped.
+		{src: "let x = 1\r\nx", tokens: []tokenWant{
+			{LET, "let", "1:1-1:4", ""},
+			{IDENTIFIER, "x", "1:5-1:6", ""},
+			{EQUAL, "=", "1:7-1:8", ""},
+			{INT_LITERAL, "1", "1:9-1:10", "1"},
+			{NEWLINE, "\n", "1:11-2:1", ""},
+			{IDENTIFIER, "x", "2:1-2:2", ""},
+		}},
+	})
+}
+
+func TestSpanFile(t *testing.T) {
+	tokens, _ := NewFileLexer("main.fun", "a\n\"b\nc").Lex()
+	for _, tok := range tokens {
+		if tok.Span.File != "main.fun" {
+			t.Errorf("%s: file %q, want main.fun", tok, tok.Span.File)
+		}
+	}
+	eof := tokens[len(tokens)-1]
+	if eof.Type != EOF_TOKEN || spanString(eof.Span) != "3:2-3:2" || eof.Span.StartOffset != 6 || eof.Span.EndOffset != 6 {
+		t.Errorf("EOF span %+v, want an empty span at 3:2", eof.Span)
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	checkTokens(t, 0, []tokenCase{
+		{src: "let préço = 変数 + _x1", tokens: []tokenWant{
+			{LET, "let", "1:1-1:4", ""},
+			{IDENTIFIER, "préço", "1:5-1:10", ""},
+			{EQUAL, "=", "1:11-1:12", ""},
+			{IDENTIFIER, "変数", "1:13-1:15", ""},
+			{PLUS, "+", "1:16-1:17", ""},
+			{IDENTIFIER, "_x1", "1:18-1:21", ""},
+		}},
+		// Combining marks and connectors may continue an identifier.
+		{src: "naïve x́ a‿b", tokens: []tokenWant{
+			{IDENTIFIER, "naïve", "1:1-1:6", ""},
+			{IDENTIFIER, "x́", "1:7-1:9", ""},
+			{IDENTIFIER, "a‿b", "1:10-1:13", ""},
+		}},
+		// Columns count runes, in comments and strings too.
+		{src: "\"日本\" // コメント\nx", tokens: []tokenWant{
+			{STRING_LITERAL, `"日本"`, "1:1-1:5", `"日本"`},
+			{NEWLINE, "\n", "1:13-2:1", ""},
+			{IDENTIFIER, "x", "2:1-2:2", ""},
+		}},
+		// Only letters and '_' start an identifier.
+		{src: "x₁ = 1", tokens: []tokenWant{
+			{IDENTIFIER, "x", "1:1-1:2", ""},
+			{ILLEGAL, "₁", "1:2-1:3", ""},
+			{EQUAL, "=", "1:4-1:5", ""},
+			{INT_LITERAL, "1", "1:6-1:7", "1"},
+		}, errors: []string{"1:2-1:3: Unexpected character '₁'"}},
+		{src: "/* 😀 */ y", tokens: []tokenWant{
+			{IDENTIFIER, "y", "1:9-1:10", ""},
+		}},
+		// An invalid byte is one column.
+		{src: "é\xffz", tokens: []tokenWant{
+			{IDENTIFIER, "é", "1:1-1:2", ""},
+			{ILLEGAL, "\xff", "1:2-1:3", ""},
+			{IDENTIFIER, "z", "1:3-1:4", ""},
+		}, errors: []string{"1:2-1:3: Invalid UTF-8 byte 0xFF"}},
+		{src: "١٢ a١٢", tokens: []tokenWant{
+			{ILLEGAL, "١", "1:1-1:2", ""},
+			{ILLEGAL, "٢", "1:2-1:3", ""},
+			{IDENTIFIER, "a١٢", "1:4-1:7", ""},
+		}, errors: []string{"1:1-1:2: Unexpected character '١'", "1:2-1:3: Unexpected character '٢'"}},
+	})
+}
+
+func TestUTF16Column(t *testing.T) {
+	src := "a😀é\nb"
+	for _, tt := range []struct{ offset, want int }{
+		{0, 1}, {1, 2}, {5, 4}, {7, 5}, {8, 1},
+	} {
+		if got := UTF16Column(src, tt.offset); got != tt.want {
+			t.Errorf("UTF16Column(%q, %d) = %d, want %d", src, tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestNewlines(t *testing.T) {
+	checkTokens(t, 0, []tokenCase{
+		// A line break ends a statement only after a token that can end one.
+		{src: "a +\nb", tokens: []tokenWant{
+			{IDENTIFIER, "a", "1:1-1:2", ""},
+			{PLUS, "+", "1:3-1:4", ""},
+			{IDENTIFIER, "b", "2:1-2:2", ""},
+		}},
+		{src: "return\nx++\ny", tokens: []tokenWant{
+			{RETURN, "return", "1:1-1:7", ""},
+			{NEWLINE, "\n", "1:7-2:1", ""},
+			{IDENTIFIER, "x", "2:1-2:2", ""},
+			{PLUS_PLUS, "++", "2:2-2:4", ""},
+			{NEWLINE, "\n", "2:4-3:1", ""},
+			{IDENTIFIER, "y", "3:1-3:2", ""},
+		}},
+		{src: "{\na\n}\nb", tokens: []tokenWant{
+			{LEFT_BRACE, "{", "1:1-1:2", ""},
+			{IDENTIFIER, "a", "2:1-2:2", ""},
+			{NEWLINE, "\n", "2:2-3:1", ""},
+			{RIGHT_BRACE, "}", "3:1-3:2", ""},
+			{NEWLINE, "\n", "3:2-4:1", ""},
+			{IDENTIFIER, "b", "4:1-4:2", ""},
+		}},
+		// Inside parentheses and brackets it does not, unless a statement
+		// keyword starts the next line.
+		{src: "f(a,\nb)", tokens: []tokenWant{
+			{IDENTIFIER, "f", "1:1-1:2", ""},
+			{LEFT_PAREN, "(", "1:2-1:3", ""},
+			{IDENTIFIER, "a", "1:3-1:4", ""},
+			{COMMA, ",", "1:4-1:5", ""},
+			{IDENTIFIER, "b", "2:1-2:2", ""},
+			{RIGHT_PAREN, ")", "2:2-2:3", ""},
+		}},
+		{src: "[1\n,2]", tokens: []tokenWant{
+			{LEFT_BRACKET, "[", "1:1-1:2", ""},
+			{INT_LITERAL, "1", "1:2-1:3", "1"},
+			{COMMA, ",", "2:1-2:2", ""},
+			{INT_LITERAL, "2", "2:2-2:3", "2"},
+			{RIGHT_BRACKET, "]", "2:3-2:4", ""},
+		}},
+		{src: "print(a\nlet x = 1", tokens: []tokenWant{
+			{IDENTIFIER, "print", "1:1-1:6", ""},
+			{LEFT_PAREN, "(", "1:6-1:7", ""},
+			{IDENTIFIER, "a", "1:7-1:8", ""},
+			{NEWLINE, "\n", "1:8-2:1", ""},
+			{LET, "let", "2:1-2:4", ""},
+			{IDENTIFIER, "x", "2:5-2:6", ""},
+			{EQUAL, "=", "2:7-2:8", ""},
+			{INT_LITERAL, "1", "2:9-2:10", "1"},
+		}},
+		// Nor inside an interpolation.
+		{src: "\"${a\n+ b}\"\nc", tokens: []tokenWant{
+			{INTERPOLATED_STRING, "\"${a\n+ b}\"", "1:1-2:6", "${a + b}"},
+			{NEWLINE, "\n", "2:6-3:1", ""},
+			{IDENTIFIER, "c", "3:1-3:2", ""},
+		}},
+		// A NEWLINE token follows a line comment rather than covering it.
+		{src: "a // c\n.b", tokens: []tokenWant{
+			{IDENTIFIER, "a", "1:1-1:2", ""},
+			{NEWLINE, "\n", "1:7-2:1", ""},
+			{DOT, ".", "2:1-2:2", ""},
+			{IDENTIFIER, "b", "2:2-2:3", ""},
+		}},
+		{src: "if (x\n  if y", tokens: []tokenWant{
+			{IF, "if", "1:1-1:3", ""},
+			{LEFT_PAREN, "(", "1:4-1:5", ""},
+			{IDENTIFIER, "x", "1:5-1:6", ""},
+			{NEWLINE, "\n", "1:6-2:1", ""},
+			{IF, "if", "2:3-2:5", ""},
+			{IDENTIFIER, "y", "2:6-2:7", ""},
+		}},
+	})
+}
+
+// triviaWant describes the trivia of a token lexed with KeepTrivia: its
+// leading and trailing lists, each item written as "kind `text` span",
+// and its doc comment.
+type triviaWant struct {
+	lexeme   string
+	leading  string
+	trailing string
+	doc      string
+}
+
+func checkTrivia(t *testing.T, src string, want []triviaWant) {
+	t.Helper()
+	l := NewLexer(src)
+	l.SetMode(KeepTrivia)
+	tokens, _ := l.Lex()
+	checkSpans(t, src, tokens)
+	var got []triviaWant
+	var text strings.Builder
+	for _, tok := range tokens {
+		got = append(got, triviaWant{tok.Lexeme, triviaString(tok.Leading), triviaString(tok.Trailing), tok.Doc})
+		for _, list := range [][]Trivia{tok.Leading, {{Text: tok.Lexeme}}, tok.Trailing} {
+			for _, trivia := range list {
+				text.WriteString(trivia.Text)
+			}
+		}
+	}
+	if text.String() != src {
+		t.Errorf("%q: tokens and trivia join to %q", src, text.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%q: got\n%s\nwant\n%s", src, triviaList(got), triviaList(want))
+	}
+
+	// Doc comments are collected without KeepTrivia too.
+	plain, _ := NewLexer(src).Lex()
+	for _, tok := range plain {
+		for _, kept := range tokens {
+			if kept.Span == tok.Span && kept.Doc != tok.Doc {
+				t.Errorf("%q: %s has doc %q without trivia, %q with it", src, tok, tok.Doc, kept.Doc)
+			}
+		}
+	}
+}
+
+func triviaString(list []Trivia) string {
+	var items []string
+	for _, trivia := range list {
+		items = append(items, fmt.Sprintf("%s `%s` %s", trivia.Kind, trivia.Text, spanString(trivia.Span)))
+	}
+	return strings.Join(items, ", ")
+}
+
+func triviaList(tokens []triviaWant) string {
+	var lines []string
+	for _, tok := range tokens {
+		lines = append(lines, fmt.Sprintf("\t{%q, %q, %q, %q},", tok.lexeme, tok.leading, tok.trailing, tok.doc))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestTrivia(t *testing.T) {
+	tests := []struct {
+		src    string
+		tokens []triviaWant
+	}{
+		// Consecutive `///` lines make one doc comment.
+		{"/// Adds two numbers.\n/// Returns their sum.\nfun add(a, b) {}", []triviaWant{
+			{"fun", "doc comment `/// Adds two numbers.` 1:1-1:22, newline `\n` 1:22-2:1, doc comment `/// Returns their sum.` 2:1-2:23, newline `\n` 2:23-3:1", "whitespace ` ` 3:4-3:5", "Adds two numbers.\nReturns their sum."},
+			{"add", "", "", ""},
+			{"(", "", "", ""},
+			{"a", "", "", ""},
+			{",", "", "whitespace ` ` 3:11-3:12", ""},
+			{"b", "", "", ""},
+			{")", "", "whitespace ` ` 3:14-3:15", ""},
+			{"{", "", "", ""},
+			{"}", "", "", ""},
+			{"", "", "", ""},
+		}},
+		// A `/** */` comment loses its delimiters and leading stars.
+		{"/**\n * A point.\n *\n * With two fields.\n */\ndata Point(x, y)", []triviaWant{
+			{"data", "doc comment `/**\n * A point.\n *\n * With two fields.\n */` 1:1-5:4, newline `\n` 5:4-6:1", "whitespace ` ` 6:5-6:6", "A point.\n\nWith two fields."},
+			{"Point", "", "", ""},
+			{"(", "", "", ""},
+			{"x", "", "", ""},
+			{",", "", "whitespace ` ` 6:14-6:15", ""},
+			{"y", "", "", ""},
+			{")", "", "", ""},
+			{"", "", "", ""},
+		}},
+		// Other comments are kept as trivia but are not docs.
+		{"// plain\n//// not doc\n/**/ /*** neither */\nfun f() {}", []triviaWant{
+			{"fun", "line comment `// plain` 1:1-1:9, newline `\n` 1:9-2:1, line comment `//// not doc` 2:1-2:13, newline `\n` 2:13-3:1, block comment `/**/` 3:1-3:5, whitespace ` ` 3:5-3:6, block comment `/*** neither */` 3:6-3:21, newline `\n` 3:21-4:1", "whitespace ` ` 4:4-4:5", ""},
+			{"f", "", "", ""},
+			{"(", "", "", ""},
+			{")", "", "whitespace ` ` 4:8-4:9", ""},
+			{"{", "", "", ""},
+			{"}", "", "", ""},
+			{"", "", "", ""},
+		}},
+		// Trailing trivia runs to the end of the line, and leading trivia
+		// from there to the token.
+		{"/// first\nlet x = 1 // after x\n\n/// second\nlet y = 2", []triviaWant{
+			{"let", "doc comment `/// first` 1:1-1:10, newline `\n` 1:10-2:1", "whitespace ` ` 2:4-2:5", "first"},
+			{"x", "", "whitespace ` ` 2:6-2:7", ""},
+			{"=", "", "whitespace ` ` 2:8-2:9", ""},
+			{"1", "", "whitespace ` ` 2:10-2:11, line comment `// after x` 2:11-2:21", ""},
+			{"\n", "", "", ""},
+			{"let", "newline `\n` 3:1-4:1, doc comment `/// second` 4:1-4:11, newline `\n` 4:11-5:1", "whitespace ` ` 5:4-5:5", "second"},
+			{"y", "", "whitespace ` ` 5:6-5:7", ""},
+			{"=", "", "whitespace ` ` 5:8-5:9", ""},
+			{"2", "", "", ""},
+			{"", "", "", ""},
+		}},
+		// A plain comment or a blank line does not detach a doc comment.
+		{"/// doc\n// plain\n\nclass C {}", []triviaWant{
+			{"class", "doc comment `/// doc` 1:1-1:8, newline `\n` 1:8-2:1, line comment `// plain` 2:1-2:9, newline `\n` 2:9-3:1, newline `\n` 3:1-4:1", "whitespace ` ` 4:6-4:7", "doc"},
+			{"C", "", "whitespace ` ` 4:8-4:9", ""},
+			{"{", "", "", ""},
+			{"}", "", "", ""},
+			{"", "", "", ""},
+		}},
+	}
+	for _, tt := range tests {
+		checkTrivia(t, tt.src, tt.tokens)
+	}
+}
+
+func TestBlockCommentsAndShebang(t *testing.T) {
+	checkTokens(t, 0, []tokenCase{
+		// Block comments nest, so '*/' closes only the innermost one.
+		{src: "a /* x /* y */ z */ b", tokens: []tokenWant{
+			{IDENTIFIER, "a", "1:1-1:2", ""},
+			{IDENTIFIER, "b", "1:21-1:22", ""},
+		}},
+		{src: "/* /* */\nc", tokens: []tokenWant{
+			{ILLEGAL, "/* /* */\nc", "1:1-2:2", ""},
+		}, errors: []string{"1:1-2:2: Unterminated block comment"}},
+		// A shebang is skipped only at the very start of the file.
+		{src: "#!/usr/bin/env dotFun\nprint(1)", tokens: []tokenWant{
+			{IDENTIFIER, "print", "2:1-2:6", ""},
+			{LEFT_PAREN, "(", "2:6-2:7", ""},
+			{INT_LITERAL, "1", "2:7-2:8", "1"},
+			{RIGHT_PAREN, ")", "2:8-2:9", ""},
+		}},
+		{src: "x #!y", tokens: []tokenWant{
+			{IDENTIFIER, "x", "1:1-1:2", ""},
+			{ILLEGAL, "#", "1:3-1:4", ""},
+			{NOT_BANG, "!", "1:4-1:5", ""},
+			{IDENTIFIER, "y", "1:5-1:6", ""},
+		}, errors: []string{"1:3-1:4: Unexpected character '#'"}},
+		{src: " /* a */ #!z", tokens: []tokenWant{
+			{ILLEGAL, "#", "1:10-1:11", ""},
+			{NOT_BANG, "!", "1:11-1:12", ""},
+			{IDENTIFIER, "z", "1:12-1:13", ""},
+		}, errors: []string{"1:10-1:11: Unexpected character '#'"}},
+		{src: "/*/ a */ b", tokens: []tokenWant{
+			{IDENTIFIER, "b", "1:10-1:11", ""},
+		}},
+	})
+}
+
+func TestShebangTrivia(t *testing.T) {
+	checkTrivia(t, "#!/usr/bin/env dotFun\nf /* a /* b */ */", []triviaWant{
+		{"f", "shebang `#!/usr/bin/env dotFun` 1:1-1:22, newline `\n` 1:22-2:1", "whitespace ` ` 2:2-2:3, block comment `/* a /* b */ */` 2:3-2:18", ""},
+		{"", "", "", ""},
+	})
+}
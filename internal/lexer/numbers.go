@@ -0,0 +1,169 @@
+package lexer
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// number scans an integer or float literal whose first digit has already
+// been consumed. Integers may be written in decimal, hex (0xFF), binary
+// (0b1010) or octal (0o17); floats need a fraction or an exponent. Digits
+// may be grouped with single underscores (1_000_000).
+func (l *Lexer) number() error {
+	first := l.source[l.start]
+	if first == '0' {
+		switch l.peek() {
+		case 'x', 'X':
+			return l.radixNumber(16, "hex")
+		case 'b', 'B':
+			return l.radixNumber(2, "binary")
+		case 'o', 'O':
+			return l.radixNumber(8, "octal")
+		}
+	}
+
+	if err := l.digits(10, "decimal"); err != nil {
+		return err
+	}
+	isFloat := false
+
+	if l.peek() == '.' && isDigit(l.peekNext()) {
+		isFloat = true
+		l.advance()
+		if err := l.digits(10, "decimal"); err != nil {
+			return err
+		}
+	}
+
+	if c := l.peek(); c == 'e' || c == 'E' {
+		isFloat = true
+		l.advance()
+		if c := l.peek(); c == '+' || c == '-' {
+			l.advance()
+		}
+		if !isDigit(l.peek()) {
+			return l.numberError("Expected digits in the exponent of '%s'", l.source[l.start:l.current])
+		}
+		if err := l.digits(10, "decimal"); err != nil {
+			return err
+		}
+	}
+
+	if err := l.checkNumberEnd(); err != nil {
+		return err
+	}
+
+	text := l.digitText(l.start)
+	if isFloat {
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return l.numberError("Float literal '%s' is out of range", l.source[l.start:l.current])
+		}
+		return l.addLiteralToken(FLOAT_LITERAL, Literal{Float: value})
+	}
+
+	if len(text) > 1 && text[0] == '0' {
+		return l.numberError("Leading zeros are not allowed in '%s', use 0o for octal", l.source[l.start:l.current])
+	}
+	return l.intLiteral(text, 10)
+}
+
+func (l *Lexer) radixNumber(base int, name string) error {
+	l.advance()
+	if l.peek() == '_' {
+		l.advance()
+	}
+	if !isDigitIn(l.peek(), base) {
+		return l.numberError("Expected %s digits after '%s'", name, l.source[l.start:l.current])
+	}
+	if err := l.digits(base, name); err != nil {
+		return err
+	}
+	if err := l.checkNumberEnd(); err != nil {
+		return err
+	}
+	return l.intLiteral(l.digitText(l.start+2), base)
+}
+
+// digitText returns source[start:current] without its digit separators.
+func (l *Lexer) digitText(start int) string {
+	text := l.source[start:l.current]
+	if strings.IndexByte(text, '_') == -1 {
+		return text
+	}
+	mark := l.beginText()
+	for i := 0; i < len(text); i++ {
+		if text[i] != '_' {
+			l.arena.WriteByte(text[i])
+		}
+	}
+	return l.endText(mark)
+}
+
+// digits consumes a run of digits in base, allowing single underscores
+// between them. A decimal digit outside base is reported rather than left
+// to start a new token, so '0b12' is an error instead of 0b1 followed by 2.
+func (l *Lexer) digits(base int, name string) error {
+	for {
+		c := l.peek()
+		switch {
+		case c == '_':
+			if !isDigitIn(l.peekNext(), base) || !isDigitIn(l.source[l.current-1], base) {
+				return l.numberError("Digit separator '_' must be between digits")
+			}
+			l.advance()
+		case isDigitIn(c, base):
+			l.advance()
+		case isDigit(c):
+			return l.numberError("Invalid digit '%c' in %s literal", c, name)
+		default:
+			return nil
+		}
+	}
+}
+
+// checkNumberEnd rejects literals running straight into an identifier,
+// such as 12px.
+func (l *Lexer) checkNumberEnd() error {
+	if r, _ := l.peekRune(); isIdentStart(r) {
+		return l.numberError("Invalid character '%c' in number literal", r)
+	}
+	return nil
+}
+
+func (l *Lexer) intLiteral(text string, base int) error {
+	value, err := strconv.ParseInt(text, base, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return l.numberError("Integer literal '%s' overflows Int", l.source[l.start:l.current])
+		}
+		return l.numberError("Invalid integer literal '%s'", l.source[l.start:l.current])
+	}
+	return l.addLiteralToken(INT_LITERAL, Literal{Int: value})
+}
+
+// numberError skips the rest of the malformed literal so that it becomes
+// a single ILLEGAL token.
+func (l *Lexer) numberError(format string, args ...any) error {
+	for {
+		if r, _ := l.peekRune(); !isIdentPart(r) && !(r == '.' && isDigit(l.peekNext())) {
+			break
+		}
+		l.advance()
+		l.advanceRune()
+	}
+	return l.errorf(format, args...)
+}
+
+func isDigitIn(c byte, base int) bool {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c-'0') < base
+	case c >= 'a' && c <= 'f':
+		return base == 16
+	case c >= 'A' && c <= 'F':
+		return base == 16
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package lexer
+
+import "fmt"
+
+// Span is the source range covered by a token or syntax node. Offsets are
+// byte offsets into the source with EndOffset exclusive. Lines and columns
+// are 1-based; EndLine and EndCol locate the position just past the end.
+type Span struct {
+	File        string
+	StartOffset int
+	EndOffset   int
+	StartLine   int
+	StartCol    int
+	EndLine     int
+	EndCol      int
+}
+
+// Position is a single point in the source.
+type Position struct {
+	File   string
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	text := fmt.Sprintf("%d:%d", p.Line, p.Column)
+	if p.File != "" {
+		return p.File + ":" + text
+	}
+	return text
+}
+
+// Pos returns where s starts.
+func (s Span) Pos() Position {
+	return Position{File: s.File, Offset: s.StartOffset, Line: s.StartLine, Column: s.StartCol}
+}
+
+// End returns the position just past the end of s.
+func (s Span) End() Position {
+	return Position{File: s.File, Offset: s.EndOffset, Line: s.EndLine, Column: s.EndCol}
+}
+
+// SourceSpan returns s. Syntax nodes embed a Span, so this gives every
+// node the same accessor.
+func (s Span) SourceSpan() Span {
+	return s
+}
+
+// To returns the span running from the start of s to the end of end.
+func (s Span) To(end Span) Span {
+	s.EndOffset = end.EndOffset
+	s.EndLine = end.EndLine
+	s.EndCol = end.EndCol
+	return s
+}
+
+// Shift moves s by offset bytes and lines lines, keeping its columns.
+func (s Span) Shift(offset, lines int) Span {
+	if !s.IsValid() {
+		return s
+	}
+	s.StartOffset += offset
+	s.EndOffset += offset
+	s.StartLine += lines
+	s.EndLine += lines
+	return s
+}
+
+func (s Span) Len() int {
+	return s.EndOffset - s.StartOffset
+}
+
+func (s Span) IsValid() bool {
+	return s.StartLine > 0
+}
+
+func (s Span) String() string {
+	text := fmt.Sprintf("%d:%d-%d:%d", s.StartLine, s.StartCol, s.EndLine, s.EndCol)
+	if s.File != "" {
+		return s.File + ":" + text
+	}
+	return text
+}
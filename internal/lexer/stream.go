@@ -0,0 +1,101 @@
+package lexer
+
+import "io"
+
+// NewBytesLexer is like NewFileLexer but takes the source as bytes. The
+// bytes are copied once, so the caller may reuse them; lexemes are slices
+// of the copy.
+func NewBytesLexer(file string, source []byte) *Lexer {
+	return NewFileLexer(file, string(source))
+}
+
+// NewReaderLexer returns a lexer over the input read from r. The input is
+// read as the lexer needs it, so Next can return the first tokens of a
+// large input before the rest has been read. A read error ends the input
+// and is reported as a lexical error.
+func NewReaderLexer(file string, r io.Reader) *Lexer {
+	l := NewFileLexer(file, "")
+	l.reader = r
+	return l
+}
+
+const (
+	// readSize is the most a reader lexer asks for in one Read.
+	readSize = 64 << 10
+	// maxEmptyReads is how many reads in a row may return nothing before
+	// the reader is taken to be stuck, as in bufio.
+	maxEmptyReads = 100
+)
+
+// fill reads from the reader until the source holds at least n bytes or
+// the input ends, and reports whether it holds them. Reads may return less
+// than asked for. The input is kept in a strings.Builder, so the source
+// grows without being copied for every read.
+func (l *Lexer) fill(n int) bool {
+	for empty := 0; len(l.source) < n && l.reader != nil; {
+		if l.chunk == nil {
+			l.chunk = make([]byte, readSize)
+		}
+		k, err := l.reader.Read(l.chunk)
+		l.input.Write(l.chunk[:k])
+		l.source = l.input.String()
+		if k == 0 && err == nil {
+			if empty++; empty == maxEmptyReads {
+				err = io.ErrNoProgress
+			}
+		} else {
+			empty = 0
+		}
+		switch err {
+		case nil:
+		case io.EOF:
+			l.reader = nil
+		default:
+			l.reader = nil
+			l.report(l.errorAt(len(l.source), len(l.source), "Error reading input: %s", err))
+		}
+	}
+	return len(l.source) >= n
+}
+
+// Next scans just far enough to return the next token, so that large
+// inputs can be lexed without holding every token in memory. Lexemes are
+// slices of the source rather than copies.
+//
+// Next does not allocate for each token. Decoded strings, interpolation
+// parts and, with KeepTrivia, trivia lists are copied into blocks shared by
+// many tokens (see alloc.go), so allocations grow with the size of the
+// input rather than the number of tokens.
+//
+// Once EOF_TOKEN is returned, every further call returns it again. Next
+// and Lex must not be used on the same Lexer.
+func (l *Lexer) Next() Token {
+	// One token of lookahead is kept: trailing trivia and the newline rule
+	// both need the previous token, and trailing trivia is only complete
+	// once the following token starts.
+	for len(l.tokens)-l.next < 2 && !l.eof {
+		if l.next > 0 {
+			n := copy(l.tokens, l.tokens[l.next:])
+			l.tokens = l.tokens[:n]
+			l.next = 0
+		}
+		if l.isAtEnd() {
+			l.tokens = append(l.tokens, l.eofToken())
+			l.eof = true
+			break
+		}
+		l.scan()
+	}
+
+	tok := l.tokens[l.next]
+	if tok.Type != EOF_TOKEN {
+		l.next++
+	}
+	return tok
+}
+
+// Source returns the text being lexed, or for a reader lexer the part
+// read so far. Token spans are byte offsets into it.
+func (l *Lexer) Source() string {
+	return l.source
+}
@@ -0,0 +1,94 @@
+package lexer
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func next(l *Lexer) []Token {
+	var tokens []Token
+	for {
+		tokens = append(tokens, l.Next())
+		if tokens[len(tokens)-1].Type == EOF_TOKEN {
+			return tokens
+		}
+	}
+}
+
+// TestReader checks that a reader lexer gives the tokens a lexer over the
+// whole source does, however the reads are split.
+func TestReader(t *testing.T) {
+	src := corpus(t) + generated(200<<10)
+	for _, mode := range []Mode{0, KeepTrivia} {
+		want, wantErrs := lex(src, mode)
+		for _, r := range []io.Reader{strings.NewReader(src), iotest.OneByteReader(strings.NewReader(src)), iotest.HalfReader(strings.NewReader(src))} {
+			l := NewReaderLexer("", r)
+			l.SetMode(mode)
+			if got := next(l); !reflect.DeepEqual(got, want) {
+				t.Errorf("mode %d, %T: tokens differ from Lex", mode, r)
+			}
+			if !reflect.DeepEqual(l.Errors(), wantErrs) {
+				t.Errorf("mode %d, %T: errors %v, want %v", mode, r, l.Errors(), wantErrs)
+			}
+			if l.Source() != src {
+				t.Errorf("mode %d, %T: read %d bytes, want %d", mode, r, len(l.Source()), len(src))
+			}
+		}
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("let a = 1\nlet b"), iotest.ErrReader(errors.New("disk on fire")))
+	l := NewReaderLexer("", r)
+	tokens := next(l)
+	if got := tokens[len(tokens)-2]; got.Lexeme != "b" {
+		t.Errorf("last token is %v, want b", got)
+	}
+	errs := l.Errors()
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "disk on fire") || errs[0].StartOffset != 15 {
+		t.Errorf("errors are %v, want the read error at offset 15", errs)
+	}
+}
+
+type stuckReader struct{}
+
+func (stuckReader) Read([]byte) (int, error) { return 0, nil }
+
+func TestReaderNoProgress(t *testing.T) {
+	l := NewReaderLexer("", io.MultiReader(strings.NewReader("let a"), stuckReader{}))
+	tokens := next(l)
+	if got := tokens[len(tokens)-2]; got.Lexeme != "a" {
+		t.Errorf("last token is %v, want a", got)
+	}
+	if errs := l.Errors(); len(errs) != 1 || !strings.Contains(errs[0].Message, io.ErrNoProgress.Error()) {
+		t.Errorf("errors are %v, want %v", errs, io.ErrNoProgress)
+	}
+}
+
+// TestNextAllocations checks that Next does not allocate for each token,
+// whether or not the token has a value or trivia.
+func TestNextAllocations(t *testing.T) {
+	src := generated(256<<10) + strings.Repeat(`
+/** Block doc
+ * over lines. */
+let s = """
+    line \u{1F600} ${ "nested \"$x\"" + f(a, 'x') }
+    """ + r"raw $x" + "" // done
+`, 500)
+	for _, mode := range []Mode{0, KeepTrivia} {
+		tokens := len(next(NewLexer(src)))
+		allocs := testing.AllocsPerRun(5, func() {
+			l := NewLexer(src)
+			l.SetMode(mode)
+			for l.Next().Type != EOF_TOKEN {
+			}
+		})
+		if allocs > float64(tokens/100) {
+			t.Errorf("mode %d: lexing %d tokens took %.0f allocations", mode, tokens, allocs)
+		}
+	}
+}
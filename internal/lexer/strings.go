@@ -0,0 +1,438 @@
+package lexer
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// unescape writes s to sb with its escape sequences decoded. s starts at
+// byte offset base of the source. A bad escape is reported and decoded as
+// U+FFFD so that lexing can carry on.
+func (l *Lexer) unescape(sb *strings.Builder, s string, base int) {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+
+		escapeStart := i
+		if i+1 >= len(s) {
+			l.escapeError(base+escapeStart, base+len(s), sb, "Incomplete escape sequence")
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case '0':
+			sb.WriteByte(0)
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'v':
+			sb.WriteByte('\v')
+		case '\\', '"', '\'', '$':
+			sb.WriteByte(c)
+
+		case 'x':
+			if i+2 >= len(s) {
+				l.escapeError(base+escapeStart, base+len(s), sb, "Expected two hex digits after '\\x'")
+				i = len(s)
+				continue
+			}
+			value, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			if err != nil {
+				l.escapeError(base+escapeStart, base+i+3, sb, "Expected two hex digits after '\\x'")
+				i += 2
+				continue
+			}
+			if value > 0x7F {
+				l.escapeError(base+escapeStart, base+i+3, sb, "Escape '\\x%s' is outside ASCII, use '\\u{...}'", s[i+1:i+3])
+				i += 2
+				continue
+			}
+			sb.WriteByte(byte(value))
+			i += 2
+
+		case 'u':
+			if i+1 >= len(s) || s[i+1] != '{' {
+				l.escapeError(base+escapeStart, base+i+1, sb, "Expected '{' after '\\u'")
+				continue
+			}
+			end := strings.IndexByte(s[i+2:], '}')
+			if end == -1 {
+				l.escapeError(base+escapeStart, base+len(s), sb, "Unterminated unicode escape")
+				i = len(s)
+				continue
+			}
+			escapeEnd := base + i + 3 + end
+			digits := s[i+2 : i+2+end]
+			i += 2 + end
+			if len(digits) == 0 || len(digits) > 6 {
+				l.escapeError(base+escapeStart, escapeEnd, sb, "Unicode escape must have 1 to 6 hex digits")
+				continue
+			}
+			value, err := strconv.ParseUint(digits, 16, 32)
+			if err != nil {
+				l.escapeError(base+escapeStart, escapeEnd, sb, "Invalid hex digits '%s' in unicode escape", digits)
+				continue
+			}
+			r := rune(value)
+			if !utf8.ValidRune(r) {
+				l.escapeError(base+escapeStart, escapeEnd, sb, "Invalid unicode code point U+%X", value)
+				continue
+			}
+			sb.WriteRune(r)
+
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			l.escapeError(base+escapeStart, base+i+size, sb, "Invalid escape sequence '\\%c'", r)
+			i += size - 1
+		}
+	}
+}
+
+func (l *Lexer) escapeError(start, end int, sb *strings.Builder, format string, args ...any) {
+	l.report(l.errorAt(start, end, format, args...))
+	sb.WriteRune(utf8.RuneError)
+}
+
+// positionAt converts a byte offset into a 1-based line and rune column.
+// Offsets inside the current token are counted from its start, so the cost
+// does not grow with the size of the source.
+func (l *Lexer) positionAt(offset int) (int, int) {
+	line, column, from := 1, 1, 0
+	if offset >= l.start {
+		line, column, from = l.startLine, l.startColumn, l.start
+	}
+	text := l.source[from:offset]
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		return line + strings.Count(text, "\n"), columnWidth(text[i+1:]) + 1
+	}
+	return line, column + columnWidth(text)
+}
+
+// columnWidth counts the columns advance moves over for s: one per byte
+// that starts a rune, so invalid bytes count the same way everywhere.
+func columnWidth(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if utf8.RuneStart(s[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+type textLine struct {
+	start int
+	end   int
+	// newline is the offset of the '\n' joining this line to the next kept
+	// line, or -1 for the last one.
+	newline int
+}
+
+// trimIndent splits source[start:end] into lines, appended to lines, and
+// removes the indentation shared by every non-blank line. A blank first line (the one
+// holding the opening quotes) and a blank last line (the one holding the
+// closing quotes) are dropped, so
+//
+//	val s = """
+//	    hello
+//	      world
+//	    """
+//
+// yields "hello\n  world".
+func trimIndent(lines []textLine, source string, start, end int) []textLine {
+	base := len(lines)
+	for lineStart := start; ; {
+		newline := strings.IndexByte(source[lineStart:end], '\n')
+		if newline == -1 {
+			lines = append(lines, textLine{lineStart, end, -1})
+			break
+		}
+		lineEnd := lineStart + newline
+		line := textLine{lineStart, lineEnd, lineEnd}
+		if lineEnd > lineStart && source[lineEnd-1] == '\r' {
+			line.end--
+		}
+		lines = append(lines, line)
+		lineStart = lineEnd + 1
+	}
+
+	blank := func(line textLine) bool {
+		return strings.TrimLeft(source[line.start:line.end], " \t") == ""
+	}
+	if len(lines)-base > 1 && blank(lines[base]) {
+		lines = append(lines[:base], lines[base+1:]...)
+	}
+	if len(lines)-base > 1 && blank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	lines[len(lines)-1].newline = -1
+
+	indent := -1
+	for _, line := range lines[base:] {
+		if blank(line) {
+			continue
+		}
+		text := source[line.start:line.end]
+		width := len(text) - len(strings.TrimLeft(text, " \t"))
+		if indent == -1 || width < indent {
+			indent = width
+		}
+	}
+
+	for i := base; i < len(lines); i++ {
+		if line := lines[i]; blank(line) {
+			lines[i].start = line.end
+			continue
+		}
+		lines[i].start += indent
+	}
+	return lines
+}
+
+// stringBody scans the contents of a string literal whose opening quotes
+// have been consumed, splitting out $name and ${expr} interpolations.
+func (l *Lexer) stringBody(raw, multiline bool) error {
+	// The parts and lines of a string are kept at the end of l.parts and
+	// l.lines, after those of any string it is nested in.
+	s := &stringScanner{l: l, raw: raw, parts: len(l.parts)}
+	lines := len(l.lines)
+	defer func() { l.parts, l.lines = l.parts[:s.parts], l.lines[:lines] }()
+
+	if multiline {
+		end, ok := l.findTripleQuote(raw)
+		if !ok {
+			return l.errorf("Unterminated multi-line string")
+		}
+		l.lines = trimIndent(l.lines, l.source, l.current, end)
+		s.lines = l.lines[lines:]
+	}
+
+	runStart := l.current
+	for {
+		if l.isAtEnd() || (!multiline && l.peek() == '\n') {
+			return l.errorf("Unterminated string literal")
+		}
+		if l.peek() == '"' && (!multiline || (l.peekNext() == '"' && l.peekAt(2) == '"')) {
+			break
+		}
+
+		switch c := l.peek(); {
+		case c == '\\' && !raw:
+			l.advance()
+			if !l.isAtEnd() && (multiline || l.peek() != '\n') {
+				l.advance()
+			}
+			continue
+
+		case c == '$' && !raw && l.startsInterpolation():
+			if err := s.literal(runStart, l.current); err != nil {
+				return err
+			}
+			// The pending text is finished first, as strings nested in the
+			// interpolation write to the arena too.
+			s.flush()
+			dollar := l.current
+			l.advance()
+			tokens, err := l.interpolation()
+			if err != nil {
+				return err
+			}
+			s.expression(tokens, l.spanAt(dollar, l.current))
+			runStart = l.current
+			continue
+		}
+		l.advance()
+	}
+
+	if err := s.literal(runStart, l.current); err != nil {
+		return err
+	}
+	l.advance()
+	if multiline {
+		l.advance()
+		l.advance()
+	}
+
+	if len(l.parts) == s.parts {
+		return l.addLiteralToken(STRING_LITERAL, Literal{Text: s.text})
+	}
+	s.flush()
+	return l.addLiteralToken(INTERPOLATED_STRING, Literal{Parts: keep(&l.partBlock, l.parts[s.parts:])})
+}
+
+// findTripleQuote returns the offset of the closing """ without consuming
+// anything.
+func (l *Lexer) findTripleQuote(raw bool) (int, bool) {
+	for i := l.current; l.fill(i + 3); i++ {
+		if l.source[i] == '\\' && !raw {
+			i++
+			continue
+		}
+		if l.source[i] == '"' && l.source[i+1] == '"' && l.source[i+2] == '"' {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// startsInterpolation reports whether the '$' at current begins $name or
+// ${expr}.
+func (l *Lexer) startsInterpolation() bool {
+	if l.peekNext() == '{' {
+		return true
+	}
+	r, _ := l.runeAt(l.current + 1)
+	return isIdentStart(r)
+}
+
+// interpolation scans the expression after a '$' inside a string and
+// returns its tokens terminated by EOF_TOKEN. Either a single identifier
+// ($name) or a braced expression (${expr}) is accepted.
+func (l *Lexer) interpolation() ([]Token, error) {
+	// Trivia inside the braces is part of the string token's lexeme, so
+	// anything left pending is dropped rather than leaking to later tokens.
+	// The pending lists are scanned into past their current end, which the
+	// enclosing scan still owns.
+	saved, start, startLine, startColumn := l.tokens, l.start, l.startLine, l.startColumn
+	trivia, trailing, trailingTrivia := l.trivia, l.trailing, l.trailingTrivia
+	defer func() {
+		l.releaseTokens(l.tokens)
+		l.tokens, l.start, l.startLine, l.startColumn = saved, start, startLine, startColumn
+		l.trivia, l.trailing, l.trailingTrivia = trivia, trailing, trailingTrivia
+	}()
+	l.tokens = l.tokenBuffer()
+	l.trivia, l.trailing, l.trailingTrivia = trivia[len(trivia):], false, trailingTrivia[len(trailingTrivia):]
+
+	if !l.match('{') {
+		l.mark()
+		l.advance()
+		l.advanceRune()
+		if err := l.identifier(); err != nil {
+			return nil, err
+		}
+		l.tokens = append(l.tokens, l.eofToken())
+		return keep(&l.tokenBlock, l.tokens), nil
+	}
+
+	// Newlines inside ${...} never end a statement. Brackets left open by
+	// a bad interpolation must not leak out of the string.
+	open := len(l.brackets)
+	l.openBracket('$')
+	defer func() { l.brackets = l.brackets[:open] }()
+
+	dollar := l.current - 2
+	depth := 0
+	for {
+		if l.isAtEnd() {
+			return nil, l.errorAt(dollar, -1, "Unterminated interpolation")
+		}
+		if l.peek() == '}' && depth == 0 {
+			break
+		}
+		count := len(l.tokens)
+		l.scan()
+		if len(l.tokens) > count {
+			switch l.tokens[len(l.tokens)-1].Type {
+			case LEFT_BRACE:
+				depth++
+			case RIGHT_BRACE:
+				depth--
+			}
+		}
+	}
+	l.tokens = append(l.tokens, l.eofToken())
+	l.advance()
+	return keep(&l.tokenBlock, l.tokens), nil
+}
+
+type stringScanner struct {
+	l     *Lexer
+	raw   bool
+	lines []textLine
+	// text is the pending decoded text. It is a slice of the source until
+	// more is added to it, and then it is written to the arena from mark.
+	text    string
+	inArena bool
+	mark    int
+	// parts is the index in l.parts of the string's first part.
+	parts int
+	// textStart and textEnd bound the source of the pending text.
+	textStart int
+	textEnd   int
+}
+
+// literal appends the decoded text of source[start:end], leaving out any
+// indentation trimmed from a multi-line string.
+func (s *stringScanner) literal(start, end int) error {
+	if s.text == "" {
+		s.textStart = start
+	}
+	s.textEnd = end
+	if s.lines == nil {
+		return s.decode(start, end)
+	}
+	for _, line := range s.lines {
+		if from, to := max(start, line.start), min(end, line.end); from < to {
+			if err := s.decode(from, to); err != nil {
+				return err
+			}
+		}
+		if line.newline >= start && line.newline < end {
+			s.add("\n")
+		}
+	}
+	return nil
+}
+
+func (s *stringScanner) decode(start, end int) error {
+	text := s.l.source[start:end]
+	if s.raw || strings.IndexByte(text, '\\') == -1 {
+		s.add(text)
+		return nil
+	}
+	s.toArena()
+	s.l.unescape(&s.l.arena, text, start)
+	s.text = s.l.endText(s.mark)
+	return nil
+}
+
+func (s *stringScanner) add(text string) {
+	if s.text == "" && !s.inArena {
+		s.text = text
+		return
+	}
+	s.toArena()
+	s.l.arena.WriteString(text)
+	s.text = s.l.endText(s.mark)
+}
+
+// toArena moves the pending text to the arena so that more can be added.
+func (s *stringScanner) toArena() {
+	if !s.inArena {
+		s.mark = s.l.beginText()
+		s.l.arena.WriteString(s.text)
+		s.inArena = true
+	}
+}
+
+func (s *stringScanner) flush() {
+	if s.text != "" {
+		s.l.parts = append(s.l.parts, StringPart{Text: s.text, Span: s.l.spanAt(s.textStart, s.textEnd)})
+	}
+	s.text, s.inArena = "", false
+}
+
+func (s *stringScanner) expression(tokens []Token, span Span) {
+	s.flush()
+	s.l.parts = append(s.l.parts, StringPart{Tokens: tokens, Span: span})
+}
@@ -6,11 +6,15 @@ type TokenType int
 
 const (
 	EOF_TOKEN TokenType = iota
+	ILLEGAL
 
 	// Identifiers & literals
 	IDENTIFIER
-	NUMBER_LITERAL
+	INT_LITERAL
+	FLOAT_LITERAL
 	STRING_LITERAL
+	CHAR_LITERAL
+	INTERPOLATED_STRING
 
 	// Keywords: OOP / Structures
 	CLASS
@@ -27,8 +31,6 @@ const (
 	THIS
 	NEW
 	SUPER
-	CONSTRUCTOR
-	DATA
 	INSTANCEOF
 
 	// Control Flow
@@ -54,15 +56,11 @@ const (
 	// Variables
 	LET
 	VAL
-	GLOBAL
 
 	// Operators (word versions)
 	AND
 	OR
 	NOT
-	IS
-	IN
-	OF
 
 	// Error Handling
 	TRY
@@ -71,9 +69,7 @@ const (
 	THROW
 
 	// Switch/Case
-	TURN
 	CASE
-	DEFAULT
 
 	// Symbols for operators
 	PLUS          // +
@@ -84,6 +80,7 @@ const (
 	AND_AND       // &&
 	OR_OR         // ||
 	NOT_BANG      // !
+	BANG_EQUAL    // !=
 	EQUAL_EQUAL   // ==
 	COLON         // :
 	GREATER       // >
@@ -93,9 +90,20 @@ const (
 	MINUS_MINUS   // --
 	PLUS_PLUS     // ++
 	DOLLAR        // $
+	TILDE         // ~
+	STAR_STAR     // **
 
 	// Assignment
-	EQUAL // =
+	EQUAL             // =
+	PLUS_EQUAL        // +=
+	MINUS_EQUAL       // -=
+	STAR_EQUAL        // *=
+	SLASH_EQUAL       // /=
+	PERCENT_EQUAL     // %=
+	BIT_AND_EQUAL     // &=
+	BIT_OR_EQUAL      // |=
+	SHIFT_LEFT_EQUAL  // <<=
+	SHIFT_RIGHT_EQUAL // >>=
 
 	// Brackets
 	LEFT_PAREN
@@ -112,6 +120,12 @@ const (
 	QUESTION    // ?
 	ELLIPSIS    // ...
 
+	// Nil handling and ranges
+	QUESTION_DOT      // ?.
+	QUESTION_QUESTION // ??
+	DOT_DOT           // ..
+	DOT_DOT_LESS      // ..<
+
 	BIT_AND
 	BIT_OR
 	BIT_XOR
@@ -123,16 +137,54 @@ const (
 	COMMA
 	DOT
 	SEMICOLON
+	NEWLINE // a line break that ends a statement
 )
 
+// Token is a lexeme with its decoded literal value. Line and Column give
+// where the token starts; Span holds its full range. Doc is the text of the
+// doc comment just before the token, if any. Leading and Trailing are only
+// filled in KeepTrivia mode.
 type Token struct {
-	Type   TokenType
-	Lexeme string
-	Line   int
-	Column int
+	Type     TokenType
+	Lexeme   string
+	Literal  Literal
+	Line     int
+	Column   int
+	Span     Span
+	Doc      string
+	Leading  []Trivia
+	Trailing []Trivia
+}
+
+// Literal is the decoded value of a literal token. Only the field for the
+// token's type is set: Int for INT_LITERAL and, as a code point, for
+// CHAR_LITERAL, Float for FLOAT_LITERAL, Text for STRING_LITERAL and Parts
+// for INTERPOLATED_STRING. Unlike an interface, it holds a number without
+// allocating.
+type Literal struct {
+	Int   int64
+	Float float64
+	Text  string
+	Parts []StringPart
+}
+
+// StringPart is one segment of an INTERPOLATED_STRING token: either
+// decoded literal text or the tokens of an embedded expression, terminated
+// by EOF_TOKEN.
+type StringPart struct {
+	Text   string
+	Tokens []Token
+	Span   Span
+}
+
+func (p StringPart) IsExpr() bool {
+	return p.Tokens != nil
 }
 
 func (t Token) String() string {
+	if t.Type == NEWLINE {
+		return "NEWLINE at " + itoa(t.Line) + ":" + itoa(t.Column)
+	}
 	return t.Type.String() + "('" + t.Lexeme + "') at " + itoa(t.Line) + ":" + itoa(t.Column)
 }
 
@@ -142,28 +194,30 @@ func (t TokenType) String() string {
 
 var tokenStrings = map[TokenType]string{
 	EOF_TOKEN: "EOF_TOKEN",
-
-	IDENTIFIER:     "IDENTIFIER",
-	NUMBER_LITERAL: "NUMBER_LITERAL",
-	STRING_LITERAL: "STRING_LITERAL",
-
-	CLASS:       "CLASS",
-	INTERFACE:   "INTERFACE",
-	EXTENDS:     "EXTENDS",
-	IMPORT:      "IMPORT",
-	EXPORT:      "EXPORT",
-	ENUM:        "ENUM",
-	STRUCT:      "STRUCT",
-	PUBLIC:      "PUBLIC",
-	PROTECTED:   "PROTECTED",
-	PRIVATE:     "PRIVATE",
-	OVERRIDE:    "OVERRIDE",
-	THIS:        "THIS",
-	NEW:         "NEW",
-	SUPER:       "SUPER",
-	CONSTRUCTOR: "CONSTRUCTOR",
-	DATA:        "DATA",
-	INSTANCEOF:  "INSTANCEOF",
+	ILLEGAL:   "ILLEGAL",
+
+	IDENTIFIER:          "IDENTIFIER",
+	INT_LITERAL:         "INT_LITERAL",
+	FLOAT_LITERAL:       "FLOAT_LITERAL",
+	STRING_LITERAL:      "STRING_LITERAL",
+	CHAR_LITERAL:        "CHAR_LITERAL",
+	INTERPOLATED_STRING: "INTERPOLATED_STRING",
+
+	CLASS:      "CLASS",
+	INTERFACE:  "INTERFACE",
+	EXTENDS:    "EXTENDS",
+	IMPORT:     "IMPORT",
+	EXPORT:     "EXPORT",
+	ENUM:       "ENUM",
+	STRUCT:     "STRUCT",
+	PUBLIC:     "PUBLIC",
+	PROTECTED:  "PROTECTED",
+	PRIVATE:    "PRIVATE",
+	OVERRIDE:   "OVERRIDE",
+	THIS:       "THIS",
+	NEW:        "NEW",
+	SUPER:      "SUPER",
+	INSTANCEOF: "INSTANCEOF",
 
 	IF:       "IF",
 	ELSE:     "ELSE",
@@ -182,25 +236,19 @@ var tokenStrings = map[TokenType]string{
 	FALSE: "FALSE",
 	NIL:   "NIL",
 
-	LET:    "LET",
-	VAL:    "VAL",
-	GLOBAL: "GLOBAL",
+	LET: "LET",
+	VAL: "VAL",
 
 	AND: "AND",
 	OR:  "OR",
 	NOT: "NOT",
-	IS:  "IS",
-	IN:  "IN",
-	OF:  "OF",
 
 	TRY:     "TRY",
 	CATCH:   "CATCH",
 	FINALLY: "FINALLY",
 	THROW:   "THROW",
 
-	TURN:    "TURN",
-	CASE:    "CASE",
-	DEFAULT: "DEFAULT",
+	CASE: "CASE",
 
 	PLUS:          "PLUS",
 	MINUS:         "MINUS",
@@ -210,6 +258,7 @@ var tokenStrings = map[TokenType]string{
 	AND_AND:       "AND_AND",
 	OR_OR:         "OR_OR",
 	NOT_BANG:      "NOT_BANG",
+	BANG_EQUAL:    "BANG_EQUAL",
 	EQUAL_EQUAL:   "EQUAL_EQUAL",
 	COLON:         "COLON",
 	GREATER:       "GREATER",
@@ -219,8 +268,19 @@ var tokenStrings = map[TokenType]string{
 	MINUS_MINUS:   "MINUS_MINUS",
 	PLUS_PLUS:     "PLUS_PLUS",
 	DOLLAR:        "DOLLAR",
-
-	EQUAL: "EQUAL",
+	TILDE:         "TILDE",
+	STAR_STAR:     "STAR_STAR",
+
+	EQUAL:             "EQUAL",
+	PLUS_EQUAL:        "PLUS_EQUAL",
+	MINUS_EQUAL:       "MINUS_EQUAL",
+	STAR_EQUAL:        "STAR_EQUAL",
+	SLASH_EQUAL:       "SLASH_EQUAL",
+	PERCENT_EQUAL:     "PERCENT_EQUAL",
+	BIT_AND_EQUAL:     "BIT_AND_EQUAL",
+	BIT_OR_EQUAL:      "BIT_OR_EQUAL",
+	SHIFT_LEFT_EQUAL:  "SHIFT_LEFT_EQUAL",
+	SHIFT_RIGHT_EQUAL: "SHIFT_RIGHT_EQUAL",
 
 	LEFT_PAREN:    "LEFT_PAREN",
 	RIGHT_PAREN:   "RIGHT_PAREN",
@@ -235,6 +295,11 @@ var tokenStrings = map[TokenType]string{
 	QUESTION:    "QUESTION",
 	ELLIPSIS:    "ELLIPSIS",
 
+	QUESTION_DOT:      "QUESTION_DOT",
+	QUESTION_QUESTION: "QUESTION_QUESTION",
+	DOT_DOT:           "DOT_DOT",
+	DOT_DOT_LESS:      "DOT_DOT_LESS",
+
 	BIT_AND: "BIT_AND",
 	BIT_OR:  "BIT_OR",
 	BIT_XOR: "BIT_XOR",
@@ -245,6 +310,7 @@ var tokenStrings = map[TokenType]string{
 	COMMA:     "COMMA",
 	DOT:       "DOT",
 	SEMICOLON: "SEMICOLON",
+	NEWLINE:   "NEWLINE",
 }
 
 func itoa(i int) string {
@@ -0,0 +1,167 @@
+package lexer
+
+import "strings"
+
+// Mode selects optional lexer behaviour.
+type Mode uint
+
+const (
+	// KeepTrivia attaches whitespace, line breaks and comments to the
+	// tokens around them, so that joining every token's Leading trivia,
+	// Lexeme and Trailing trivia reproduces the source exactly.
+	KeepTrivia Mode = 1 << iota
+)
+
+type TriviaKind int
+
+const (
+	TriviaWhitespace TriviaKind = iota
+	TriviaNewline
+	TriviaLineComment
+	TriviaBlockComment
+	TriviaDocComment
+	TriviaShebang
+)
+
+var triviaKindNames = map[TriviaKind]string{
+	TriviaWhitespace:   "whitespace",
+	TriviaNewline:      "newline",
+	TriviaLineComment:  "line comment",
+	TriviaBlockComment: "block comment",
+	TriviaDocComment:   "doc comment",
+	TriviaShebang:      "shebang",
+}
+
+func (k TriviaKind) String() string {
+	return triviaKindNames[k]
+}
+
+// Trivia is source text that is not part of any token. A token's trailing
+// trivia runs up to the end of its line; everything after that up to the
+// next token is that token's leading trivia.
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+	Span Span
+}
+
+func (l *Lexer) SetMode(mode Mode) {
+	l.mode = mode
+}
+
+// addTrivia records source[start:current], which scanToken skipped.
+func (l *Lexer) addTrivia() {
+	text := l.source[l.start:l.current]
+	kind := TriviaWhitespace
+	switch {
+	case text == "\n":
+		kind = TriviaNewline
+	case strings.HasPrefix(text, "#!"):
+		kind = TriviaShebang
+	case isDocComment(text):
+		kind = TriviaDocComment
+	case strings.HasPrefix(text, "//"):
+		kind = TriviaLineComment
+	case strings.HasPrefix(text, "/*"):
+		kind = TriviaBlockComment
+	}
+
+	list := &l.trivia
+	if kind == TriviaNewline {
+		l.endTrailing()
+	} else if l.trailing && len(l.tokens) > 0 {
+		list = &l.trailingTrivia
+	}
+	if n := len(*list); n > 0 && kind == TriviaWhitespace && (*list)[n-1].Kind == TriviaWhitespace {
+		last := &(*list)[n-1]
+		last.Span = last.Span.To(l.tokenSpan())
+		last.Text = l.source[last.Span.StartOffset:l.current]
+		return
+	}
+	*list = append(*list, Trivia{Kind: kind, Text: text, Span: l.tokenSpan()})
+}
+
+// takeTrivia returns the pending leading trivia for the token being added.
+func (l *Lexer) takeTrivia() []Trivia {
+	trivia := keep(&l.triviaBlock, l.trivia)
+	l.trivia = l.trivia[:0]
+	return trivia
+}
+
+// endTrailing gives the pending trailing trivia to the last token, which
+// has none until its line ends or another token starts.
+func (l *Lexer) endTrailing() {
+	if len(l.trailingTrivia) > 0 && len(l.tokens) > 0 {
+		l.tokens[len(l.tokens)-1].Trailing = keep(&l.triviaBlock, l.trailingTrivia)
+	}
+	l.trailingTrivia = l.trailingTrivia[:0]
+	l.trailing = false
+}
+
+// isDocComment reports whether a comment is a `///` line or a `/** */`
+// block. Four or more slashes, `/**/` and `/***` make plain comments.
+func isDocComment(text string) bool {
+	if strings.HasPrefix(text, "///") {
+		return !strings.HasPrefix(text, "////")
+	}
+	return strings.HasPrefix(text, "/**") && !strings.HasPrefix(text, "/***") && text != "/**/"
+}
+
+// addDoc collects a doc comment for the next token. Consecutive `///`
+// lines form one comment, joined in the arena.
+func (l *Lexer) addDoc(comment string) {
+	if strings.HasPrefix(comment, "///") && l.doc == "" {
+		l.doc = lineDocText(comment)
+		return
+	}
+	// The doc so far is usually the last text in the arena, and if so it
+	// is extended in place.
+	var mark int
+	if l.doc != "" && strings.HasSuffix(l.arena.String(), l.doc) {
+		mark = l.arena.Len() - len(l.doc)
+	} else {
+		mark = l.beginText()
+		l.arena.WriteString(l.doc)
+	}
+	if l.doc != "" {
+		l.arena.WriteByte('\n')
+	}
+	if strings.HasPrefix(comment, "///") {
+		l.arena.WriteString(lineDocText(comment))
+	} else {
+		writeBlockDoc(&l.arena, comment)
+	}
+	l.doc = l.endText(mark)
+}
+
+func lineDocText(comment string) string {
+	text := strings.TrimPrefix(strings.TrimPrefix(comment, "///"), " ")
+	return strings.TrimRight(text, " \t\r")
+}
+
+// writeBlockDoc writes the text of a `/** */` comment, without its
+// delimiters, the leading '*' of each line or blank lines at either end.
+func writeBlockDoc(sb *strings.Builder, comment string) {
+	body := strings.TrimSuffix(strings.TrimPrefix(comment, "/**"), "*/")
+	written, blank := false, 0
+	for more := true; more; {
+		var line string
+		line, body, more = strings.Cut(body, "\n")
+		line = strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(line, "*") {
+			line = strings.TrimPrefix(strings.TrimPrefix(line, "*"), " ")
+		}
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case line == "":
+			blank++
+			continue
+		case written:
+			for ; blank >= 0; blank-- {
+				sb.WriteByte('\n')
+			}
+		}
+		sb.WriteString(line)
+		written, blank = true, 0
+	}
+}
@@ -0,0 +1,71 @@
+package lexer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Identifiers follow a simplified form of Unicode UAX #31:
+//
+//	identifier = start { continue }
+//	start      = letter | "_"
+//	continue   = start | decimal digit | combining mark | connector
+//
+// where letter is any rune in category L, decimal digit is category Nd,
+// combining mark is Mn or Mc and connector is Pc. So `préço` and `変数`
+// are identifiers while `1x`, `x-y` and `x₁` (₁ is category No) are not.
+
+func isIdentStart(r rune) bool {
+	if r < utf8.RuneSelf {
+		return isAlpha(byte(r)) || r == '_'
+	}
+	return unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	if r < utf8.RuneSelf {
+		return isAlphaNumeric(byte(r)) || r == '_'
+	}
+	return unicode.IsLetter(r) || unicode.Is(unicode.Nd, r) ||
+		unicode.In(r, unicode.Mn, unicode.Mc, unicode.Pc)
+}
+
+// peekRune decodes the rune at current, returning utf8.RuneError with size
+// 0 at the end of input.
+func (l *Lexer) peekRune() (rune, int) {
+	return l.runeAt(l.current)
+}
+
+func (l *Lexer) runeAt(offset int) (rune, int) {
+	if !l.fill(offset+utf8.UTFMax) && offset >= len(l.source) {
+		return utf8.RuneError, 0
+	}
+	return utf8.DecodeRuneInString(l.source[offset:])
+}
+
+// advanceRune consumes the rest of a multi-byte rune whose first byte has
+// already been read.
+func (l *Lexer) advanceRune() {
+	for !l.isAtEnd() && !utf8.RuneStart(l.peek()) {
+		l.advance()
+	}
+}
+
+// UTF16Column converts a byte offset into a 1-based column counted in
+// UTF-16 code units, as used by the Language Server Protocol.
+func UTF16Column(source string, offset int) int {
+	lineStart := strings.LastIndexByte(source[:offset], '\n') + 1
+	column := 1
+	for _, r := range source[lineStart:offset] {
+		column += utf16Len(r)
+	}
+	return column
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,574 @@
+package parser
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/lexer"
+)
+
+// ContextKind says what is being written at a cursor.
+type ContextKind int
+
+const (
+	// ContextNone is inside a comment or string, where nothing is
+	// completed.
+	ContextNone ContextKind = iota
+	// ContextExpression is where an expression or statement can start.
+	ContextExpression
+	// ContextMember follows '.' or '?.'.
+	ContextMember
+	// ContextType names a type or class: after ':', 'instanceof',
+	// 'extends' or 'new'.
+	ContextType
+	// ContextImport is inside the path of an import.
+	ContextImport
+	// ContextDeclaration names something being declared, such as a
+	// variable or a parameter.
+	ContextDeclaration
+)
+
+var contextKindNames = map[ContextKind]string{
+	ContextNone:        "none",
+	ContextExpression:  "expression",
+	ContextMember:      "member",
+	ContextType:        "type",
+	ContextImport:      "import",
+	ContextDeclaration: "declaration",
+}
+
+func (k ContextKind) String() string {
+	return contextKindNames[k]
+}
+
+// Context describes what is being written at a cursor, for completion and
+// signature help.
+type Context struct {
+	Kind ContextKind
+	// Prefix is the part of the word or import path before the cursor,
+	// which starts at PrefixOffset. A completion replaces it.
+	Prefix       string
+	PrefixOffset int
+	// Receiver is the expression before the '.' of a member access. It is
+	// nil when that cannot be parsed, as after 'super'.
+	Receiver ast.Expr
+	// Callee is what the innermost call whose argument list holds the
+	// cursor calls, and Argument is the index of the argument there. For
+	// 'new C(', Callee is C and New is set. Callee is nil outside a call.
+	Callee   ast.Expr
+	Argument int
+	New      bool
+	// Scope is the innermost scope around the cursor.
+	Scope *Scope
+}
+
+// Scope is a node that opens a scope, with the names declared in it that
+// are visible at the cursor.
+type Scope struct {
+	// Node is a FunctionStmt, ConstructorStmt, LambdaExpr, ClassStmt,
+	// InterfaceStmt, StructStmt, ForStmt, TryStmt or BlockStmt, or nil for
+	// the file.
+	Node   ast.Node
+	Names  []Name
+	Parent *Scope
+}
+
+type Name struct {
+	Name string
+	// Decl declares the name: a statement, a *ast.Parameter, or the
+	// LambdaExpr or TryStmt for a lambda parameter or a caught value.
+	Decl ast.Node
+}
+
+// Lookup returns the innermost declaration of name visible from s.
+func (s *Scope) Lookup(name string) (Name, bool) {
+	for ; s != nil; s = s.Parent {
+		for i := len(s.Names) - 1; i >= 0; i-- {
+			if s.Names[i].Name == name {
+				return s.Names[i], true
+			}
+		}
+	}
+	return Name{}, false
+}
+
+// Visible returns every name visible from s, innermost first, leaving out
+// those that are shadowed.
+func (s *Scope) Visible() []Name {
+	seen := map[string]bool{}
+	var names []Name
+	for ; s != nil; s = s.Parent {
+		for i := len(s.Names) - 1; i >= 0; i-- {
+			if n := s.Names[i]; !seen[n.Name] {
+				seen[n.Name] = true
+				names = append(names, n)
+			}
+		}
+	}
+	return names
+}
+
+// ContextAt describes what is being written at byte offset in the source
+// lexed into tokens. It works on broken and unfinished code: the tokens
+// before the cursor are parsed as if the file ended there with every open
+// bracket closed, so the scopes around the cursor are found whatever
+// follows it. Comments are only recognised when the tokens were lexed
+// with lexer.KeepTrivia.
+func ContextAt(tokens []lexer.Token, offset int) *Context {
+	c := &Context{Kind: ContextExpression, PrefixOffset: offset}
+	if len(tokens) == 0 {
+		c.Scope = &Scope{}
+		return c
+	}
+	cut := cursorToken(tokens, offset)
+	c.Scope = scopeAt(tokens, cut, offset)
+	if inComment(tokens, offset) {
+		c.Kind = ContextNone
+		return c
+	}
+	c.describe(tokens, offset)
+	return c
+}
+
+// cursorToken returns the index of the first token that does not end
+// before offset, or that of the next one if offset is just past a token
+// that is not a word.
+func cursorToken(tokens []lexer.Token, offset int) int {
+	i := 0
+	for i < len(tokens)-1 && tokens[i].Span.EndOffset < offset {
+		i++
+	}
+	if i < len(tokens)-1 {
+		if span := tokens[i].Span; span.StartOffset < offset && span.EndOffset == offset && !isWord(tokens[i]) && !unterminated(tokens[i]) {
+			i++
+		}
+	}
+	return i
+}
+
+// isWord reports whether tok is an identifier or keyword, which a
+// completion can replace.
+func isWord(tok lexer.Token) bool {
+	r, _ := utf8.DecodeRuneInString(tok.Lexeme)
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// unterminated reports whether tok is a string the lexer found no end to.
+func unterminated(tok lexer.Token) bool {
+	return tok.Type == lexer.ILLEGAL && len(tok.Lexeme) > 0 && (tok.Lexeme[0] == '"' || tok.Lexeme[0] == '\'')
+}
+
+func inComment(tokens []lexer.Token, offset int) bool {
+	for _, tok := range tokens {
+		if fullStart(tok) > offset {
+			break
+		}
+		for _, list := range [][]lexer.Trivia{tok.Leading, tok.Trailing} {
+			for _, t := range list {
+				switch t.Kind {
+				case lexer.TriviaLineComment, lexer.TriviaDocComment, lexer.TriviaShebang:
+					if t.Span.StartOffset < offset && offset <= t.Span.EndOffset {
+						return true
+					}
+				case lexer.TriviaBlockComment:
+					if t.Span.StartOffset < offset && offset < t.Span.EndOffset {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// describe fills in everything but the scope from the tokens around
+// offset.
+func (c *Context) describe(tokens []lexer.Token, offset int) {
+	cut := cursorToken(tokens, offset)
+	if tok := tokens[cut]; tok.Span.StartOffset < offset {
+		if !isWord(tok) {
+			c.inString(tokens, cut, offset)
+			return
+		}
+		c.Prefix = tok.Lexeme[:offset-tok.Span.StartOffset]
+		c.PrefixOffset = tok.Span.StartOffset
+	}
+
+	params := c.call(tokens, cut)
+	if cut == 0 {
+		return
+	}
+	switch before := tokens[cut-1]; before.Type {
+	case lexer.DOT, lexer.QUESTION_DOT:
+		c.Kind = ContextMember
+		c.Receiver = parseOperand(tokens, cut-1)
+	case lexer.COLON, lexer.INSTANCEOF, lexer.EXTENDS, lexer.NEW:
+		c.Kind = ContextType
+	case lexer.RIGHT_BRACKET:
+		// An array type, as in 'let a: []'.
+		i := cut - 1
+		for i > 0 && tokens[i].Type == lexer.RIGHT_BRACKET && tokens[i-1].Type == lexer.LEFT_BRACKET {
+			i -= 2
+		}
+		if i < cut-1 && tokens[i].Type == lexer.COLON {
+			c.Kind = ContextType
+		}
+	case lexer.FUN, lexer.LET, lexer.VAL, lexer.CLASS, lexer.INTERFACE, lexer.STRUCT, lexer.ENUM, lexer.CATCH:
+		c.Kind = ContextDeclaration
+	case lexer.LEFT_PAREN, lexer.COMMA:
+		if params {
+			c.Kind = ContextDeclaration
+		}
+	}
+}
+
+// inString handles a cursor inside tokens[i], a string or an unterminated
+// one: the path of an import, an interpolated expression or plain text.
+func (c *Context) inString(tokens []lexer.Token, i, offset int) {
+	tok := tokens[i]
+	start := tok.Span.StartOffset
+	switch {
+	case tok.Type != lexer.STRING_LITERAL && tok.Type != lexer.INTERPOLATED_STRING && !unterminated(tok):
+		c.Kind = ContextNone
+
+	case i > 0 && tokens[i-1].Type == lexer.IMPORT:
+		c.Kind = ContextImport
+		c.Prefix = tok.Lexeme[1 : offset-start]
+		c.PrefixOffset = start + 1
+
+	case tok.Type == lexer.INTERPOLATED_STRING:
+		c.Kind = ContextNone
+		for _, part := range tok.Literal.Parts {
+			if !part.IsExpr() {
+				continue
+			}
+			// Skip the '$' or '${', and for the latter stop before '}'.
+			first, last := part.Span.StartOffset+1, part.Span.EndOffset
+			if tok.Lexeme[first-start] == '{' {
+				first, last = first+1, last-1
+			}
+			if first <= offset && offset <= last {
+				c.Kind = ContextExpression
+				c.describe(part.Tokens, offset)
+				return
+			}
+		}
+
+	default:
+		c.Kind = ContextNone
+	}
+}
+
+// call finds the innermost call whose argument list holds the cursor,
+// which is just before tokens[cut]. It reports whether the cursor is in a
+// parameter list instead.
+func (c *Context) call(tokens []lexer.Token, cut int) bool {
+	depth, commas := 0, 0
+	for i := cut - 1; i >= 0; i-- {
+		switch tokens[i].Type {
+		case lexer.RIGHT_PAREN, lexer.RIGHT_BRACKET, lexer.RIGHT_BRACE:
+			depth++
+		case lexer.LEFT_BRACKET, lexer.LEFT_BRACE:
+			if depth == 0 {
+				return false
+			}
+			depth--
+		case lexer.COMMA:
+			if depth == 0 {
+				commas++
+			}
+		case lexer.LEFT_PAREN:
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if i == 0 {
+				return false
+			}
+			prev := tokens[i-1]
+			switch {
+			case prev.Type == lexer.CATCH,
+				prev.Type == lexer.IDENTIFIER && prev.Lexeme == "constructor",
+				prev.Type == lexer.IDENTIFIER && i >= 2 && (tokens[i-2].Type == lexer.FUN || tokens[i-2].Lexeme == "data"):
+				return true
+			case prev.Type == lexer.IDENTIFIER && i >= 2 && tokens[i-2].Type == lexer.NEW:
+				c.Callee = &ast.VariableExpr{Span: prev.Span, Name: &ast.Identifier{Span: prev.Span, Name: prev.Lexeme}}
+				c.New = true
+			case endsOperand(prev.Type):
+				c.Callee = parseOperand(tokens, i)
+			}
+			if c.Callee != nil {
+				c.Argument = commas
+			}
+			return false
+		}
+	}
+	return false
+}
+
+func endsOperand(t lexer.TokenType) bool {
+	switch t {
+	case lexer.IDENTIFIER, lexer.THIS, lexer.TRUE, lexer.FALSE, lexer.NIL,
+		lexer.INT_LITERAL, lexer.FLOAT_LITERAL, lexer.STRING_LITERAL, lexer.CHAR_LITERAL, lexer.INTERPOLATED_STRING,
+		lexer.RIGHT_PAREN, lexer.RIGHT_BRACKET:
+		return true
+	}
+	return false
+}
+
+// parseOperand parses the chain of calls, indexes and member accesses
+// that ends just before tokens[end], or returns nil if there is none.
+func parseOperand(tokens []lexer.Token, end int) ast.Expr {
+	start := operandStart(tokens, end)
+	if start == end {
+		return nil
+	}
+	eof := lexer.Token{Type: lexer.EOF_TOKEN, Line: tokens[end].Line, Column: tokens[end].Column, Span: emptySpan(tokens[end].Span)}
+	p := NewParser(append(tokens[start:end:end], eof))
+	if err := p.expression(); err != nil || !p.isAtEnd() {
+		return nil
+	}
+	return p.expr()
+}
+
+// operandStart returns the index of the first token of the operand chain
+// ending just before tokens[end].
+func operandStart(tokens []lexer.Token, end int) int {
+	i := end
+	for i > 0 {
+		j := i - 1
+		switch t := tokens[j].Type; {
+		case t == lexer.RIGHT_PAREN || t == lexer.RIGHT_BRACKET:
+			if j = openingBracket(tokens, j); j < 0 {
+				return i
+			}
+			if j > 0 && endsOperand(tokens[j-1].Type) {
+				// A call or an index continues the chain.
+				i = j
+				continue
+			}
+			if j > 1 && tokens[j-1].Type == lexer.IDENTIFIER && tokens[j-2].Type == lexer.NEW {
+				j -= 2
+			}
+		case endsOperand(t) || t == lexer.SUPER:
+		default:
+			return i
+		}
+		if j > 0 && (tokens[j-1].Type == lexer.DOT || tokens[j-1].Type == lexer.QUESTION_DOT) {
+			i = j - 1
+			continue
+		}
+		return j
+	}
+	return i
+}
+
+// openingBracket returns the index of the bracket that tokens[i] closes,
+// or -1 if it is not closed.
+func openingBracket(tokens []lexer.Token, i int) int {
+	depth := 0
+	for ; i >= 0; i-- {
+		switch tokens[i].Type {
+		case lexer.RIGHT_PAREN, lexer.RIGHT_BRACKET:
+			depth++
+		case lexer.LEFT_PAREN, lexer.LEFT_BRACKET:
+			depth--
+		}
+		if depth == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// scopeAt parses the tokens before the cursor, then an identifier for the
+// word being written, which completes most unfinished expressions, and
+// closes every bracket left open. It returns the innermost scope around
+// offset. The file scope also has the functions and types declared after
+// the cursor, taken from a parse of the whole file.
+func scopeAt(tokens []lexer.Token, cut, offset int) *Scope {
+
+	at := tokens[cut]
+	word := ""
+	if at.Span.StartOffset < offset && isWord(at) {
+		word = at.Lexeme[:offset-at.Span.StartOffset]
+	}
+	synthetic := func(t lexer.TokenType, lexeme string) lexer.Token {
+		span := at.Span
+		span.StartOffset, span.EndOffset = offset, offset
+		return lexer.Token{Type: t, Lexeme: lexeme, Line: at.Line, Column: at.Column, Span: span}
+	}
+	truncated := append([]lexer.Token{}, tokens[:cut]...)
+	var open []lexer.TokenType
+	for _, tok := range truncated {
+		switch tok.Type {
+		case lexer.LEFT_PAREN, lexer.LEFT_BRACKET, lexer.LEFT_BRACE:
+			open = append(open, tok.Type)
+		case lexer.RIGHT_PAREN, lexer.RIGHT_BRACKET, lexer.RIGHT_BRACE:
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	truncated = append(truncated, synthetic(lexer.IDENTIFIER, word))
+	for i := len(open) - 1; i >= 0; i-- {
+		switch open[i] {
+		case lexer.LEFT_PAREN:
+			truncated = append(truncated, synthetic(lexer.RIGHT_PAREN, ")"))
+		case lexer.LEFT_BRACKET:
+			truncated = append(truncated, synthetic(lexer.RIGHT_BRACKET, "]"))
+		case lexer.LEFT_BRACE:
+			truncated = append(truncated, synthetic(lexer.RIGHT_BRACE, "}"))
+		}
+	}
+	truncated = append(truncated, synthetic(lexer.EOF_TOKEN, ""))
+
+	stmts, _ := NewParser(truncated).Parse()
+	file, _ := NewParser(tokens).Parse()
+	scope := &Scope{Names: visibleDecls(stmts, offset)}
+	for _, name := range visibleDecls(file, offset) {
+		if _, ok := scope.Lookup(name.Name); !ok {
+			scope.Names = append(scope.Names, name)
+		}
+	}
+	for _, stmt := range stmts {
+		if contains(stmt, offset) {
+			return innerScope(stmt, offset, scope)
+		}
+	}
+	return scope
+}
+
+// innerScope returns the innermost scope around offset within n, which
+// holds it, given the scope s around n.
+func innerScope(n ast.Node, offset int, s *Scope) *Scope {
+	if names, ok := scopeNames(n, offset); ok {
+		s = &Scope{Node: n, Names: names, Parent: s}
+	}
+	var child ast.Node
+	ast.Inspect(n, func(c ast.Node) bool {
+		if c == n {
+			return true
+		}
+		if child == nil && c != nil && contains(c, offset) {
+			child = c
+		}
+		return false
+	})
+	if child != nil {
+		return innerScope(child, offset, s)
+	}
+	return s
+}
+
+// scopeNames returns the names declared in the scope n opens around
+// offset, and whether it opens one.
+func scopeNames(n ast.Node, offset int) ([]Name, bool) {
+	var names []Name
+	switch n := n.(type) {
+	case *ast.BlockStmt:
+		for _, name := range visibleDecls(n.Statements, offset) {
+			if name.Decl.Pos().Offset < offset {
+				names = append(names, name)
+			}
+		}
+	case *ast.FunctionStmt:
+		names = paramNames(n.Parameters)
+	case *ast.ConstructorStmt:
+		names = paramNames(n.Parameters)
+	case *ast.LambdaExpr:
+		for _, param := range n.Params {
+			names = append(names, Name{Name: param, Decl: n})
+		}
+	case *ast.ClassStmt:
+		names = memberNames(n.Members)
+	case *ast.InterfaceStmt:
+		names = memberNames(n.Members)
+	case *ast.StructStmt:
+		names = memberNames(n.Members)
+	case *ast.ForStmt:
+		if n.Init != nil {
+			if name := declName(n.Init); name != "" {
+				names = append(names, Name{Name: name, Decl: n.Init})
+			}
+		}
+	case *ast.TryStmt:
+		if n.CatchBlock == nil || n.CatchVarName == "" || !contains(n.CatchBlock, offset) {
+			return nil, false
+		}
+		names = append(names, Name{Name: n.CatchVarName, Decl: n})
+	default:
+		return nil, false
+	}
+	return names, true
+}
+
+// visibleDecls returns the names declared by stmts that can be used at
+// offset: every function and type, and the variables declared before it.
+// A variable cannot be used in its own initializer.
+func visibleDecls(stmts []ast.Stmt, offset int) []Name {
+	var names []Name
+	for _, stmt := range stmts {
+		name := declName(stmt)
+		if name == "" {
+			continue
+		}
+		switch stmt.(type) {
+		case *ast.LetStmt, *ast.ValStmt, *ast.GlobalStmt:
+			if stmt.End().Offset >= offset {
+				continue
+			}
+		}
+		names = append(names, Name{Name: name, Decl: stmt})
+	}
+	return names
+}
+
+func paramNames(params []ast.Parameter) []Name {
+	var names []Name
+	for i := range params {
+		names = append(names, Name{Name: params[i].Name, Decl: &params[i]})
+	}
+	return names
+}
+
+func memberNames(members []ast.Stmt) []Name {
+	var names []Name
+	for _, member := range members {
+		if name := declName(member); name != "" {
+			names = append(names, Name{Name: name, Decl: member})
+		}
+	}
+	return names
+}
+
+// declName returns the name stmt declares, or "" if it declares none.
+func declName(stmt ast.Stmt) string {
+	switch s := stmt.(type) {
+	case *ast.LetStmt:
+		return s.Name
+	case *ast.ValStmt:
+		return s.Name
+	case *ast.GlobalStmt:
+		return s.Name
+	case *ast.FunctionStmt:
+		return s.Name
+	case *ast.ClassStmt:
+		return s.Name
+	case *ast.InterfaceStmt:
+		return s.Name
+	case *ast.StructStmt:
+		return s.Name
+	case *ast.EnumStmt:
+		return s.Name
+	case *ast.DataStmt:
+		return s.Name
+	}
+	return ""
+}
+
+// contains reports whether offset is within n or at either end of it.
+func contains(n ast.Node, offset int) bool {
+	return n.Pos().Offset <= offset && offset <= n.End().Offset
+}
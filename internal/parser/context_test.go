@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"dotFun/internal/ast/printer"
+	"dotFun/internal/lexer"
+)
+
+// TestContextAt checks the context at the cursor, marked '|', in each
+// source: its kind, the prefix a completion replaces, the receiver and
+// callee parsed from the partial source around it, and the visible names.
+func TestContextAt(t *testing.T) {
+	tests := []struct {
+		src      string
+		kind     ContextKind
+		prefix   string
+		receiver string
+		callee   string
+		argument int
+		names    string
+	}{
+		// End of input.
+		{src: "let total = 1\nto|", kind: ContextExpression, prefix: "to", names: "total"},
+		{src: "fun f(a, b) {\n  return |", kind: ContextExpression, names: "b a f"},
+		{src: "|", kind: ContextExpression},
+		// Strings, the first two unterminated.
+		{src: "let s = \"abc|", kind: ContextNone},
+		{src: "let s = \"$a |b", kind: ContextNone},
+		{src: "let s = \"a ${x.|}\"", kind: ContextMember, receiver: "x"},
+		{src: "import \"std/i|", kind: ContextImport, prefix: "std/i"},
+		// Comments.
+		{src: "let a = 1 // a |comment\nlet b = 2", kind: ContextNone, names: "a"},
+		{src: "let a = /* inside| */ 1", kind: ContextNone},
+		{src: "/// doc|\nfun f() {}", kind: ContextNone, names: "f"},
+		// Right after '.'.
+		{src: "let p = point.|", kind: ContextMember, receiver: "point"},
+		{src: "print(a.b(1)?.|)", kind: ContextMember, receiver: "a.b(1)", callee: "print"},
+		{src: "let q = 1\nq.b().le|", kind: ContextMember, prefix: "le", receiver: "q.b()", names: "q"},
+		{src: "f(1, (x + y).|", kind: ContextMember, receiver: "x + y", callee: "f", argument: 1},
+		// Elsewhere.
+		{src: "let x: |", kind: ContextType},
+		{src: "fun g(a, |", kind: ContextDeclaration},
+		{src: "max(1, |)", kind: ContextExpression, callee: "max", argument: 1},
+	}
+	for _, tt := range tests {
+		offset := strings.Index(tt.src, "|")
+		src := tt.src[:offset] + tt.src[offset+1:]
+		l := lexer.NewLexer(src)
+		l.SetMode(lexer.KeepTrivia)
+		tokens, _ := l.Lex()
+
+		c := ContextAt(tokens, offset)
+		var receiver, callee string
+		if c.Receiver != nil {
+			receiver = printer.String(c.Receiver)
+		}
+		if c.Callee != nil {
+			callee = printer.String(c.Callee)
+		}
+		var names []string
+		for _, n := range c.Scope.Visible() {
+			names = append(names, n.Name)
+		}
+		if c.Kind != tt.kind || c.Prefix != tt.prefix || receiver != tt.receiver || callee != tt.callee ||
+			c.Argument != tt.argument || strings.Join(names, " ") != tt.names {
+			t.Errorf("%q: got kind %s, prefix %q, receiver %q, callee %q, argument %d, names %q\n"+
+				"want kind %s, prefix %q, receiver %q, callee %q, argument %d, names %q",
+				tt.src, c.Kind, c.Prefix, receiver, callee, c.Argument, strings.Join(names, " "),
+				tt.kind, tt.prefix, tt.receiver, tt.callee, tt.argument, tt.names)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"dotFun/internal/cst"
+	"dotFun/internal/lexer"
+)
+
+// modifiers records which modifiers were written before a declaration,
+// in the order they must appear.
+type modifiers struct {
+	access   bool
+	override bool
+	async    bool
+}
+
+func (p *Parser) modifiers() modifiers {
+	var m modifiers
+	m.access = p.match(lexer.PUBLIC, lexer.PROTECTED, lexer.PRIVATE)
+	m.override = p.match(lexer.OVERRIDE)
+	m.async = p.match(lexer.ASYNC)
+	return m
+}
+
+func isModifier(t lexer.TokenType) bool {
+	switch t {
+	case lexer.PUBLIC, lexer.PROTECTED, lexer.PRIVATE, lexer.OVERRIDE, lexer.ASYNC:
+		return true
+	}
+	return false
+}
+
+func (m modifiers) any() bool {
+	return m.access || m.override || m.async
+}
+
+// modifiedDeclaration parses the declarations that may follow modifiers
+// everywhere: functions, variables and types.
+func (p *Parser) modifiedDeclaration(m modifiers) (cst.Kind, bool, error) {
+	if p.match(lexer.FUN) {
+		return cst.FunctionStmt, true, p.function(false)
+	}
+	if m.override || m.async {
+		return "", true, p.errorAt(p.peek(), "Expected 'fun' after '%s'", p.previous().Lexeme)
+	}
+
+	switch {
+	case p.match(lexer.LET, lexer.VAL):
+		kind, err := p.varDeclaration(p.previous())
+		return kind, true, err
+	case p.match(lexer.CLASS):
+		return cst.ClassStmt, true, p.classDeclaration()
+	case p.match(lexer.INTERFACE):
+		return cst.InterfaceStmt, true, p.interfaceDeclaration()
+	case p.match(lexer.STRUCT):
+		return cst.StructStmt, true, p.structDeclaration()
+	case p.match(lexer.ENUM):
+		return cst.EnumStmt, true, p.enumDeclaration()
+	case p.checkContextual("data") && p.checkNext(lexer.IDENTIFIER):
+		p.advance()
+		return cst.DataStmt, true, p.dataDeclaration()
+	}
+	return "", false, nil
+}
+
+func (p *Parser) classDeclaration() error {
+	if _, err := p.consume(lexer.IDENTIFIER, "Expected class name"); err != nil {
+		return err
+	}
+	if p.match(lexer.EXTENDS) {
+		if _, err := p.consume(lexer.IDENTIFIER, "Expected superclass name after 'extends'"); err != nil {
+			return err
+		}
+	}
+	return p.members("class", p.classMember)
+}
+
+func (p *Parser) classMember(m modifiers) (cst.Kind, error) {
+	if p.checkContextual("constructor") && p.checkNext(lexer.LEFT_PAREN) {
+		if m.any() {
+			return "", p.errorAt(p.peek(), "A constructor cannot have modifiers")
+		}
+		return cst.ConstructorStmt, p.constructor()
+	}
+	if kind, ok, err := p.modifiedDeclaration(m); ok {
+		return kind, err
+	}
+	return "", p.errorAt(p.peek(), "Expected a method, field or constructor")
+}
+
+func (p *Parser) constructor() error {
+	p.advance()
+	if err := p.parameters(); err != nil {
+		return err
+	}
+	return p.block()
+}
+
+func (p *Parser) interfaceDeclaration() error {
+	if _, err := p.consume(lexer.IDENTIFIER, "Expected interface name"); err != nil {
+		return err
+	}
+	return p.members("interface", p.interfaceMember)
+}
+
+func (p *Parser) interfaceMember(m modifiers) (cst.Kind, error) {
+	if m.override {
+		return "", p.errorAt(p.peek(), "'override' is not allowed in an interface")
+	}
+	if !p.match(lexer.FUN) {
+		return "", p.errorAt(p.peek(), "Expected a method")
+	}
+	return cst.FunctionStmt, p.function(true)
+}
+
+func (p *Parser) structDeclaration() error {
+	if _, err := p.consume(lexer.IDENTIFIER, "Expected struct name"); err != nil {
+		return err
+	}
+	return p.members("struct", p.structMember)
+}
+
+func (p *Parser) structMember(m modifiers) (cst.Kind, error) {
+	if m.override || m.async || !p.match(lexer.LET, lexer.VAL) {
+		return "", p.errorAt(p.peek(), "Expected a field")
+	}
+	return p.varDeclaration(p.previous())
+}
+
+// members parses the braced body of a class, interface or struct, reading
+// each member's modifiers before handing it to member.
+func (p *Parser) members(kind string, member func(modifiers) (cst.Kind, error)) error {
+	if _, err := p.consume(lexer.LEFT_BRACE, "Expected '{' before "+kind+" body"); err != nil {
+		return err
+	}
+	p.depth++
+	for p.skipNewlines(); !p.check(lexer.RIGHT_BRACE) && !p.isAtEnd(); p.skipNewlines() {
+		p.recoverStmt(func() (cst.Kind, error) {
+			return member(p.modifiers())
+		})
+	}
+	p.depth--
+	_, err := p.consume(lexer.RIGHT_BRACE, "Expected '}' after "+kind+" body")
+	return err
+}
+
+func (p *Parser) enumDeclaration() error {
+	if _, err := p.consume(lexer.IDENTIFIER, "Expected enum name"); err != nil {
+		return err
+	}
+	if _, err := p.consume(lexer.LEFT_BRACE, "Expected '{' before enum body"); err != nil {
+		return err
+	}
+	for p.skipNewlines(); !p.check(lexer.RIGHT_BRACE); p.skipNewlines() {
+		if _, err := p.consume(lexer.IDENTIFIER, "Expected enum element name"); err != nil {
+			return err
+		}
+		p.skipNewlines()
+		if !p.match(lexer.COMMA) {
+			break
+		}
+	}
+	_, err := p.consume(lexer.RIGHT_BRACE, "Expected '}' after enum elements")
+	return err
+}
+
+func (p *Parser) dataDeclaration() error {
+	p.advance()
+	if err := p.parameters(); err != nil {
+		return err
+	}
+	return p.endStatement()
+}
+
+func (p *Parser) importDeclaration() error {
+	if _, err := p.consume(lexer.STRING_LITERAL, "Expected module path after 'import'"); err != nil {
+		return err
+	}
+	return p.endStatement()
+}
+
+func (p *Parser) exportDeclaration() error {
+	if _, err := p.consume(lexer.IDENTIFIER, "Expected name after 'export'"); err != nil {
+		return err
+	}
+	return p.endStatement()
+}
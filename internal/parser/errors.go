@@ -1 +1,224 @@
 package parser
+
+import (
+	"errors"
+	"fmt"
+
+	"dotFun/internal/cst"
+	"dotFun/internal/lexer"
+)
+
+type ParseError struct {
+	Token   lexer.Token
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	switch e.Token.Type {
+	case lexer.EOF_TOKEN:
+		return e.Message + " at end"
+	case lexer.NEWLINE:
+		return e.Message + " at end of line"
+	}
+	return fmt.Sprintf("%s at '%s'", e.Message, e.Token.Lexeme)
+}
+
+func (e *ParseError) Position() (int, int) {
+	return e.Token.Line, e.Token.Column
+}
+
+func (e *ParseError) Offsets() (int, int) {
+	return e.Token.Span.StartOffset, e.Token.Span.EndOffset
+}
+
+// ErrorList collects every syntax error from one Parse call, in the order
+// they were found.
+type ErrorList []*ParseError
+
+func (el ErrorList) Error() string {
+	switch len(el) {
+	case 0:
+		return "no errors"
+	case 1:
+		return fmt.Sprintf("%d:%d: %s", el[0].Token.Line, el[0].Token.Column, el[0])
+	}
+	return fmt.Sprintf("%d:%d: %s (and %d more errors)", el[0].Token.Line, el[0].Token.Column, el[0], len(el)-1)
+}
+
+// Err returns el as an error, or nil when it is empty.
+func (el ErrorList) Err() error {
+	if len(el) == 0 {
+		return nil
+	}
+	return el
+}
+
+// report records err. Errors at ILLEGAL tokens, or at the end of a file
+// that ends in one, are dropped because the lexer has already reported the
+// bad input there.
+func (p *Parser) report(err error) {
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		pe = &ParseError{Token: p.peek(), Message: err.Error()}
+	}
+	switch pe.Token.Type {
+	case lexer.ILLEGAL:
+		return
+	case lexer.EOF_TOKEN:
+		if n := len(p.tokens); n > 1 && p.tokens[n-2].Type == lexer.ILLEGAL {
+			return
+		}
+	}
+	p.errors = append(p.errors, pe)
+}
+
+// syncTokens start a declaration or statement, so the parser can resume
+// at them after an error.
+var syncTokens = map[lexer.TokenType]bool{
+	lexer.FUN: true, lexer.LET: true, lexer.VAL: true,
+	lexer.CLASS: true, lexer.INTERFACE: true, lexer.STRUCT: true, lexer.ENUM: true,
+	lexer.PUBLIC: true, lexer.PROTECTED: true, lexer.PRIVATE: true,
+	lexer.OVERRIDE: true, lexer.ASYNC: true,
+	lexer.IF: true, lexer.WHILE: true, lexer.FOR: true, lexer.RETURN: true,
+	lexer.TRY: true, lexer.THROW: true, lexer.IMPORT: true, lexer.EXPORT: true,
+}
+
+// recoverStmt runs parse, which parses one statement or member and
+// returns its kind. If it fails, recoverStmt records the error, skips to
+// the next statement boundary and makes the tokens it gave up on a
+// BadStmt.
+func (p *Parser) recoverStmt(parse func() (cst.Kind, error)) {
+	start, cp := p.current, p.checkpoint()
+	kind, err := parse()
+	if err == nil {
+		p.finish(cp, kind)
+		return
+	}
+	p.report(err)
+	p.synchronize(start)
+	p.finish(cp, cst.BadStmt)
+}
+
+// synchronize skips tokens after an error in the statement that started
+// at token start. It stops just past a statement terminator, before a
+// token in syncTokens, or before the '}' that closes the enclosing body.
+// Braced bodies are parsed by recoverBody rather than skipped, so the
+// errors inside them are reported too. At least one token is always
+// skipped unless it is that '}'.
+func (p *Parser) synchronize(start int) {
+	bodies := 0
+	for !p.isAtEnd() {
+		if p.current > start {
+			if t := p.previous().Type; t == lexer.SEMICOLON || t == lexer.NEWLINE {
+				return
+			}
+			if syncTokens[p.peek().Type] {
+				return
+			}
+		}
+		switch {
+		case p.check(lexer.RIGHT_BRACE) && p.depth > 0:
+			return
+		case p.check(lexer.LEFT_BRACE):
+			p.recoverBody(start, bodies == 0)
+			bodies++
+		default:
+			p.advance()
+		}
+	}
+}
+
+// recoverBody parses the braced body at the current token, part of the
+// statement that started at token start. The first body of a type
+// declaration holds its members and that of a turn statement its cases;
+// an enum's holds no statements and is skipped. Every other body is a
+// block.
+func (p *Parser) recoverBody(start int, first bool) {
+	for start < p.current && isModifier(p.tokens[start].Type) {
+		start++
+	}
+	var err error
+	switch header := p.tokens[start]; {
+	case !first:
+		err = p.block()
+	case header.Type == lexer.CLASS:
+		err = p.members("class", p.classMember)
+	case header.Type == lexer.INTERFACE:
+		err = p.members("interface", p.interfaceMember)
+	case header.Type == lexer.STRUCT:
+		err = p.members("struct", p.structMember)
+	case header.Type == lexer.ENUM:
+		p.skipBraces()
+	case header.Type == lexer.IDENTIFIER && header.Lexeme == "turn":
+		err = p.turnBody()
+	default:
+		err = p.block()
+	}
+	if err != nil {
+		p.report(err)
+	}
+}
+
+// skipBraces skips the '{' at the current token and everything up to and
+// including its matching '}'.
+func (p *Parser) skipBraces() {
+	depth := 0
+	for !p.isAtEnd() {
+		switch p.advance().Type {
+		case lexer.LEFT_BRACE:
+			depth++
+		case lexer.RIGHT_BRACE:
+			depth--
+		}
+		if depth == 0 {
+			return
+		}
+	}
+}
+
+// recoverElement handles err, raised while parsing the element of a ','
+// separated list, closed by closing, that started at token start. If the
+// element ends on the same line, at a ',' or closing outside any nested
+// brackets, it records err, moves there and returns nil, leaving the
+// caller to make the element a BadExpr. Otherwise it returns err for the
+// enclosing statement to handle.
+func (p *Parser) recoverElement(start int, closing lexer.TokenType, err error) error {
+	depth := 0
+	for i := start; i < len(p.tokens); i++ {
+		switch p.tokens[i].Type {
+		case lexer.LEFT_PAREN, lexer.LEFT_BRACKET:
+			depth++
+			continue
+		case lexer.RIGHT_PAREN, lexer.RIGHT_BRACKET:
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if p.tokens[i].Type != closing {
+				return err
+			}
+		case lexer.COMMA:
+			if depth > 0 {
+				continue
+			}
+		case lexer.LEFT_BRACE, lexer.RIGHT_BRACE, lexer.NEWLINE, lexer.SEMICOLON, lexer.EOF_TOKEN:
+			return err
+		default:
+			continue
+		}
+
+		if i < p.current {
+			return err
+		}
+		p.report(err)
+		p.current = i
+		return nil
+	}
+	return err
+}
+
+// emptySpan returns the empty span where s starts.
+func emptySpan(s lexer.Span) lexer.Span {
+	s.EndOffset, s.EndLine, s.EndCol = s.StartOffset, s.StartLine, s.StartCol
+	return s
+}
@@ -1 +1,295 @@
 package parser
+
+import (
+	"dotFun/internal/cst"
+	"dotFun/internal/lexer"
+)
+
+func (p *Parser) expression() error {
+	return p.parsePrecedence(precAssignment)
+}
+
+// parsePrecedence parses an expression made of operators that bind at
+// least as tightly as min. It reads a prefix expression and then, while
+// the next token is an infix or postfix operator from infixOperators that
+// binds tightly enough and accepts what has been parsed so far as its left
+// operand, folds that operator in.
+func (p *Parser) parsePrecedence(min precedence) error {
+	cp := p.checkpoint()
+	if min <= precAssignment && p.isLambda() {
+		if err := p.lambda(); err != nil {
+			return err
+		}
+		p.finish(cp, cst.LambdaExpr)
+		return nil
+	}
+
+	kind, leftPrec, err := p.prefix()
+	if err != nil {
+		return err
+	}
+	left := p.finish(cp, kind)
+	for {
+		op, ok := infixOperators[p.peek().Type]
+		if !ok || op.precedence < min || leftPrec < op.leftMin() {
+			return nil
+		}
+		kind, err := p.infix(left, p.advance(), op)
+		if err != nil {
+			return err
+		}
+		left = p.finish(cp, kind)
+		leftPrec = op.precedence
+	}
+}
+
+// prefix parses a unary expression or a primary one and returns its kind
+// and the precedence it binds at.
+func (p *Parser) prefix() (cst.Kind, precedence, error) {
+	if !prefixOperators[p.peek().Type] {
+		kind, err := p.primary()
+		return kind, precCall, err
+	}
+	p.advance()
+	if err := p.parsePrecedence(precUnary); err != nil {
+		return "", 0, err
+	}
+	return cst.UnaryExpr, precUnary, nil
+}
+
+// infix parses the rest of the expression that operator, already
+// consumed, continues from left, and returns its kind.
+func (p *Parser) infix(left *cst.GreenNode, operator lexer.Token, op operator) (cst.Kind, error) {
+	switch operator.Type {
+	case lexer.LEFT_PAREN:
+		return cst.CallExpr, p.arguments()
+
+	case lexer.DOT, lexer.QUESTION_DOT:
+		_, err := p.consume(lexer.IDENTIFIER, "Expected property name after '"+operator.Lexeme+"'")
+		return cst.MemberExpr, err
+
+	case lexer.PLUS_PLUS, lexer.MINUS_MINUS:
+		if left.Kind != cst.VariableExpr {
+			return "", p.errorAt(operator, "Invalid operand for '%s'", operator.Lexeme)
+		}
+		return cst.PostfixUnaryExpr, nil
+
+	case lexer.INSTANCEOF:
+		return cst.InstanceOfExpr, p.parseType()
+	}
+
+	if err := p.parsePrecedence(op.rightMin()); err != nil {
+		return "", err
+	}
+
+	switch op.precedence {
+	case precAssignment:
+		switch {
+		case left.Kind == cst.VariableExpr:
+			return cst.AssignExpr, nil
+		case left.Kind == cst.MemberExpr && !isOptional(left):
+			return cst.MemberAssignExpr, nil
+		}
+		return "", p.errorAt(operator, "Invalid assignment target")
+	case precNilCoalescing, precOr, precAnd:
+		return cst.LogicalExpr, nil
+	case precRange:
+		return cst.RangeExpr, nil
+	}
+	return cst.BinaryExpr, nil
+}
+
+// isOptional reports whether member, a MemberExpr node, is written with
+// '?.'.
+func isOptional(member *cst.GreenNode) bool {
+	return member.Children[1].(*cst.GreenToken).Type == lexer.QUESTION_DOT
+}
+
+// isLambda reports whether a lambda starts at the current token.
+func (p *Parser) isLambda() bool {
+	if p.check(lexer.IDENTIFIER) {
+		return p.checkNext(lexer.ARROW) || p.checkNext(lexer.FAT_ARROW)
+	}
+	return p.check(lexer.LEFT_PAREN) && p.isLambdaParams()
+}
+
+// isLambdaParams reports whether the parenthesised group starting at the
+// current token is a lambda parameter list, i.e. it is followed by '->'
+// or '=>'.
+func (p *Parser) isLambdaParams() bool {
+	depth := 0
+	for i := p.current; i < len(p.tokens); i++ {
+		switch p.tokens[i].Type {
+		case lexer.LEFT_PAREN:
+			depth++
+		case lexer.RIGHT_PAREN:
+			depth--
+			if depth == 0 {
+				return i+1 < len(p.tokens) &&
+					(p.tokens[i+1].Type == lexer.ARROW || p.tokens[i+1].Type == lexer.FAT_ARROW)
+			}
+		case lexer.EOF_TOKEN:
+			return false
+		}
+	}
+	return false
+}
+
+func (p *Parser) lambda() error {
+	if p.match(lexer.LEFT_PAREN) {
+		if !p.check(lexer.RIGHT_PAREN) {
+			for {
+				if _, err := p.consume(lexer.IDENTIFIER, "Expected parameter name"); err != nil {
+					return err
+				}
+				if !p.match(lexer.COMMA) {
+					break
+				}
+			}
+		}
+		if _, err := p.consume(lexer.RIGHT_PAREN, "Expected ')' after lambda parameters"); err != nil {
+			return err
+		}
+	} else {
+		p.advance()
+	}
+
+	if !p.match(lexer.ARROW, lexer.FAT_ARROW) {
+		return p.errorAt(p.peek(), "Expected '->' after lambda parameters")
+	}
+	return p.expression()
+}
+
+// arguments parses a comma separated argument list; the opening '(' has
+// already been consumed.
+func (p *Parser) arguments() error {
+	if !p.check(lexer.RIGHT_PAREN) {
+		for {
+			if err := p.element(lexer.RIGHT_PAREN); err != nil {
+				return err
+			}
+			if !p.match(lexer.COMMA) {
+				break
+			}
+		}
+	}
+	_, err := p.consume(lexer.RIGHT_PAREN, "Expected ')' after arguments")
+	return err
+}
+
+// element parses one element of a ',' separated list closed by closing,
+// which becomes a BadExpr if it has errors that recoverElement can skip.
+func (p *Parser) element(closing lexer.TokenType) error {
+	start, cp := p.current, p.checkpoint()
+	err := p.expression()
+	if err == nil {
+		return nil
+	}
+	if err := p.recoverElement(start, closing, err); err != nil {
+		return err
+	}
+	p.finish(cp, cst.BadExpr)
+	return nil
+}
+
+// primary parses a primary expression and returns its kind.
+func (p *Parser) primary() (cst.Kind, error) {
+	tok := p.peek()
+
+	switch {
+	case p.match(lexer.TRUE, lexer.FALSE):
+		return cst.BoolLiteral, nil
+	case p.match(lexer.NIL):
+		return cst.NilLiteral, nil
+
+	case p.match(lexer.INT_LITERAL):
+		return cst.IntLiteral, nil
+	case p.match(lexer.FLOAT_LITERAL):
+		return cst.FloatLiteral, nil
+
+	case p.match(lexer.STRING_LITERAL):
+		return cst.StringLiteral, nil
+
+	case p.match(lexer.INTERPOLATED_STRING):
+		p.interpolatedString(tok)
+		return cst.InterpolatedString, nil
+
+	case p.match(lexer.CHAR_LITERAL):
+		return cst.CharLiteral, nil
+
+	case p.match(lexer.THIS):
+		return cst.ThisExpr, nil
+
+	case p.match(lexer.SUPER):
+		if _, err := p.consume(lexer.DOT, "Expected '.' after 'super'"); err != nil {
+			return "", err
+		}
+		_, err := p.consume(lexer.IDENTIFIER, "Expected superclass method name")
+		return cst.SuperExpr, err
+
+	case p.match(lexer.NEW):
+		if _, err := p.consume(lexer.IDENTIFIER, "Expected class name after 'new'"); err != nil {
+			return "", err
+		}
+		if _, err := p.consume(lexer.LEFT_PAREN, "Expected '(' after class name"); err != nil {
+			return "", err
+		}
+		return cst.NewExpr, p.arguments()
+
+	case p.match(lexer.ILLEGAL):
+		// The lexer has reported what is wrong with it.
+		return cst.BadExpr, nil
+
+	case p.match(lexer.IDENTIFIER):
+		return cst.VariableExpr, nil
+
+	case p.match(lexer.LEFT_PAREN):
+		if err := p.expression(); err != nil {
+			return "", err
+		}
+		_, err := p.consume(lexer.RIGHT_PAREN, "Expected ')' after expression")
+		return cst.GroupingExpr, err
+
+	case p.match(lexer.LEFT_BRACKET):
+		if !p.check(lexer.RIGHT_BRACKET) {
+			for {
+				if err := p.element(lexer.RIGHT_BRACKET); err != nil {
+					return "", err
+				}
+				if !p.match(lexer.COMMA) || p.check(lexer.RIGHT_BRACKET) {
+					break
+				}
+			}
+		}
+		_, err := p.consume(lexer.RIGHT_BRACKET, "Expected ']' after array elements")
+		return cst.ArrayLiteral, err
+	}
+
+	return "", p.errorAt(tok, "Expected expression")
+}
+
+// interpolatedString adds the interpolated string tok, the last consumed
+// token, to the tree, parsing each embedded expression with a parser of
+// its own. An expression with errors becomes a BadExpr; the rest of the
+// string is fine, so parsing carries on after it.
+func (p *Parser) interpolatedString(tok lexer.Token) {
+	var trees [][]cst.Green
+	for _, part := range tok.Literal.Parts {
+		if !part.IsExpr() {
+			continue
+		}
+		sub := NewParser(part.Tokens)
+		err := sub.expression()
+		if err == nil && !sub.isAtEnd() {
+			err = sub.errorAt(sub.peek(), "Unexpected token in string interpolation")
+		}
+		p.errors = append(p.errors, sub.errors...)
+		sub.addTokens(len(sub.tokens) - 1)
+		if err != nil {
+			p.report(err)
+			sub.tree.Finish(0, cst.BadExpr)
+		}
+		trees = append(trees, sub.tree.Elements())
+	}
+	p.addString(tok, trees)
+}
@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"dotFun/internal/ast"
+)
+
+// productions has at least one example of every production in
+// docs/grammar.ebnf, with the node its first statement parses to; for an
+// expression statement that is the node of the expression.
+var productions = []struct {
+	production, src, want string
+}{
+	{"program", "let a = 1\nlet b = 2\n", "*ast.LetStmt"},
+	{"terminator", "f(); g()\nh()", "*ast.CallExpr"},
+	{"declaration", "global g: Int = 1", "*ast.GlobalStmt"},
+	{"declaration", `import "math"`, "*ast.ImportStmt"},
+	{"declaration", "export f", "*ast.ExportStmt"},
+	{"access", "private let a = 1", "*ast.LetStmt"},
+	{"modified", "async fun f() {}", "*ast.FunctionStmt"},
+	{"function", "fun f(a, b: Int): Int { return a + b }", "*ast.FunctionStmt"},
+	{"parameters", "fun f() {}", "*ast.FunctionStmt"},
+	{"parameter", "fun f(a: []Int) {}", "*ast.FunctionStmt"},
+	{"variable", "val a: Int = 1", "*ast.ValStmt"},
+	{"class", "class B extends A {}", "*ast.ClassStmt"},
+	{"classMember", `class C {
+  constructor(a) { this.a = a }
+  private override async fun f() {}
+  protected struct S { let x: Int }
+}`, "*ast.ClassStmt"},
+	{"interface", "interface I {\n  public async fun f(): Int\n  fun g() {}\n}", "*ast.InterfaceStmt"},
+	{"method", "interface I { fun f(a); fun g() { return 1 } }", "*ast.InterfaceStmt"},
+	{"struct", "struct S { private let x: Int }", "*ast.StructStmt"},
+	{"enum", "enum E { A, B, }", "*ast.EnumStmt"},
+	{"data", "data D(a: Int, b)", "*ast.DataStmt"},
+	{"type", "let a: [][]String", "*ast.LetStmt"},
+	{"statement", "if a { f() }", "*ast.IfStmt"},
+	{"statement", "while a { break; continue }", "*ast.WhileStmt"},
+	{"statement", "fun f() { return }", "*ast.FunctionStmt"},
+	{"statement", "throw e", "*ast.ThrowStmt"},
+	{"statement", "try { f() } finally { g() }", "*ast.TryStmt"},
+	{"statement", "a + 1", "*ast.BinaryExpr"},
+	{"elseClause", "if a {} elif b {} else if c {} else {}", "*ast.IfStmt"},
+	{"forClauses", "for (let i = 0; i < 3; i++) {}", "*ast.ForStmt"},
+	{"forClauses", "for ; ; { break }", "*ast.ForStmt"},
+	{"forInit", "for i = 0; i < 3; i++ {}", "*ast.ForStmt"},
+	{"catchClause", "try {} catch (e) {} finally {}", "*ast.TryStmt"},
+	{"catchClause", "try {} catch e {}", "*ast.TryStmt"},
+	{"case", "turn x {\n  case 1, 2 { f() }\n  default { g() }\n}", "*ast.SwitchStmt"},
+	{"block", "{ let a = 1 }", "*ast.BlockStmt"},
+	{"expression", "a", "*ast.VariableExpr"},
+	{"lambda", "(a, b) -> a + b", "*ast.LambdaExpr"},
+	{"lambda", "a => a", "*ast.LambdaExpr"},
+	{"assignment", "a = 1", "*ast.AssignExpr"},
+	{"assignment", "this.x = x", "*ast.MemberAssignExpr"},
+	{"assignOp", "a.b <<= 1", "*ast.MemberAssignExpr"},
+	{"nilCoalescing", "a ?? b", "*ast.LogicalExpr"},
+	{"or", "a or b || c", "*ast.LogicalExpr"},
+	{"and", "a and b && c", "*ast.LogicalExpr"},
+	{"equality", "a != b", "*ast.BinaryExpr"},
+	{"comparison", "a <= b", "*ast.BinaryExpr"},
+	{"comparison", "a instanceof []Int", "*ast.InstanceOfExpr"},
+	{"range", "a..<b", "*ast.RangeExpr"},
+	{"bitOr", "a | b", "*ast.BinaryExpr"},
+	{"bitXor", "a ^ b", "*ast.BinaryExpr"},
+	{"bitAnd", "a & b", "*ast.BinaryExpr"},
+	{"shift", "a >> b", "*ast.BinaryExpr"},
+	{"term", "a - b", "*ast.BinaryExpr"},
+	{"factor", "a % b", "*ast.BinaryExpr"},
+	{"unary", "not a", "*ast.UnaryExpr"},
+	{"power", "a ** -b", "*ast.BinaryExpr"},
+	{"postfix", "a--", "*ast.PostfixUnaryExpr"},
+	{"call", "a.b?.c(d)", "*ast.CallExpr"},
+	{"arguments", "f(a, b => b, c)", "*ast.CallExpr"},
+	{"primary", "1.5", "*ast.FloatLiteral"},
+	{"primary", `"s $x"`, "*ast.InterpolatedString"},
+	{"primary", "'c'", "*ast.CharLiteral"},
+	{"primary", "nil", "*ast.NilLiteral"},
+	{"primary", "super.f", "*ast.SuperExpr"},
+	{"primary", "new C(1)", "*ast.NewExpr"},
+	{"primary", "(a)", "*ast.GroupingExpr"},
+	{"primary", "[1, 2,]", "*ast.ArrayLiteral"},
+}
+
+// TestGrammar parses the examples in productions and checks that they
+// cover docs/grammar.ebnf.
+func TestGrammar(t *testing.T) {
+	for _, tt := range productions {
+		statements, errs := parse(tt.src)
+		if len(errs) > 0 {
+			t.Errorf("%s: %q: %v", tt.production, tt.src, errs)
+			continue
+		}
+		var got ast.Node = statements[0]
+		if stmt, ok := got.(*ast.ExpressionStmt); ok {
+			got = stmt.Expression
+		}
+		if fmt.Sprintf("%T", got) != tt.want {
+			t.Errorf("%s: %q parsed as %T, want %s", tt.production, tt.src, got, tt.want)
+		}
+	}
+
+	data, err := os.ReadFile("../../docs/grammar.ebnf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	covered := map[string]bool{}
+	for _, tt := range productions {
+		covered[tt.production] = true
+	}
+	defined := map[string]bool{}
+	for _, m := range regexp.MustCompile(`(?m)^(\w+)\s+=`).FindAllStringSubmatch(string(data), -1) {
+		defined[m[1]] = true
+		if !covered[m[1]] {
+			t.Errorf("no example of production %s", m[1])
+		}
+	}
+	for production := range covered {
+		if !defined[production] {
+			t.Errorf("production %s is not in docs/grammar.ebnf", production)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"dotFun/internal/ast"
+	"dotFun/internal/lexer"
+)
+
+// Reparse parses newTokens, reusing the top-level statements of old, which
+// were parsed from oldTokens, wherever the tokens around them are
+// unchanged. The result is the same as NewParser(newTokens).Parse().
+func Reparse(old []ast.Stmt, oldTokens, newTokens []lexer.Token) ([]ast.Stmt, error) {
+	// The errors of a statement are not kept with it, so one that had any
+	// cannot be reused.
+	if len(oldTokens) == 0 || len(newTokens) == 0 || hasBadNodes(old) {
+		return NewParser(newTokens).Parse()
+	}
+
+	// starts[i] is the index in oldTokens of the first token of old[i].
+	starts := make([]int, len(old))
+	byStart := make(map[int]int, len(old))
+	k := 0
+	for i, stmt := range old {
+		for k < len(oldTokens) && oldTokens[k].Span.StartOffset < stmt.SourceSpan().StartOffset {
+			k++
+		}
+		starts[i] = k
+		byStart[k] = i
+	}
+
+	prefix := 0
+	for prefix < len(oldTokens) && prefix < len(newTokens) && sameToken(oldTokens[prefix], newTokens[prefix], 0, 0) {
+		prefix++
+	}
+	oldEOF, newEOF := oldTokens[len(oldTokens)-1], newTokens[len(newTokens)-1]
+	offset := newEOF.Span.EndOffset - oldEOF.Span.EndOffset
+	lines := newEOF.Span.EndLine - oldEOF.Span.EndLine
+	shift := len(newTokens) - len(oldTokens)
+	suffix := 0
+	for suffix < len(oldTokens)-prefix && suffix < len(newTokens)-prefix {
+		i := len(oldTokens) - 1 - suffix
+		if !sameToken(oldTokens[i], newTokens[i+shift], offset, lines) {
+			break
+		}
+		suffix++
+	}
+
+	// A statement is reused when every token it was parsed from, including
+	// the lookahead up to the first token of the next statement, is
+	// unchanged.
+	reused := 0
+	for reused+1 < len(old) && starts[reused+1] < prefix {
+		reused++
+	}
+	statements := append([]ast.Stmt{}, old[:reused]...)
+
+	p := NewParser(newTokens)
+	if reused < len(old) {
+		p.current = starts[reused]
+	}
+	p.emitted = p.current
+	from := p.current
+	for p.skipNewlines(); !p.isAtEnd(); p.skipNewlines() {
+		if p.current >= len(newTokens)-suffix {
+			if i, ok := byStart[p.current-shift]; ok && i >= reused {
+				statements = append(statements, p.statements(from)...)
+				for _, stmt := range old[i:] {
+					statements = append(statements, ast.Shift(stmt, offset, lines))
+				}
+				return statements, p.errors.Err()
+			}
+		}
+		p.recoverStmt(p.declaration)
+	}
+	return append(statements, p.statements(from)...), p.errors.Err()
+}
+
+func hasBadNodes(stmts []ast.Stmt) bool {
+	bad := false
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch n.(type) {
+			case *ast.BadStmt, *ast.BadExpr:
+				bad = true
+			}
+			return !bad
+		})
+	}
+	return bad
+}
+
+// sameToken reports whether b is a moved by offset bytes and lines lines.
+func sameToken(a, b lexer.Token, offset, lines int) bool {
+	return a.Type == b.Type && a.Lexeme == b.Lexeme && a.Doc == b.Doc && a.Span.Shift(offset, lines) == b.Span
+}
@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+
+	"dotFun/internal/lexer"
+)
+
+// corpus returns testdata/corpus.fun, which uses every kind of statement
+// and expression.
+func corpus(t *testing.T) string {
+	data, err := os.ReadFile("testdata/corpus.fun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// TestReparse checks that reparsing after random edits, which leave some
+// statements alone and break others, gives the same statements and
+// errors as parsing the edited source from scratch.
+func TestReparse(t *testing.T) {
+	src := corpus(t)
+	oldTokens, _ := lexer.NewLexer(src).Lex()
+	old, _ := NewParser(oldTokens).Parse()
+
+	texts := []string{"", "x", " ", "\n", "1", "+", "(", ")", "{", "}", "\"", "q = 3\n", "let w = 2\n", "fun h() {}\n"}
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 3000; i++ {
+		start := rng.Intn(len(src) + 1)
+		end := min(start+rng.Intn(3), len(src))
+		edit := lexer.Edit{Start: start, End: end, Text: texts[rng.Intn(len(texts))]}
+
+		tokens, _ := lexer.NewLexer(edit.Apply(src)).Lex()
+		got, gotErr := Reparse(old, oldTokens, tokens)
+		want, wantErr := NewParser(tokens).Parse()
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("edit %+v: statements differ from a full parse", edit)
+		}
+		if !reflect.DeepEqual(gotErr, wantErr) {
+			t.Fatalf("edit %+v: errors %v, want %v", edit, gotErr, wantErr)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"dotFun/internal/ast"
+)
+
+// contextualWords are the words that were once reserved and are now
+// identifiers except where the grammar gives them a meaning.
+var contextualWords = []string{"constructor", "data", "default", "global", "turn", "in", "is", "of"}
+
+// TestContextualKeywordsAsNames uses each contextual keyword as a
+// variable, parameter, field, method and member name.
+func TestContextualKeywordsAsNames(t *testing.T) {
+	uses := []string{
+		"let W = 1\nW = W + 1",
+		"val W: Int = 1",
+		"fun f(W) { return W }",
+		"fun f(a, W: Int) { return a + W }",
+		"let g = W => W * 2",
+		"let h = (a, W) -> a + W",
+		"class C {\n  let W = 1\n  private val x = W\n}",
+		"struct S { let W: Int }",
+		"interface I { fun W(): Int }",
+		"class C {\n  fun W(a) { return this.W }\n}",
+		"enum E { W, X }",
+		"data D(W: Int)",
+		"println(o.W, o?.W, o.W(1), W.x)",
+		"for let W = 0; W < 3; W++ { println(W) }",
+		"try { f() } catch (W) { println(W) }",
+	}
+	for _, word := range contextualWords {
+		for _, use := range uses {
+			src := strings.ReplaceAll(use, "W", word)
+			if _, errs := parse(src); len(errs) > 0 {
+				t.Errorf("%q: %v", src, errs)
+			}
+		}
+	}
+}
+
+// TestContextualKeywordsInPosition checks that the contextual keywords
+// still act as keywords where the grammar uses them.
+func TestContextualKeywordsInPosition(t *testing.T) {
+	statements, errs := parse(`global counter = 0
+data Pair(a: Int, b: Int)
+turn x {
+  case 1 { f() }
+  default { g() }
+}
+class C {
+  constructor(a) { f(a) }
+}
+`)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if _, ok := statements[0].(*ast.GlobalStmt); !ok {
+		t.Errorf("global declaration parsed as %T", statements[0])
+	}
+	if _, ok := statements[1].(*ast.DataStmt); !ok {
+		t.Errorf("data declaration parsed as %T", statements[1])
+	}
+	if turn, ok := statements[2].(*ast.SwitchStmt); !ok || turn.Default == nil {
+		t.Errorf("turn statement parsed as %T without its default clause", statements[2])
+	}
+	if class, ok := statements[3].(*ast.ClassStmt); !ok {
+		t.Errorf("class parsed as %T", statements[3])
+	} else if _, ok := class.Members[0].(*ast.ConstructorStmt); !ok {
+		t.Errorf("constructor parsed as %T", class.Members[0])
+	}
+}
@@ -0,0 +1,480 @@
+package parser
+
+import (
+	"dotFun/internal/ast"
+	"dotFun/internal/cst"
+	"dotFun/internal/lexer"
+)
+
+// A lowerer derives ast nodes from a green tree. It walks the tree in
+// source order, pairing each token of it with the next lexer token, which
+// supplies the span, literal value and doc comment the tree does not
+// keep. Each node derived is linked to its green node in tree.
+type lowerer struct {
+	tree   *cst.Builder
+	tokens []lexer.Token
+	next   int
+}
+
+// element is a child of a green node: a token, or a node, whose kind is
+// set, with the ast node derived from it.
+type element struct {
+	tok  lexer.Token
+	kind cst.Kind
+	node ast.Node
+}
+
+// statements derives the statements from the top-level nodes of the
+// tree, the first token of which is p.tokens[from].
+func (p *Parser) statements(from int) []ast.Stmt {
+	l := &lowerer{tree: p.tree, tokens: p.tokens, next: from}
+	statements := []ast.Stmt{}
+	for _, g := range p.tree.Elements() {
+		if n, ok := g.(*cst.GreenNode); ok {
+			statements = append(statements, l.node(n).(ast.Stmt))
+		} else {
+			l.next++
+		}
+	}
+	return statements
+}
+
+// expr derives the expression that is the only node of the tree.
+func (p *Parser) expr() ast.Expr {
+	l := &lowerer{tree: p.tree, tokens: p.tokens}
+	return l.node(p.tree.Elements()[0].(*cst.GreenNode)).(ast.Expr)
+}
+
+// node derives the ast node of g and links g to it.
+func (l *lowerer) node(g *cst.GreenNode) ast.Node {
+	start := l.next
+	var n ast.Node
+	switch g.Kind {
+	case cst.InterpolatedString:
+		n = l.interpolatedString(g)
+	case cst.BadExpr:
+		// What a bad node holds is not part of the ast.
+		l.skip(g)
+		n = &ast.BadExpr{Span: l.span(start)}
+	case cst.BadStmt:
+		l.skip(g)
+		n = &ast.BadStmt{Span: l.span(start)}
+	default:
+		el := l.children(g)
+		n = l.derive(g.Kind, l.span(start), el)
+	}
+	l.tree.Link(g, n)
+	return n
+}
+
+func (l *lowerer) children(g *cst.GreenNode) []element {
+	el := make([]element, len(g.Children))
+	for i, c := range g.Children {
+		switch c := c.(type) {
+		case *cst.GreenToken:
+			el[i].tok = l.tokens[l.next]
+			l.next++
+		case *cst.GreenNode:
+			el[i].kind = c.Kind
+			el[i].node = l.node(c)
+		}
+	}
+	return el
+}
+
+// skip moves past the tokens of g without deriving anything from them.
+func (l *lowerer) skip(g *cst.GreenNode) {
+	for _, c := range g.Children {
+		switch c := c.(type) {
+		case *cst.GreenToken:
+			l.next++
+		case *cst.GreenNode:
+			if c.Kind == cst.InterpolatedString {
+				l.next++
+			} else {
+				l.skip(c)
+			}
+		}
+	}
+}
+
+// span returns the span of the tokens from start to the last one taken,
+// leaving out a newline that ended a statement. A node without tokens
+// gets an empty span where the next token starts.
+func (l *lowerer) span(start int) ast.Span {
+	if l.next == start {
+		return emptySpan(l.tokens[min(start, len(l.tokens)-1)].Span)
+	}
+	last := l.next - 1
+	if last > start && l.tokens[last].Type == lexer.NEWLINE {
+		last--
+	}
+	return l.tokens[start].Span.To(l.tokens[last].Span)
+}
+
+// interpolatedString derives an interpolated string from its single
+// lexer token. The nodes of g are the interpolated expressions, which are
+// paired with the tokens lexed for each of them.
+func (l *lowerer) interpolatedString(g *cst.GreenNode) ast.Node {
+	tok := l.tokens[l.next]
+	l.next++
+	var exprs []*cst.GreenNode
+	for _, c := range g.Children {
+		if n, ok := c.(*cst.GreenNode); ok {
+			exprs = append(exprs, n)
+		}
+	}
+
+	str := &ast.InterpolatedString{Span: tok.Span}
+	for _, part := range tok.Literal.Parts {
+		if !part.IsExpr() {
+			str.Parts = append(str.Parts, &ast.StringLiteral{Span: part.Span, Value: part.Text})
+			continue
+		}
+		n := exprs[0]
+		exprs = exprs[1:]
+		// A BadExpr other than a lone ILLEGAL token stands for a part
+		// that did not parse, and covers all of it.
+		if n.Kind == cst.BadExpr && !isIllegal(n) {
+			bad := &ast.BadExpr{Span: part.Span}
+			l.tree.Link(n, bad)
+			str.Parts = append(str.Parts, bad)
+			continue
+		}
+		sub := &lowerer{tree: l.tree, tokens: part.Tokens}
+		str.Parts = append(str.Parts, sub.node(n).(ast.Expr))
+	}
+	return str
+}
+
+func isIllegal(g *cst.GreenNode) bool {
+	if len(g.Children) != 1 {
+		return false
+	}
+	tok, ok := g.Children[0].(*cst.GreenToken)
+	return ok && tok.Type == lexer.ILLEGAL
+}
+
+// derive makes the ast node of the given kind from its span and children.
+func (l *lowerer) derive(kind cst.Kind, span ast.Span, el []element) ast.Node {
+	var nodes []ast.Node
+	var toks []lexer.Token
+	for _, e := range el {
+		if e.kind != "" {
+			nodes = append(nodes, e.node)
+		} else {
+			toks = append(toks, e.tok)
+		}
+	}
+
+	switch kind {
+	case cst.IntLiteral:
+		return &ast.IntLiteral{Span: span, Value: toks[0].Literal.Int}
+	case cst.FloatLiteral:
+		return &ast.FloatLiteral{Span: span, Value: toks[0].Literal.Float}
+	case cst.StringLiteral:
+		return &ast.StringLiteral{Span: span, Value: toks[0].Literal.Text}
+	case cst.CharLiteral:
+		return &ast.CharLiteral{Span: span, Value: rune(toks[0].Literal.Int)}
+	case cst.BoolLiteral:
+		return &ast.BoolLiteral{Span: span, Value: toks[0].Type == lexer.TRUE}
+	case cst.NilLiteral:
+		return &ast.NilLiteral{Span: span}
+	case cst.ThisExpr:
+		return &ast.ThisExpr{Span: span}
+	case cst.VariableExpr:
+		return &ast.VariableExpr{Span: span, Name: identifier(toks[0])}
+	case cst.SuperExpr:
+		return &ast.SuperExpr{Span: span, Method: identifier(toks[2])}
+	case cst.NewExpr:
+		return &ast.NewExpr{Span: span, ClassName: toks[1].Lexeme, Args: exprs(nodes)}
+	case cst.ArrayLiteral:
+		return &ast.ArrayLiteral{Span: span, Elements: exprs(nodes)}
+	case cst.GroupingExpr:
+		return &ast.GroupingExpr{Span: span, Expression: nodes[0].(ast.Expr)}
+	case cst.LambdaExpr:
+		params := []string{}
+		for _, tok := range toks {
+			if tok.Type == lexer.IDENTIFIER {
+				params = append(params, tok.Lexeme)
+			}
+		}
+		return &ast.LambdaExpr{Span: span, Params: params, Body: nodes[0].(ast.Expr)}
+	case cst.UnaryExpr:
+		return &ast.UnaryExpr{Span: span, Operator: toks[0].Lexeme, Right: nodes[0].(ast.Expr)}
+	case cst.PostfixUnaryExpr:
+		return &ast.PostfixUnaryExpr{Span: span, Operand: nodes[0].(ast.Expr), Operator: toks[0].Lexeme}
+	case cst.BinaryExpr:
+		return &ast.BinaryExpr{Span: span, Left: nodes[0].(ast.Expr), Operator: toks[0].Lexeme, Right: nodes[1].(ast.Expr)}
+	case cst.LogicalExpr:
+		return &ast.LogicalExpr{Span: span, Left: nodes[0].(ast.Expr), Operator: toks[0].Lexeme, Right: nodes[1].(ast.Expr)}
+	case cst.RangeExpr:
+		return &ast.RangeExpr{Span: span, Start: nodes[0].(ast.Expr), Stop: nodes[1].(ast.Expr), Inclusive: toks[0].Type == lexer.DOT_DOT}
+	case cst.AssignExpr:
+		target := nodes[0].(*ast.VariableExpr)
+		return &ast.AssignExpr{Span: span, Name: target.Name, Operator: toks[0].Lexeme, Value: nodes[1].(ast.Expr)}
+	case cst.MemberAssignExpr:
+		target := nodes[0].(*ast.MemberExpr)
+		return &ast.MemberAssignExpr{Span: span, Object: target.Object, Property: target.Property,
+			Operator: toks[0].Lexeme, Value: nodes[1].(ast.Expr)}
+	case cst.CallExpr:
+		return &ast.CallExpr{Span: span, Callee: nodes[0].(ast.Expr), Arguments: exprs(nodes[1:])}
+	case cst.MemberExpr:
+		return &ast.MemberExpr{Span: span, Object: nodes[0].(ast.Expr), Property: identifier(toks[1]),
+			Optional: toks[0].Type == lexer.QUESTION_DOT}
+	case cst.InstanceOfExpr:
+		return &ast.InstanceOfExpr{Span: span, Object: nodes[0].(ast.Expr), Type: typeOf(toks[1:])}
+
+	case cst.BlockStmt:
+		return &ast.BlockStmt{Span: span, Statements: stmts(nodes)}
+	case cst.BreakStmt:
+		return &ast.BreakStmt{Span: span}
+	case cst.ContinueStmt:
+		return &ast.ContinueStmt{Span: span}
+	case cst.ExpressionStmt:
+		return &ast.ExpressionStmt{Span: span, Expression: nodes[0].(ast.Expr)}
+	case cst.ReturnStmt:
+		stmt := &ast.ReturnStmt{Span: span}
+		if len(nodes) > 0 {
+			stmt.Value = nodes[0].(ast.Expr)
+		}
+		return stmt
+	case cst.ThrowStmt:
+		return &ast.ThrowStmt{Span: span, Value: nodes[0].(ast.Expr)}
+	case cst.IfStmt:
+		stmt := &ast.IfStmt{Span: span, Condition: nodes[0].(ast.Expr), ThenBlock: nodes[1].(*ast.BlockStmt)}
+		if len(nodes) > 2 {
+			stmt.ElseBlock = nodes[2].(ast.Stmt)
+		}
+		return stmt
+	case cst.WhileStmt:
+		return &ast.WhileStmt{Span: span, Condition: nodes[0].(ast.Expr), Body: nodes[1].(*ast.BlockStmt)}
+	case cst.ForStmt:
+		return forStmt(span, el)
+	case cst.TryStmt:
+		return tryStmt(span, el)
+	case cst.SwitchStmt:
+		stmt := &ast.SwitchStmt{Span: span, Expr: nodes[0].(ast.Expr), Cases: []*ast.SwitchCase{}}
+		for _, n := range nodes[1:] {
+			switch n := n.(type) {
+			case *ast.SwitchCase:
+				stmt.Cases = append(stmt.Cases, n)
+			case *ast.BlockStmt:
+				stmt.Default = n
+			}
+		}
+		return stmt
+	case cst.SwitchCase:
+		c := &ast.SwitchCase{Span: span}
+		for _, n := range nodes {
+			switch n := n.(type) {
+			case *ast.BlockStmt:
+				c.Body = n
+			case ast.Expr:
+				c.CaseExprs = append(c.CaseExprs, n)
+			}
+		}
+		if c.Body == nil {
+			// A case that did not parse, which holds just a BadExpr.
+			c.Body = &ast.BlockStmt{Span: emptySpan(l.tokens[l.next].Span), Statements: []ast.Stmt{}}
+		}
+		return c
+	}
+	return declaration(kind, span, toks, nodes)
+}
+
+// declaration derives the declarations, which start with their
+// modifiers, keyword and name.
+func declaration(kind cst.Kind, span ast.Span, toks []lexer.Token, nodes []ast.Node) ast.Node {
+	doc := toks[0].Doc
+	access := ast.ModifierNone
+	i := 0
+	if t := toks[i].Type; t == lexer.PUBLIC || t == lexer.PROTECTED || t == lexer.PRIVATE {
+		access = ast.ModifierFromString(toks[i].Lexeme)
+		i++
+	}
+	override := toks[i].Type == lexer.OVERRIDE
+	if override {
+		i++
+	}
+	async := toks[i].Type == lexer.ASYNC
+	if async {
+		i++
+	}
+	keyword, rest := toks[i], toks[i+1:]
+
+	switch kind {
+	case cst.LetStmt, cst.ValStmt, cst.GlobalStmt:
+		var declaredType ast.Type
+		if j := index(rest, lexer.COLON); j >= 0 {
+			declaredType = typeOf(rest[j+1:])
+		}
+		var initializer ast.Expr
+		if len(nodes) > 0 {
+			initializer = nodes[0].(ast.Expr)
+		}
+		switch kind {
+		case cst.ValStmt:
+			return &ast.ValStmt{Span: span, Name: rest[0].Lexeme, DeclaredType: declaredType, Initializer: initializer, Modifiers: access}
+		case cst.GlobalStmt:
+			return &ast.GlobalStmt{Span: span, Name: rest[0].Lexeme, DeclaredType: declaredType, Initializer: initializer}
+		}
+		return &ast.LetStmt{Span: span, Name: rest[0].Lexeme, DeclaredType: declaredType, Initializer: initializer, Modifiers: access}
+	case cst.FunctionStmt:
+		stmt := &ast.FunctionStmt{
+			Span:       span,
+			Doc:        doc,
+			Name:       rest[0].Lexeme,
+			Parameters: parameters(nodes),
+			Modifiers:  access,
+			Async:      async,
+			Override:   override,
+		}
+		if j := index(rest, lexer.COLON); j >= 0 {
+			stmt.ReturnType = typeOf(rest[j+1:])
+		}
+		if n := len(nodes); n > 0 {
+			stmt.Body, _ = nodes[n-1].(*ast.BlockStmt)
+		}
+		return stmt
+	case cst.Parameter:
+		param := &ast.Parameter{Span: span, Name: keyword.Lexeme}
+		if len(rest) > 0 {
+			param.Type = typeOf(rest[1:])
+		}
+		return param
+	case cst.ClassStmt:
+		stmt := &ast.ClassStmt{Span: span, Doc: doc, Name: rest[0].Lexeme, Modifiers: access, Members: stmts(nodes)}
+		if j := index(rest, lexer.EXTENDS); j >= 0 {
+			stmt.SuperClass = rest[j+1].Lexeme
+		}
+		return stmt
+	case cst.ConstructorStmt:
+		return &ast.ConstructorStmt{Span: span, Parameters: parameters(nodes), Body: nodes[len(nodes)-1].(*ast.BlockStmt)}
+	case cst.InterfaceStmt:
+		return &ast.InterfaceStmt{Span: span, Doc: doc, Name: rest[0].Lexeme, Modifiers: access, Members: stmts(nodes)}
+	case cst.StructStmt:
+		return &ast.StructStmt{Span: span, Name: rest[0].Lexeme, Modifiers: access, Members: stmts(nodes)}
+	case cst.EnumStmt:
+		elements := []string{}
+		for _, tok := range rest[1:] {
+			if tok.Type == lexer.IDENTIFIER {
+				elements = append(elements, tok.Lexeme)
+			}
+		}
+		return &ast.EnumStmt{Span: span, Doc: doc, Name: rest[0].Lexeme, Modifiers: access, Elements: elements}
+	case cst.DataStmt:
+		return &ast.DataStmt{Span: span, Doc: doc, Name: rest[0].Lexeme, Modifiers: access, Fields: parameters(nodes)}
+	case cst.ImportStmt:
+		return &ast.ImportStmt{Span: span, Module: rest[0].Literal.Text}
+	case cst.ExportStmt:
+		return &ast.ExportStmt{Span: span, ExportedName: rest[0].Lexeme}
+	}
+	panic("parser: cannot derive an ast node of kind " + string(kind))
+}
+
+// forStmt derives a for statement, whose clauses are told apart by the
+// ';' tokens between them. The initializer ends with its own ';'.
+func forStmt(span ast.Span, el []element) *ast.ForStmt {
+	stmt := &ast.ForStmt{Span: span}
+	clause := 0
+	for _, e := range el {
+		switch {
+		case e.kind == "":
+			if e.tok.Type == lexer.SEMICOLON {
+				clause++
+			}
+		case e.kind == cst.BlockStmt:
+			stmt.Body = e.node.(*ast.BlockStmt)
+		case clause == 0:
+			stmt.Init = e.node.(ast.Stmt)
+			clause++
+		case clause == 1:
+			stmt.Condition = e.node.(ast.Expr)
+		default:
+			stmt.Post = e.node.(ast.Stmt)
+		}
+	}
+	return stmt
+}
+
+// tryStmt derives a try statement, giving each block to the keyword
+// before it.
+func tryStmt(span ast.Span, el []element) *ast.TryStmt {
+	stmt := &ast.TryStmt{Span: span}
+	var keyword lexer.TokenType
+	for _, e := range el {
+		if e.kind == "" {
+			switch e.tok.Type {
+			case lexer.TRY, lexer.CATCH, lexer.FINALLY:
+				keyword = e.tok.Type
+			case lexer.IDENTIFIER:
+				stmt.CatchVarName = e.tok.Lexeme
+			}
+			continue
+		}
+		block := e.node.(*ast.BlockStmt)
+		switch keyword {
+		case lexer.TRY:
+			stmt.TryBlock = block
+		case lexer.CATCH:
+			stmt.CatchBlock = block
+		default:
+			stmt.FinallyBlock = block
+		}
+	}
+	return stmt
+}
+
+// typeOf derives the type written at the start of toks.
+func typeOf(toks []lexer.Token) ast.Type {
+	if toks[0].Type == lexer.LEFT_BRACKET {
+		return &ast.ArrayType{ElementType: typeOf(toks[2:])}
+	}
+	switch t := ast.PrimitiveType(toks[0].Lexeme); t {
+	case ast.IntType, ast.StringType, ast.CharType, ast.FloatType, ast.BoolType, ast.AnyType:
+		return t
+	}
+	return &ast.NamedType{Name: toks[0].Lexeme}
+}
+
+func identifier(tok lexer.Token) *ast.Identifier {
+	return &ast.Identifier{Span: tok.Span, Name: tok.Lexeme}
+}
+
+// index returns the index of the first token of type t in toks, or -1.
+func index(toks []lexer.Token, t lexer.TokenType) int {
+	for i, tok := range toks {
+		if tok.Type == t {
+			return i
+		}
+	}
+	return -1
+}
+
+func exprs(nodes []ast.Node) []ast.Expr {
+	list := make([]ast.Expr, len(nodes))
+	for i, n := range nodes {
+		list[i] = n.(ast.Expr)
+	}
+	return list
+}
+
+func stmts(nodes []ast.Node) []ast.Stmt {
+	list := make([]ast.Stmt, len(nodes))
+	for i, n := range nodes {
+		list[i] = n.(ast.Stmt)
+	}
+	return list
+}
+
+// parameters returns the parameters among nodes.
+func parameters(nodes []ast.Node) []ast.Parameter {
+	params := []ast.Parameter{}
+	for _, n := range nodes {
+		if param, ok := n.(*ast.Parameter); ok {
+			params = append(params, *param)
+		}
+	}
+	return params
+}
@@ -1 +1,114 @@
 package parser
+
+import (
+	"fmt"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/cst"
+	"dotFun/internal/lexer"
+)
+
+type Parser struct {
+	tokens  []lexer.Token
+	current int
+	// depth counts the braced bodies being parsed around current.
+	depth  int
+	errors ErrorList
+	// tree receives the concrete syntax tree, from which the ast is
+	// derived; the tokens before emitted have been added to it.
+	tree    *cst.Builder
+	emitted int
+}
+
+func NewParser(tokens []lexer.Token) *Parser {
+	return &Parser{
+		tokens: tokens,
+		tree:   &cst.Builder{},
+	}
+}
+
+// Parse parses the whole token stream. It keeps going after syntax errors,
+// so the statements are returned even when the error, an ErrorList, is
+// not nil; the parts that could not be parsed are BadStmt and BadExpr
+// nodes.
+func (p *Parser) Parse() ([]ast.Stmt, error) {
+	_, statements, err := p.ParseTree()
+	return statements, err
+}
+
+// Errors returns the syntax errors found so far.
+func (p *Parser) Errors() ErrorList {
+	return p.errors
+}
+
+func (p *Parser) match(types ...lexer.TokenType) bool {
+	for _, t := range types {
+		if p.check(t) {
+			p.advance()
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) consume(t lexer.TokenType, message string) (lexer.Token, error) {
+	if p.check(t) {
+		return p.advance(), nil
+	}
+	return lexer.Token{}, p.errorAt(p.peek(), message)
+}
+
+func (p *Parser) check(t lexer.TokenType) bool {
+	return p.peek().Type == t
+}
+
+func (p *Parser) checkNext(t lexer.TokenType) bool {
+	return p.peekAt(1).Type == t
+}
+
+// checkContextual reports whether the current token is the contextual
+// keyword word, which the lexer leaves as an identifier.
+func (p *Parser) checkContextual(word string) bool {
+	tok := p.peek()
+	return tok.Type == lexer.IDENTIFIER && tok.Lexeme == word
+}
+
+func (p *Parser) advance() lexer.Token {
+	if !p.isAtEnd() {
+		p.current++
+	}
+	return p.previous()
+}
+
+func (p *Parser) isAtEnd() bool {
+	return p.peek().Type == lexer.EOF_TOKEN
+}
+
+func (p *Parser) peek() lexer.Token {
+	return p.peekAt(0)
+}
+
+func (p *Parser) peekAt(offset int) lexer.Token {
+	i := p.current + offset
+	if i >= len(p.tokens) {
+		if len(p.tokens) == 0 {
+			return lexer.Token{Type: lexer.EOF_TOKEN, Line: 1, Column: 1}
+		}
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[i]
+}
+
+func (p *Parser) previous() lexer.Token {
+	if p.current == 0 {
+		return p.peek()
+	}
+	return p.tokens[p.current-1]
+}
+
+func (p *Parser) errorAt(tok lexer.Token, format string, args ...any) error {
+	return &ParseError{
+		Token:   tok,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
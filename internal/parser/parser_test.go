@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/lexer"
+)
+
+func parse(src string) ([]ast.Stmt, ErrorList) {
+	tokens, _ := lexer.NewLexer(src).Lex()
+	p := NewParser(tokens)
+	statements, _ := p.Parse()
+	return statements, p.Errors()
+}
+
+func errorLines(errs ErrorList) []int {
+	lines := []int{}
+	for _, err := range errs {
+		lines = append(lines, err.Token.Line)
+	}
+	return lines
+}
+
+// Lexical errors are reported by the lexer alone, and do not make the
+// parser report the line after them.
+func TestNoErrorsAfterIllegal(t *testing.T) {
+	src := `let z = "unterminated
+let a = 0b12
+let b = ''
+let c = 12px
+let d = 1 @
+let e = 1
+let f = 2
+`
+	if _, errs := parse(src); len(errs) > 0 {
+		t.Errorf("unexpected parse errors: %v", errs)
+	}
+}
+
+// An unclosed '(' or '[' is one error, and the statements after it parse.
+func TestUnclosedBracket(t *testing.T) {
+	src := `fun f(a) {
+  print(a
+  let x = [1, 2
+  return x
+}
+let y = foo(1,
+  2)
+print(y
+let w = 3
+if (w > 1) { print(w) }
+`
+	statements, errs := parse(src)
+	if got, want := errorLines(errs), []int{2, 3, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("errors on lines %v, want %v: %v", got, want, errs)
+	}
+	if len(statements) != 5 {
+		t.Fatalf("got %d statements, want 5", len(statements))
+	}
+	if _, ok := statements[3].(*ast.LetStmt); !ok {
+		t.Errorf("statement 3 is %T, want *ast.LetStmt", statements[3])
+	}
+}
+
+// The body of a statement skipped after an error is parsed for the errors
+// in it, as a block, as members or as turn cases.
+func TestErrorsInSkippedBody(t *testing.T) {
+	tests := []struct {
+		src    string
+		errors []string
+	}{
+		{"fun f( {\n  let = 3\n}", []string{
+			"1:8: Expected parameter name at '{'",
+			"2:7: Expected variable name at '='",
+		}},
+		{"if a b {\n  let = 1\n  if c { val }\n}", []string{
+			"1:6: Expected '{' at 'b'",
+			"2:7: Expected variable name at '='",
+			"3:14: Expected variable name at '}'",
+		}},
+		{"class C extends {\n  fun m() { return ) }\n  constructor(a) { val = 1 }\n}", []string{
+			"1:17: Expected superclass name after 'extends' at '{'",
+			"2:20: Expected expression at ')'",
+			"3:24: Expected variable name at '='",
+		}},
+		{"interface I ( {\n  override fun m()\n}", []string{
+			"1:13: Expected '{' before interface body at '('",
+			"2:12: 'override' is not allowed in an interface at 'fun'",
+		}},
+		{"turn x + {\n  case 1 { let = 2 }\n  default { print(1) }\n}", []string{
+			"1:10: Expected expression at '{'",
+			"2:16: Expected variable name at '='",
+		}},
+		// An enum body holds no statements.
+		{"enum E extends { A, B }", []string{
+			"1:8: Expected '{' before enum body at 'extends'",
+		}},
+		// A body left open runs to the end of the file.
+		{"while x ) {\n  let z =", []string{
+			"1:9: Expected '{' at ')'",
+			"2:10: Expected expression at end",
+			"2:10: Expected '}' after block at end",
+		}},
+	}
+	for _, tt := range tests {
+		statements, errs := parse(tt.src)
+		var got []string
+		for _, err := range errs {
+			got = append(got, fmt.Sprintf("%d:%d: %s", err.Token.Line, err.Token.Column, err))
+		}
+		if !reflect.DeepEqual(got, tt.errors) {
+			t.Errorf("%q: errors\n%s\nwant\n%s", tt.src, strings.Join(got, "\n"), strings.Join(tt.errors, "\n"))
+		}
+		if _, ok := statements[0].(*ast.BadStmt); !ok {
+			t.Errorf("%q: statement 0 is %T, want *ast.BadStmt", tt.src, statements[0])
+		}
+	}
+}
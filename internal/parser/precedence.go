@@ -1 +1,138 @@
 package parser
+
+import "dotFun/internal/lexer"
+
+// precedence orders how tightly operators bind, from loosest to tightest.
+//
+//	level          operators                      associativity
+//	assignment     = += -= *= /= %= &= |= <<= >>=  right
+//	nilCoalescing  ??                             left
+//	or             || or                          left
+//	and            && and                         left
+//	equality       == !=                          left
+//	comparison     < <= > >= instanceof           left
+//	range          .. ..<                         none
+//	bitOr          |                              left
+//	bitXor         ^                              left
+//	bitAnd         &                              left
+//	shift          << >>                          left
+//	term           + -                            left
+//	factor         * / %                          left
+//	unary          ! not - ~ (prefix)
+//	power          **                             right
+//	postfix        ++ --                          left
+//	call           ( . ?.                         left
+//
+// A lambda reaches as far right as it can, so it sits at the assignment
+// level. Unary operators bind looser than '**', so -2 ** 2 is -(2 ** 2),
+// while the exponent of '**' may itself be unary, as in 2 ** -1.
+type precedence int
+
+const (
+	precLowest precedence = iota
+	precAssignment
+	precNilCoalescing
+	precOr
+	precAnd
+	precEquality
+	precComparison
+	precRange
+	precBitOr
+	precBitXor
+	precBitAnd
+	precShift
+	precTerm
+	precFactor
+	precUnary
+	precPower
+	precPostfix
+	precCall
+)
+
+type associativity int
+
+const (
+	assocLeft associativity = iota
+	assocRight
+	// assocNone operators do not chain: a..b..c is an error.
+	assocNone
+)
+
+// operator describes a token that continues an expression after its left
+// operand.
+type operator struct {
+	precedence    precedence
+	associativity associativity
+}
+
+// leftMin is the loosest expression the operator accepts on its left.
+func (op operator) leftMin() precedence {
+	if op.associativity == assocLeft {
+		return op.precedence
+	}
+	return op.precedence + 1
+}
+
+// rightMin is the loosest expression the operator accepts on its right.
+func (op operator) rightMin() precedence {
+	if op.associativity == assocRight {
+		return op.precedence
+	}
+	return op.precedence + 1
+}
+
+var infixOperators = map[lexer.TokenType]operator{
+	lexer.EQUAL:             {precAssignment, assocRight},
+	lexer.PLUS_EQUAL:        {precAssignment, assocRight},
+	lexer.MINUS_EQUAL:       {precAssignment, assocRight},
+	lexer.STAR_EQUAL:        {precAssignment, assocRight},
+	lexer.SLASH_EQUAL:       {precAssignment, assocRight},
+	lexer.PERCENT_EQUAL:     {precAssignment, assocRight},
+	lexer.BIT_AND_EQUAL:     {precAssignment, assocRight},
+	lexer.BIT_OR_EQUAL:      {precAssignment, assocRight},
+	lexer.SHIFT_LEFT_EQUAL:  {precAssignment, assocRight},
+	lexer.SHIFT_RIGHT_EQUAL: {precAssignment, assocRight},
+
+	lexer.QUESTION_QUESTION: {precNilCoalescing, assocLeft},
+	lexer.OR_OR:             {precOr, assocLeft},
+	lexer.OR:                {precOr, assocLeft},
+	lexer.AND_AND:           {precAnd, assocLeft},
+	lexer.AND:               {precAnd, assocLeft},
+
+	lexer.EQUAL_EQUAL:   {precEquality, assocLeft},
+	lexer.BANG_EQUAL:    {precEquality, assocLeft},
+	lexer.LESS:          {precComparison, assocLeft},
+	lexer.LESS_EQUAL:    {precComparison, assocLeft},
+	lexer.GREATER:       {precComparison, assocLeft},
+	lexer.GREATER_EQUAL: {precComparison, assocLeft},
+	lexer.INSTANCEOF:    {precComparison, assocLeft},
+
+	lexer.DOT_DOT:      {precRange, assocNone},
+	lexer.DOT_DOT_LESS: {precRange, assocNone},
+
+	lexer.BIT_OR:      {precBitOr, assocLeft},
+	lexer.BIT_XOR:     {precBitXor, assocLeft},
+	lexer.BIT_AND:     {precBitAnd, assocLeft},
+	lexer.SHIFT_LEFT:  {precShift, assocLeft},
+	lexer.SHIFT_RIGHT: {precShift, assocLeft},
+	lexer.PLUS:        {precTerm, assocLeft},
+	lexer.MINUS:       {precTerm, assocLeft},
+	lexer.STAR:        {precFactor, assocLeft},
+	lexer.SLASH:       {precFactor, assocLeft},
+	lexer.PERCENT:     {precFactor, assocLeft},
+	lexer.STAR_STAR:   {precPower, assocRight},
+
+	lexer.PLUS_PLUS:   {precPostfix, assocLeft},
+	lexer.MINUS_MINUS: {precPostfix, assocLeft},
+
+	lexer.LEFT_PAREN:   {precCall, assocLeft},
+	lexer.DOT:          {precCall, assocLeft},
+	lexer.QUESTION_DOT: {precCall, assocLeft},
+}
+
+var prefixOperators = map[lexer.TokenType]bool{
+	lexer.NOT_BANG: true,
+	lexer.NOT:      true,
+	lexer.MINUS:    true,
+	lexer.TILDE:    true,
+}
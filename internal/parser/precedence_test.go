@@ -0,0 +1,162 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/ast/printer"
+)
+
+// parenthesize prints expr with every operator application in
+// parentheses, so the tree's shape can be read off the text.
+func parenthesize(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.BinaryExpr:
+		return "(" + parenthesize(e.Left) + " " + e.Operator + " " + parenthesize(e.Right) + ")"
+	case *ast.LogicalExpr:
+		return "(" + parenthesize(e.Left) + " " + e.Operator + " " + parenthesize(e.Right) + ")"
+	case *ast.UnaryExpr:
+		return "(" + e.Operator + " " + parenthesize(e.Right) + ")"
+	case *ast.PostfixUnaryExpr:
+		return "(" + parenthesize(e.Operand) + e.Operator + ")"
+	case *ast.AssignExpr:
+		return "(" + e.Name.Name + " " + e.Operator + " " + parenthesize(e.Value) + ")"
+	case *ast.MemberAssignExpr:
+		return "(" + parenthesize(e.Object) + "." + e.Property.Name + " " + e.Operator + " " + parenthesize(e.Value) + ")"
+	case *ast.RangeExpr:
+		op := "..<"
+		if e.Inclusive {
+			op = ".."
+		}
+		return "(" + parenthesize(e.Start) + op + parenthesize(e.Stop) + ")"
+	case *ast.InstanceOfExpr:
+		return "(" + parenthesize(e.Object) + " instanceof " + e.Type.TypeName() + ")"
+	case *ast.GroupingExpr:
+		return parenthesize(e.Expression)
+	case *ast.LambdaExpr:
+		return "(" + strings.Join(e.Params, ", ") + " => " + parenthesize(e.Body) + ")"
+	case *ast.CallExpr:
+		args := make([]string, len(e.Arguments))
+		for i, arg := range e.Arguments {
+			args[i] = parenthesize(arg)
+		}
+		return parenthesize(e.Callee) + "(" + strings.Join(args, ", ") + ")"
+	case *ast.MemberExpr:
+		dot := "."
+		if e.Optional {
+			dot = "?."
+		}
+		return parenthesize(e.Object) + dot + e.Property.Name
+	}
+	return printer.String(expr)
+}
+
+func TestPrecedence(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		// Each level against the next tighter one, both ways round.
+		{"a = b ?? c", "(a = (b ?? c))"},
+		{"a ?? b || c", "(a ?? (b || c))"},
+		{"a || b ?? c", "((a || b) ?? c)"},
+		{"a or b and c", "(a or (b and c))"},
+		{"a and b or c", "((a and b) or c)"},
+		{"a && b == c", "(a && (b == c))"},
+		{"a == b && c", "((a == b) && c)"},
+		{"a == b < c", "(a == (b < c))"},
+		{"a < b == c", "((a < b) == c)"},
+		{"a < b..c", "(a < (b..c))"},
+		{"a..b < c", "((a..b) < c)"},
+		{"a..<b | c", "(a..<(b | c))"},
+		{"a | b..c", "((a | b)..c)"},
+		{"a | b ^ c", "(a | (b ^ c))"},
+		{"a ^ b | c", "((a ^ b) | c)"},
+		{"a ^ b & c", "(a ^ (b & c))"},
+		{"a & b ^ c", "((a & b) ^ c)"},
+		{"a & b << c", "(a & (b << c))"},
+		{"a << b & c", "((a << b) & c)"},
+		{"a << b + c", "(a << (b + c))"},
+		{"a + b << c", "((a + b) << c)"},
+		{"a + b * c", "(a + (b * c))"},
+		{"a * b + c", "((a * b) + c)"},
+		{"a * -b", "(a * (- b))"},
+		{"-a * b", "((- a) * b)"},
+		{"a instanceof B == c", "((a instanceof B) == c)"},
+		{"a < b instanceof C", "((a < b) instanceof C)"},
+
+		// Associativity.
+		{"a = b = c", "(a = (b = c))"},
+		{"a += b -= c", "(a += (b -= c))"},
+		{"a.b = c.d = e", "(a.b = (c.d = e))"},
+		{"a.b.c *= d ?? e", "(a.b.c *= (d ?? e))"},
+		{"f(x).y = z", "(f(x).y = z)"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"a || b || c", "((a || b) || c)"},
+		{"a - b - c", "((a - b) - c)"},
+		{"a / b * c", "((a / b) * c)"},
+		{"a << b >> c", "((a << b) >> c)"},
+		{"a == b != c", "((a == b) != c)"},
+		{"a ** b ** c", "(a ** (b ** c))"},
+
+		// Unary operators bind looser than '**', whose exponent may be
+		// unary.
+		{"-2 ** 2", "(- (2 ** 2))"},
+		{"2 ** -1", "(2 ** (- 1))"},
+		{"-2 ** -2", "(- (2 ** (- 2)))"},
+		{"(-2) ** 2", "((- 2) ** 2)"},
+		{"- -x", "(- (- x))"},
+		{"~-x", "(~ (- x))"},
+		{"not a and b", "((not a) and b)"},
+		{"!a == b", "((! a) == b)"},
+		{"-x++", "(- (x++))"},
+		{"x++ ** 2", "((x++) ** 2)"},
+		{"-a.b(c)", "(- a.b(c))"},
+
+		// Calls and members bind tightest and chain to the left.
+		{"a.b.c", "a.b.c"},
+		{"a?.b.c(d)(e)", "a?.b.c(d)(e)"},
+		{"f(a + b, c)", "f((a + b), c)"},
+
+		// A lambda reaches as far right as it can, also in arguments.
+		{"f(x => x + 1, y)", "f((x => (x + 1)), y)"},
+		{"f((a, b) -> a * b)", "f((a, b => (a * b)))"},
+		{"a = x => y => x + y", "(a = (x => (y => (x + y))))"},
+		{"(x => x)(1) + 2", "((x => x)(1) + 2)"},
+	}
+	for _, tt := range tests {
+		statements, errs := parse(tt.src)
+		if len(errs) > 0 {
+			t.Errorf("%q: %v", tt.src, errs)
+			continue
+		}
+		stmt, ok := statements[0].(*ast.ExpressionStmt)
+		if len(statements) != 1 || !ok {
+			t.Errorf("%q: not a single expression statement", tt.src)
+			continue
+		}
+		if got := parenthesize(stmt.Expression); got != tt.want {
+			t.Errorf("%q parsed as %s, want %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestPrecedenceErrors(t *testing.T) {
+	for _, src := range []string{
+		"a..b..c",
+		"a..<b..c",
+		"a..b..<c",
+		"1 = 2",
+		"a + b = c",
+		"a?.b = c",
+		"f() = c",
+		"-a.b = c",
+		"a +",
+		"* a",
+		"f(a,",
+	} {
+		if _, errs := parse(src); len(errs) == 0 {
+			t.Errorf("%q parsed without errors", src)
+		}
+	}
+}
@@ -1 +1,422 @@
 package parser
+
+import (
+	"dotFun/internal/cst"
+	"dotFun/internal/lexer"
+)
+
+func (p *Parser) declaration() (cst.Kind, error) {
+	m := p.modifiers()
+	if m.override {
+		return "", p.errorAt(p.previous(), "'override' is only allowed on methods")
+	}
+	if kind, ok, err := p.modifiedDeclaration(m); ok {
+		return kind, err
+	}
+	if m.any() {
+		return "", p.errorAt(p.peek(), "Expected a declaration after '%s'", p.previous().Lexeme)
+	}
+
+	switch {
+	case p.checkContextual("global") && p.checkNext(lexer.IDENTIFIER):
+		return p.varDeclaration(p.advance())
+	case p.match(lexer.IMPORT):
+		return cst.ImportStmt, p.importDeclaration()
+	case p.match(lexer.EXPORT):
+		return cst.ExportStmt, p.exportDeclaration()
+	}
+	return p.statement()
+}
+
+func (p *Parser) statement() (cst.Kind, error) {
+	switch {
+	case p.match(lexer.IF):
+		return cst.IfStmt, p.ifStatement()
+	case p.match(lexer.WHILE):
+		return cst.WhileStmt, p.whileStatement()
+	case p.match(lexer.FOR):
+		return cst.ForStmt, p.forStatement()
+	case p.match(lexer.RETURN):
+		return cst.ReturnStmt, p.returnStatement()
+	case p.match(lexer.THROW):
+		return cst.ThrowStmt, p.throwStatement()
+	case p.match(lexer.TRY):
+		return cst.TryStmt, p.tryStatement()
+	case p.checkContextual("turn") && p.isTurn():
+		return cst.SwitchStmt, p.turnStatement()
+	case p.match(lexer.BREAK):
+		return cst.BreakStmt, p.endStatement()
+	case p.match(lexer.CONTINUE):
+		return cst.ContinueStmt, p.endStatement()
+	case p.check(lexer.LEFT_BRACE):
+		return cst.BlockStmt, p.block()
+	}
+	return cst.ExpressionStmt, p.expressionStatement()
+}
+
+// endStatement consumes the ';' or newline ending a statement. The
+// terminator may be left out before a '}' or the end of input.
+func (p *Parser) endStatement() error {
+	if p.match(lexer.SEMICOLON, lexer.NEWLINE) || p.check(lexer.RIGHT_BRACE) || p.isAtEnd() {
+		return nil
+	}
+	return p.errorAt(p.peek(), "Expected newline or ';' after statement")
+}
+
+// skipNewlines consumes blank statement terminators, such as the newline
+// after a block.
+func (p *Parser) skipNewlines() {
+	for p.match(lexer.NEWLINE) {
+	}
+}
+
+// matchAfterNewlines is like match but also looks past newlines, which
+// are only consumed when one of types follows them. It lets 'else' start a
+// new line after the closing '}'.
+func (p *Parser) matchAfterNewlines(types ...lexer.TokenType) bool {
+	i := p.current
+	for i < len(p.tokens) && p.tokens[i].Type == lexer.NEWLINE {
+		i++
+	}
+	for _, t := range types {
+		if i < len(p.tokens) && p.tokens[i].Type == t {
+			p.current = i + 1
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) expressionStatement() error {
+	if err := p.expression(); err != nil {
+		return err
+	}
+	return p.endStatement()
+}
+
+func (p *Parser) block() error {
+	cp := p.checkpoint()
+	if _, err := p.consume(lexer.LEFT_BRACE, "Expected '{'"); err != nil {
+		return err
+	}
+	p.depth++
+	for p.skipNewlines(); !p.check(lexer.RIGHT_BRACE) && !p.isAtEnd(); p.skipNewlines() {
+		p.recoverStmt(p.declaration)
+	}
+	p.depth--
+	if _, err := p.consume(lexer.RIGHT_BRACE, "Expected '}' after block"); err != nil {
+		return err
+	}
+	p.finish(cp, cst.BlockStmt)
+	return nil
+}
+
+// varDeclaration parses a let, val or global declaration after its
+// keyword and returns its kind.
+func (p *Parser) varDeclaration(keyword lexer.Token) (cst.Kind, error) {
+	name, err := p.consume(lexer.IDENTIFIER, "Expected variable name")
+	if err != nil {
+		return "", err
+	}
+	if p.match(lexer.COLON) {
+		if err := p.parseType(); err != nil {
+			return "", err
+		}
+	}
+	if p.match(lexer.EQUAL) {
+		if err := p.expression(); err != nil {
+			return "", err
+		}
+	} else if keyword.Type == lexer.VAL {
+		return "", p.errorAt(p.peek(), "Expected '=' after val '%s'", name.Lexeme)
+	}
+	if err := p.endStatement(); err != nil {
+		return "", err
+	}
+
+	switch keyword.Lexeme {
+	case "val":
+		return cst.ValStmt, nil
+	case "global":
+		return cst.GlobalStmt, nil
+	default:
+		return cst.LetStmt, nil
+	}
+}
+
+// function parses a function declaration after 'fun'. Interface methods
+// may leave out the body.
+func (p *Parser) function(bodyOptional bool) error {
+	if _, err := p.consume(lexer.IDENTIFIER, "Expected function name"); err != nil {
+		return err
+	}
+	if err := p.parameters(); err != nil {
+		return err
+	}
+	if p.match(lexer.COLON) {
+		if err := p.parseType(); err != nil {
+			return err
+		}
+	}
+	if bodyOptional && !p.check(lexer.LEFT_BRACE) {
+		return p.endStatement()
+	}
+	return p.block()
+}
+
+func (p *Parser) parameters() error {
+	if _, err := p.consume(lexer.LEFT_PAREN, "Expected '(' before parameters"); err != nil {
+		return err
+	}
+	if !p.check(lexer.RIGHT_PAREN) {
+		for {
+			cp := p.checkpoint()
+			if _, err := p.consume(lexer.IDENTIFIER, "Expected parameter name"); err != nil {
+				return err
+			}
+			if p.match(lexer.COLON) {
+				if err := p.parseType(); err != nil {
+					return err
+				}
+			}
+			p.finish(cp, cst.Parameter)
+			if !p.match(lexer.COMMA) {
+				break
+			}
+		}
+	}
+	_, err := p.consume(lexer.RIGHT_PAREN, "Expected ')' after parameters")
+	return err
+}
+
+func (p *Parser) parseType() error {
+	if p.match(lexer.LEFT_BRACKET) {
+		if _, err := p.consume(lexer.RIGHT_BRACKET, "Expected ']' in array type"); err != nil {
+			return err
+		}
+		return p.parseType()
+	}
+	_, err := p.consume(lexer.IDENTIFIER, "Expected type name")
+	return err
+}
+
+func (p *Parser) ifStatement() error {
+	if err := p.expression(); err != nil {
+		return err
+	}
+	if err := p.block(); err != nil {
+		return err
+	}
+
+	switch {
+	case p.matchAfterNewlines(lexer.ELIF):
+		cp := p.checkpointAt(p.current - 1)
+		if err := p.ifStatement(); err != nil {
+			return err
+		}
+		p.finish(cp, cst.IfStmt)
+	case p.matchAfterNewlines(lexer.ELSE):
+		cp := p.checkpoint()
+		if !p.match(lexer.IF) {
+			return p.block()
+		}
+		if err := p.ifStatement(); err != nil {
+			return err
+		}
+		p.finish(cp, cst.IfStmt)
+	}
+	return nil
+}
+
+func (p *Parser) whileStatement() error {
+	if err := p.expression(); err != nil {
+		return err
+	}
+	return p.block()
+}
+
+func (p *Parser) forStatement() error {
+	parens := p.match(lexer.LEFT_PAREN)
+
+	cp := p.checkpoint()
+	var init cst.Kind
+	var err error
+	switch {
+	case p.match(lexer.SEMICOLON):
+	case p.match(lexer.LET, lexer.VAL):
+		init, err = p.varDeclaration(p.previous())
+	default:
+		init, err = cst.ExpressionStmt, p.expressionStatement()
+	}
+	if err != nil {
+		return err
+	}
+	if init != "" {
+		p.finish(cp, init)
+		if p.previous().Type != lexer.SEMICOLON {
+			return p.errorAt(p.peek(), "Expected ';' after loop initializer")
+		}
+	}
+
+	if !p.check(lexer.SEMICOLON) {
+		if err := p.expression(); err != nil {
+			return err
+		}
+	}
+	if _, err := p.consume(lexer.SEMICOLON, "Expected ';' after loop condition"); err != nil {
+		return err
+	}
+
+	closing := lexer.LEFT_BRACE
+	if parens {
+		closing = lexer.RIGHT_PAREN
+	}
+	if !p.check(closing) {
+		cp := p.checkpoint()
+		if err := p.expression(); err != nil {
+			return err
+		}
+		p.finish(cp, cst.ExpressionStmt)
+	}
+	if parens {
+		if _, err := p.consume(lexer.RIGHT_PAREN, "Expected ')' after for clauses"); err != nil {
+			return err
+		}
+	}
+	return p.block()
+}
+
+func (p *Parser) returnStatement() error {
+	if !p.check(lexer.SEMICOLON) && !p.check(lexer.NEWLINE) && !p.check(lexer.RIGHT_BRACE) && !p.isAtEnd() {
+		if err := p.expression(); err != nil {
+			return err
+		}
+	}
+	return p.endStatement()
+}
+
+func (p *Parser) throwStatement() error {
+	if err := p.expression(); err != nil {
+		return err
+	}
+	return p.endStatement()
+}
+
+// tryStatement parses try, at least one of catch and finally, and the
+// optional name of the caught value, which may be parenthesised.
+func (p *Parser) tryStatement() error {
+	if err := p.block(); err != nil {
+		return err
+	}
+
+	handled := false
+	if p.matchAfterNewlines(lexer.CATCH) {
+		parens := p.match(lexer.LEFT_PAREN)
+		if parens || p.check(lexer.IDENTIFIER) {
+			if _, err := p.consume(lexer.IDENTIFIER, "Expected name after 'catch'"); err != nil {
+				return err
+			}
+		}
+		if parens {
+			if _, err := p.consume(lexer.RIGHT_PAREN, "Expected ')' after catch name"); err != nil {
+				return err
+			}
+		}
+		if err := p.block(); err != nil {
+			return err
+		}
+		handled = true
+	}
+	if p.matchAfterNewlines(lexer.FINALLY) {
+		if err := p.block(); err != nil {
+			return err
+		}
+		handled = true
+	}
+	if !handled {
+		return p.errorAt(p.peek(), "Expected 'catch' or 'finally' after try block")
+	}
+	return nil
+}
+
+// isTurn reports whether the 'turn' at the current token starts a turn
+// statement rather than naming a variable. Expressions never hold a '{',
+// so it does if a '{' follows before the end of the statement.
+func (p *Parser) isTurn() bool {
+	depth := 0
+	for i := p.current + 1; i < len(p.tokens); i++ {
+		switch p.tokens[i].Type {
+		case lexer.LEFT_PAREN, lexer.LEFT_BRACKET:
+			depth++
+		case lexer.RIGHT_PAREN, lexer.RIGHT_BRACKET:
+			depth--
+		case lexer.LEFT_BRACE:
+			return depth == 0
+		case lexer.NEWLINE, lexer.SEMICOLON, lexer.RIGHT_BRACE, lexer.EOF_TOKEN:
+			return false
+		}
+	}
+	return false
+}
+
+func (p *Parser) turnStatement() error {
+	p.advance()
+	if err := p.expression(); err != nil {
+		return err
+	}
+	return p.turnBody()
+}
+
+// turnBody parses the braced cases of a turn statement.
+func (p *Parser) turnBody() error {
+	if _, err := p.consume(lexer.LEFT_BRACE, "Expected '{' after turn subject"); err != nil {
+		return err
+	}
+
+	hasDefault := false
+	p.depth++
+	for p.skipNewlines(); !p.check(lexer.RIGHT_BRACE) && !p.isAtEnd(); p.skipNewlines() {
+		start, cp := p.current, p.checkpoint()
+		kind, err := p.turnCase(hasDefault)
+		switch {
+		case err != nil:
+			// Keep a case holding a BadExpr in place of the skipped tokens.
+			p.report(err)
+			p.synchronize(start)
+			p.finish(cp, cst.BadExpr)
+			p.finish(cp, cst.SwitchCase)
+		case kind == cst.SwitchCase:
+			p.finish(cp, kind)
+		default:
+			hasDefault = true
+		}
+	}
+	p.depth--
+	_, err := p.consume(lexer.RIGHT_BRACE, "Expected '}' after turn cases")
+	return err
+}
+
+// turnCase parses one case, or the default case, whose block is left
+// as it is, and returns its kind.
+func (p *Parser) turnCase(hasDefault bool) (cst.Kind, error) {
+	if hasDefault {
+		return "", p.errorAt(p.peek(), "Expected '}' after default case")
+	}
+	if p.checkContextual("default") {
+		p.advance()
+		return cst.BlockStmt, p.block()
+	}
+
+	if _, err := p.consume(lexer.CASE, "Expected 'case' or 'default'"); err != nil {
+		return "", err
+	}
+	for {
+		if err := p.expression(); err != nil {
+			return "", err
+		}
+		if !p.match(lexer.COMMA) {
+			break
+		}
+	}
+	return cst.SwitchCase, p.block()
+}
@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"dotFun/internal/ast"
+	"dotFun/internal/cst"
+	"dotFun/internal/lexer"
+)
+
+// ParseTree is like Parse but also returns the concrete syntax tree. The
+// parser builds only the tree; the statements are then derived from it,
+// and each node links to the ast node derived from it. Only the text parts
+// of an interpolated string have no node of their own; they are in the
+// string's tokens. The tree holds every token, so it is lossless when the
+// tokens were lexed with lexer.KeepTrivia.
+func (p *Parser) ParseTree() (*cst.Node, []ast.Stmt, error) {
+	for p.skipNewlines(); !p.isAtEnd(); p.skipNewlines() {
+		p.recoverStmt(p.declaration)
+	}
+	p.addTokens(len(p.tokens))
+	statements := p.statements(0)
+	return p.tree.Root(), statements, p.errors.Err()
+}
+
+// checkpoint marks the start of a tree node at the current token.
+func (p *Parser) checkpoint() int {
+	return p.checkpointAt(p.current)
+}
+
+// checkpointAt marks the start of a tree node at token i, which must not
+// have been added to the tree yet.
+func (p *Parser) checkpointAt(i int) int {
+	p.addTokens(i)
+	return p.tree.Checkpoint()
+}
+
+// finish ends the tree node of the given kind, started at cp, after the
+// last consumed token.
+func (p *Parser) finish(cp int, kind cst.Kind) *cst.GreenNode {
+	p.addTokens(p.current)
+	return p.tree.Finish(cp, kind)
+}
+
+// addTokens adds the tokens before end that are not in the tree yet.
+func (p *Parser) addTokens(end int) {
+	for ; p.emitted < end; p.emitted++ {
+		p.tree.Token(p.tokens[p.emitted])
+	}
+}
+
+// addString adds the interpolated string tok, the last consumed token, to
+// the tree. exprs holds the tree of each interpolated expression, parsed
+// by its own parser. The text around them becomes INTERPOLATED_STRING
+// tokens, the first and last of which carry the trivia of tok.
+func (p *Parser) addString(tok lexer.Token, exprs [][]cst.Green) {
+	p.addTokens(p.current - 1)
+	base, pos := tok.Span.StartOffset, tok.Span.StartOffset
+	var children []cst.Green
+	text := func(end int) {
+		children = append(children, cst.NewGreenToken(lexer.INTERPOLATED_STRING, tok.Lexeme[pos-base:end-base], nil, nil))
+		pos = end
+	}
+
+	i := 0
+	for _, part := range tok.Literal.Parts {
+		if !part.IsExpr() {
+			continue
+		}
+		text(fullStart(part.Tokens[0]))
+		for _, g := range exprs[i] {
+			children = append(children, g)
+			pos += g.Width()
+		}
+		i++
+	}
+	text(tok.Span.EndOffset)
+
+	// The string always starts and ends with text.
+	green := cst.GreenTokenOf(tok)
+	first, last := children[0].(*cst.GreenToken), children[len(children)-1].(*cst.GreenToken)
+	children[0] = cst.NewGreenToken(lexer.INTERPOLATED_STRING, first.Text, green.Leading, nil)
+	children[len(children)-1] = cst.NewGreenToken(lexer.INTERPOLATED_STRING, last.Text, nil, green.Trailing)
+	for _, child := range children {
+		p.tree.Add(child)
+	}
+	p.emitted = p.current
+}
+
+// fullStart returns the offset where tok starts, including its leading
+// trivia. The EOF token ending an interpolation is not added to the tree,
+// so its trivia is left to the surrounding text.
+func fullStart(tok lexer.Token) int {
+	start := tok.Span.StartOffset
+	if tok.Type != lexer.EOF_TOKEN {
+		for _, t := range tok.Leading {
+			start -= len(t.Text)
+		}
+	}
+	return start
+}
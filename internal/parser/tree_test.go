@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"dotFun/internal/cst"
+	"dotFun/internal/lexer"
+)
+
+// treeSources returns the corpus, broken input and random edits of the
+// corpus.
+func treeSources(t *testing.T) []string {
+	src := corpus(t)
+	sources := []string{
+		src,
+		"",
+		"let x = (1 +\n",
+		"fun f( { let = }\n class {",
+		"let s = \"a ${ b + } c ${} d ${ @ } e\"\n",
+		"turn x {\n  case { }\n  default { }\n  case 1 { }\n}\n",
+		"/* unterminated",
+		"f(a, , b)[1, +]\n",
+		"  // only a comment\n\n",
+	}
+	texts := []string{"", "x", " ", "\n", "(", ")", "{", "}", "\"", "${", "/*", "//", "let w = 2\n"}
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < 500; i++ {
+		start := rng.Intn(len(src) + 1)
+		end := min(start+rng.Intn(3), len(src))
+		edit := lexer.Edit{Start: start, End: end, Text: texts[rng.Intn(len(texts))]}
+		sources = append(sources, edit.Apply(src))
+	}
+	return sources
+}
+
+// TestTreeLossless checks that the tree of valid and broken input holds
+// the source exactly, that the statements derived from it are those of
+// Parse, and that each node links to an ast node of its kind.
+func TestTreeLossless(t *testing.T) {
+	for _, src := range treeSources(t) {
+		l := lexer.NewLexer(src)
+		l.SetMode(lexer.KeepTrivia)
+		tokens, _ := l.Lex()
+
+		root, statements, err := NewParser(tokens).ParseTree()
+		if got := root.FullText(); got != src {
+			t.Errorf("tree text %q, want %q", got, src)
+			continue
+		}
+		want, wantErr := NewParser(tokens).Parse()
+		if !reflect.DeepEqual(statements, want) || !reflect.DeepEqual(err, wantErr) {
+			t.Errorf("%q: ParseTree and Parse differ", src)
+		}
+		for _, n := range root.Nodes() {
+			checkLinks(t, src, n)
+		}
+	}
+}
+
+func checkLinks(t *testing.T, src string, n *cst.Node) {
+	t.Helper()
+	if got, want := fmt.Sprintf("%T", n.AST()), "*ast."+string(n.Kind()); got != want {
+		t.Errorf("%q: %s node links to %s", src, n.Kind(), got)
+		return
+	}
+	if n.Kind() == cst.BadStmt || n.Kind() == cst.BadExpr {
+		return
+	}
+	for _, c := range n.Nodes() {
+		checkLinks(t, src, c)
+	}
+}
+
+// TestGreenHasNoAST checks that the links to the ast belong to the red
+// tree: another red tree over the same green nodes has none.
+func TestGreenHasNoAST(t *testing.T) {
+	tokens, _ := lexer.NewLexer("let a = b + 1\n").Lex()
+	root, _, _ := NewParser(tokens).ParseTree()
+	stmt := root.Nodes()[0]
+	if stmt.AST() == nil {
+		t.Fatal("statement node has no ast node")
+	}
+	if n := cst.NewRoot(stmt.Green(), nil); n.AST() != nil || n.Nodes()[0].AST() != nil {
+		t.Error("a red tree without links reports ast nodes")
+	}
+}
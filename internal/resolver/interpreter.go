@@ -1 +1,578 @@
 package resolver
+
+import (
+	"fmt"
+	"strings"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/runtime"
+	"dotFun/internal/runtime/stdlib/core"
+)
+
+type Function struct {
+	Name    string
+	Params  []string
+	Body    []ast.Stmt
+	Expr    ast.Expr
+	Closure *runtime.Environment
+}
+
+func (f *Function) Type() string { return "Function" }
+func (f *Function) Arity() int   { return len(f.Params) }
+
+func (f *Function) String() string {
+	if f.Name == "" {
+		return "<lambda>"
+	}
+	return "<fun " + f.Name + ">"
+}
+
+// Thrown carries a value raised by a throw statement until a matching catch
+// or the top of the program.
+type Thrown struct {
+	Value runtime.Value
+	// Span is the throw statement, positioning the error when nothing
+	// catches the value.
+	Span ast.Span
+}
+
+func (t *Thrown) Error() string {
+	return "Uncaught exception: " + t.Value.String()
+}
+
+func (t *Thrown) Position() (int, int) {
+	return t.Span.StartLine, t.Span.StartCol
+}
+
+func (t *Thrown) Offsets() (int, int) {
+	return t.Span.StartOffset, t.Span.EndOffset
+}
+
+type controlKind int
+
+const (
+	controlBreak controlKind = iota
+	controlContinue
+	controlReturn
+)
+
+type controlFlow struct {
+	kind  controlKind
+	value runtime.Value
+}
+
+type Interpreter struct {
+	globals *runtime.Environment
+	env     *runtime.Environment
+	// span is the innermost node being evaluated. It is not restored when
+	// a runtime error unwinds, so it still points at the failing node.
+	span ast.Span
+}
+
+func NewInterpreter() *Interpreter {
+	globals := runtime.NewEnvironment(nil)
+	for _, native := range core.Natives() {
+		globals.Define(native.Name, native, true)
+	}
+	return &Interpreter{
+		globals: globals,
+		env:     globals,
+	}
+}
+
+func (i *Interpreter) Interpret(statements []ast.Stmt) (err error) {
+	defer i.recoverError(&err)
+	for _, stmt := range statements {
+		i.execute(stmt)
+	}
+	return nil
+}
+
+// Eval evaluates a single expression in the global environment.
+func (i *Interpreter) Eval(expr ast.Expr) (value runtime.Value, err error) {
+	defer i.recoverError(&err)
+	return i.evaluate(expr), nil
+}
+
+func (i *Interpreter) recoverError(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	i.env = i.globals
+	switch r := r.(type) {
+	case *runtime.Error:
+		*err = r
+	case *Thrown:
+		*err = r
+	default:
+		panic(r)
+	}
+}
+
+func (i *Interpreter) fail(format string, args ...any) {
+	panic(i.newError(fmt.Sprintf(format, args...)))
+}
+
+func (i *Interpreter) check(err error) {
+	if err != nil {
+		panic(i.newError(err.Error()))
+	}
+}
+
+func (i *Interpreter) newError(message string) *runtime.Error {
+	return &runtime.Error{
+		Message:     message,
+		Line:        i.span.StartLine,
+		Column:      i.span.StartCol,
+		StartOffset: i.span.StartOffset,
+		EndOffset:   i.span.EndOffset,
+	}
+}
+
+func (i *Interpreter) evaluate(expr ast.Expr) runtime.Value {
+	enclosing := i.span
+	i.span = expr.SourceSpan()
+	value := ast.VisitExpr[runtime.Value](i, expr)
+	i.span = enclosing
+	return value
+}
+
+func (i *Interpreter) execute(stmt ast.Stmt) *controlFlow {
+	enclosing := i.span
+	i.span = stmt.SourceSpan()
+	result := ast.VisitStmt[*controlFlow](i, stmt)
+	i.span = enclosing
+	return result
+}
+
+func (i *Interpreter) executeBlock(statements []ast.Stmt, env *runtime.Environment) *controlFlow {
+	previous := i.env
+	i.env = env
+	defer func() { i.env = previous }()
+
+	for _, stmt := range statements {
+		if flow := i.execute(stmt); flow != nil {
+			return flow
+		}
+	}
+	return nil
+}
+
+func (i *Interpreter) call(callee runtime.Value, args []runtime.Value) runtime.Value {
+	fn, ok := callee.(runtime.Callable)
+	if !ok {
+		i.fail("Can only call functions, got %s", callee.Type())
+	}
+	if arity := fn.Arity(); arity != runtime.Variadic && arity != len(args) {
+		i.fail("Expected %d arguments but got %d", arity, len(args))
+	}
+
+	switch fn := fn.(type) {
+	case *runtime.NativeFunction:
+		result, err := fn.Call(args)
+		i.check(err)
+		return result
+	case *Function:
+		return i.callFunction(fn, args)
+	}
+	i.fail("Cannot call %s", callee.Type())
+	return nil
+}
+
+func (i *Interpreter) callFunction(fn *Function, args []runtime.Value) runtime.Value {
+	env := runtime.NewEnvironment(fn.Closure)
+	for idx, param := range fn.Params {
+		env.Define(param, args[idx], false)
+	}
+
+	if fn.Expr != nil {
+		previous := i.env
+		i.env = env
+		defer func() { i.env = previous }()
+		return i.evaluate(fn.Expr)
+	}
+
+	if flow := i.executeBlock(fn.Body, env); flow != nil && flow.kind == controlReturn {
+		return flow.value
+	}
+	return runtime.NilValue
+}
+
+func (i *Interpreter) VisitBlockStmt(stmt *ast.BlockStmt) *controlFlow {
+	return i.executeBlock(stmt.Statements, runtime.NewEnvironment(i.env))
+}
+
+func (i *Interpreter) VisitBreakStmt(stmt *ast.BreakStmt) *controlFlow {
+	return &controlFlow{kind: controlBreak}
+}
+
+func (i *Interpreter) VisitContinueStmt(stmt *ast.ContinueStmt) *controlFlow {
+	return &controlFlow{kind: controlContinue}
+}
+
+func (i *Interpreter) VisitExpressionStmt(stmt *ast.ExpressionStmt) *controlFlow {
+	i.evaluate(stmt.Expression)
+	return nil
+}
+
+func (i *Interpreter) VisitReturnStmt(stmt *ast.ReturnStmt) *controlFlow {
+	value := runtime.NilValue
+	if stmt.Value != nil {
+		value = i.evaluate(stmt.Value)
+	}
+	return &controlFlow{kind: controlReturn, value: value}
+}
+
+func (i *Interpreter) VisitThrowStmt(stmt *ast.ThrowStmt) *controlFlow {
+	panic(&Thrown{Value: i.evaluate(stmt.Value), Span: stmt.Span})
+}
+
+func (i *Interpreter) VisitTryStmt(stmt *ast.TryStmt) *controlFlow {
+	i.fail("try statements are not supported yet")
+	return nil
+}
+
+func (i *Interpreter) VisitIfStmt(stmt *ast.IfStmt) *controlFlow {
+	if runtime.Truthy(i.evaluate(stmt.Condition)) {
+		return i.VisitBlockStmt(stmt.ThenBlock)
+	}
+	if stmt.ElseBlock != nil {
+		return i.execute(stmt.ElseBlock)
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitWhileStmt(stmt *ast.WhileStmt) *controlFlow {
+	for runtime.Truthy(i.evaluate(stmt.Condition)) {
+		if flow := i.VisitBlockStmt(stmt.Body); flow != nil {
+			if flow.kind == controlBreak {
+				break
+			}
+			if flow.kind == controlReturn {
+				return flow
+			}
+		}
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitForStmt(stmt *ast.ForStmt) *controlFlow {
+	previous := i.env
+	i.env = runtime.NewEnvironment(previous)
+	defer func() { i.env = previous }()
+
+	if stmt.Init != nil {
+		i.execute(stmt.Init)
+	}
+	for stmt.Condition == nil || runtime.Truthy(i.evaluate(stmt.Condition)) {
+		if flow := i.VisitBlockStmt(stmt.Body); flow != nil {
+			if flow.kind == controlBreak {
+				break
+			}
+			if flow.kind == controlReturn {
+				return flow
+			}
+		}
+		if stmt.Post != nil {
+			i.execute(stmt.Post)
+		}
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitSwitchCase(stmt *ast.SwitchCase) *controlFlow {
+	return i.VisitBlockStmt(stmt.Body)
+}
+
+func (i *Interpreter) VisitSwitchStmt(stmt *ast.SwitchStmt) *controlFlow {
+	i.fail("turn statements are not supported yet")
+	return nil
+}
+
+func (i *Interpreter) defineVar(name string, initializer ast.Expr, constant bool) {
+	value := runtime.NilValue
+	if initializer != nil {
+		value = i.evaluate(initializer)
+	}
+	i.env.Define(name, value, constant)
+}
+
+func (i *Interpreter) VisitValStmt(stmt *ast.ValStmt) *controlFlow {
+	i.defineVar(stmt.Name, stmt.Initializer, true)
+	return nil
+}
+
+func (i *Interpreter) VisitLetStmt(stmt *ast.LetStmt) *controlFlow {
+	i.defineVar(stmt.Name, stmt.Initializer, false)
+	return nil
+}
+
+func (i *Interpreter) VisitGlobalStmt(stmt *ast.GlobalStmt) *controlFlow {
+	value := runtime.NilValue
+	if stmt.Initializer != nil {
+		value = i.evaluate(stmt.Initializer)
+	}
+	i.globals.Define(stmt.Name, value, false)
+	return nil
+}
+
+func (i *Interpreter) VisitFunctionStmt(stmt *ast.FunctionStmt) *controlFlow {
+	params := make([]string, len(stmt.Parameters))
+	for idx, param := range stmt.Parameters {
+		params[idx] = param.Name
+	}
+	i.env.Define(stmt.Name, &Function{
+		Name:    stmt.Name,
+		Params:  params,
+		Body:    stmt.Body.Statements,
+		Closure: i.env,
+	}, false)
+	return nil
+}
+
+func (i *Interpreter) VisitClassStmt(stmt *ast.ClassStmt) *controlFlow {
+	i.fail("class declarations are not supported yet")
+	return nil
+}
+
+func (i *Interpreter) VisitConstructorStmt(stmt *ast.ConstructorStmt) *controlFlow {
+	i.fail("constructors are not supported yet")
+	return nil
+}
+
+func (i *Interpreter) VisitInterfaceStmt(stmt *ast.InterfaceStmt) *controlFlow {
+	i.fail("interface declarations are not supported yet")
+	return nil
+}
+
+func (i *Interpreter) VisitStructStmt(stmt *ast.StructStmt) *controlFlow {
+	i.fail("struct declarations are not supported yet")
+	return nil
+}
+
+func (i *Interpreter) VisitEnumStmt(stmt *ast.EnumStmt) *controlFlow {
+	i.fail("enum declarations are not supported yet")
+	return nil
+}
+
+func (i *Interpreter) VisitDataStmt(stmt *ast.DataStmt) *controlFlow {
+	i.fail("data declarations are not supported yet")
+	return nil
+}
+
+func (i *Interpreter) VisitImportStmt(stmt *ast.ImportStmt) *controlFlow {
+	i.fail("imports are not supported yet")
+	return nil
+}
+
+func (i *Interpreter) VisitExportStmt(stmt *ast.ExportStmt) *controlFlow {
+	i.fail("exports are not supported yet")
+	return nil
+}
+
+func (i *Interpreter) VisitBadStmt(stmt *ast.BadStmt) *controlFlow {
+	i.fail("cannot run a statement with syntax errors")
+	return nil
+}
+
+func (i *Interpreter) VisitIntLiteral(expr *ast.IntLiteral) runtime.Value {
+	return runtime.Int(expr.Value)
+}
+
+func (i *Interpreter) VisitFloatLiteral(expr *ast.FloatLiteral) runtime.Value {
+	return runtime.Float(expr.Value)
+}
+
+func (i *Interpreter) VisitStringLiteral(expr *ast.StringLiteral) runtime.Value {
+	return runtime.String(expr.Value)
+}
+
+func (i *Interpreter) VisitInterpolatedString(expr *ast.InterpolatedString) runtime.Value {
+	var sb strings.Builder
+	for _, part := range expr.Parts {
+		sb.WriteString(i.evaluate(part).String())
+	}
+	return runtime.String(sb.String())
+}
+
+func (i *Interpreter) VisitCharLiteral(expr *ast.CharLiteral) runtime.Value {
+	return runtime.Char(expr.Value)
+}
+
+func (i *Interpreter) VisitBoolLiteral(expr *ast.BoolLiteral) runtime.Value {
+	return runtime.Bool(expr.Value)
+}
+
+func (i *Interpreter) VisitNilLiteral(expr *ast.NilLiteral) runtime.Value {
+	return runtime.NilValue
+}
+
+func (i *Interpreter) VisitArrayLiteral(expr *ast.ArrayLiteral) runtime.Value {
+	elements := make([]runtime.Value, len(expr.Elements))
+	for idx, element := range expr.Elements {
+		elements[idx] = i.evaluate(element)
+	}
+	return &runtime.Array{Elements: elements}
+}
+
+func (i *Interpreter) VisitAssignExpr(expr *ast.AssignExpr) runtime.Value {
+	value := i.evaluate(expr.Value)
+	if expr.Operator != "=" {
+		current, err := i.env.Get(expr.Name.Name)
+		i.check(err)
+		value, err = runtime.Binary(strings.TrimSuffix(expr.Operator, "="), current, value)
+		i.check(err)
+	}
+	i.check(i.env.Assign(expr.Name.Name, value))
+	return value
+}
+
+func (i *Interpreter) VisitMemberAssignExpr(expr *ast.MemberAssignExpr) runtime.Value {
+	object := i.evaluate(expr.Object)
+	value := i.evaluate(expr.Value)
+	if expr.Operator != "=" {
+		current, err := runtime.Property(object, expr.Property.Name)
+		i.check(err)
+		value, err = runtime.Binary(strings.TrimSuffix(expr.Operator, "="), current, value)
+		i.check(err)
+	}
+	i.check(runtime.SetProperty(object, expr.Property.Name, value))
+	return value
+}
+
+func (i *Interpreter) VisitBinaryExpr(expr *ast.BinaryExpr) runtime.Value {
+	left := i.evaluate(expr.Left)
+	right := i.evaluate(expr.Right)
+	result, err := runtime.Binary(expr.Operator, left, right)
+	i.check(err)
+	return result
+}
+
+func (i *Interpreter) VisitCallExpr(expr *ast.CallExpr) runtime.Value {
+	callee := i.evaluate(expr.Callee)
+	args := make([]runtime.Value, len(expr.Arguments))
+	for idx, arg := range expr.Arguments {
+		args[idx] = i.evaluate(arg)
+	}
+	return i.call(callee, args)
+}
+
+func (i *Interpreter) VisitGroupingExpr(expr *ast.GroupingExpr) runtime.Value {
+	return i.evaluate(expr.Expression)
+}
+
+func (i *Interpreter) VisitInstanceOfExpr(expr *ast.InstanceOfExpr) runtime.Value {
+	return runtime.Bool(runtime.IsInstance(i.evaluate(expr.Object), expr.Type.TypeName()))
+}
+
+func (i *Interpreter) VisitLambdaExpr(expr *ast.LambdaExpr) runtime.Value {
+	return &Function{
+		Params:  expr.Params,
+		Expr:    expr.Body,
+		Closure: i.env,
+	}
+}
+
+func (i *Interpreter) VisitLogicalExpr(expr *ast.LogicalExpr) runtime.Value {
+	left := i.evaluate(expr.Left)
+	switch expr.Operator {
+	case "??":
+		if _, ok := left.(runtime.Nil); !ok {
+			return left
+		}
+	case "||", "or":
+		if runtime.Truthy(left) {
+			return left
+		}
+	default:
+		if !runtime.Truthy(left) {
+			return left
+		}
+	}
+	return i.evaluate(expr.Right)
+}
+
+func (i *Interpreter) VisitNewExpr(expr *ast.NewExpr) runtime.Value {
+	i.fail("Undefined class '%s'", expr.ClassName)
+	return nil
+}
+
+func (i *Interpreter) VisitPostfixUnaryExpr(expr *ast.PostfixUnaryExpr) runtime.Value {
+	variable, ok := expr.Operand.(*ast.VariableExpr)
+	if !ok {
+		i.fail("Invalid operand for '%s'", expr.Operator)
+	}
+	old := i.evaluate(variable)
+	op := "+"
+	if expr.Operator == "--" {
+		op = "-"
+	}
+	updated, err := runtime.Arithmetic(op, old, runtime.Int(1))
+	i.check(err)
+	i.check(i.env.Assign(variable.Name.Name, updated))
+	return old
+}
+
+func (i *Interpreter) VisitSuperExpr(expr *ast.SuperExpr) runtime.Value {
+	i.fail("Cannot use 'super' outside of a class")
+	return nil
+}
+
+func (i *Interpreter) VisitThisExpr(expr *ast.ThisExpr) runtime.Value {
+	i.fail("Cannot use 'this' outside of a class")
+	return nil
+}
+
+func (i *Interpreter) VisitUnaryExpr(expr *ast.UnaryExpr) runtime.Value {
+	right := i.evaluate(expr.Right)
+	switch expr.Operator {
+	case "-":
+		result, err := runtime.Negate(right)
+		i.check(err)
+		return result
+	case "!", "not":
+		return runtime.Not(right)
+	case "~":
+		result, err := runtime.BitNot(right)
+		i.check(err)
+		return result
+	}
+	i.fail("Unknown unary operator '%s'", expr.Operator)
+	return nil
+}
+
+func (i *Interpreter) VisitVariableExpr(expr *ast.VariableExpr) runtime.Value {
+	value, err := i.env.Get(expr.Name.Name)
+	i.check(err)
+	return value
+}
+
+func (i *Interpreter) VisitMemberExpr(expr *ast.MemberExpr) runtime.Value {
+	object := i.evaluate(expr.Object)
+	if _, ok := object.(runtime.Nil); ok && expr.Optional {
+		return runtime.NilValue
+	}
+	value, err := runtime.Property(object, expr.Property.Name)
+	i.check(err)
+	return value
+}
+
+func (i *Interpreter) VisitRangeExpr(expr *ast.RangeExpr) runtime.Value {
+	start := i.evaluate(expr.Start)
+	end := i.evaluate(expr.Stop)
+	result, err := runtime.Range(start, end, expr.Inclusive)
+	i.check(err)
+	return result
+}
+
+func (i *Interpreter) VisitIdentifier(expr *ast.Identifier) runtime.Value {
+	value, err := i.env.Get(expr.Name)
+	i.check(err)
+	return value
+}
+
+func (i *Interpreter) VisitBadExpr(expr *ast.BadExpr) runtime.Value {
+	i.fail("cannot evaluate an expression with syntax errors")
+	return nil
+}
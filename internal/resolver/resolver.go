@@ -1 +1,487 @@
 package resolver
+
+import (
+	"fmt"
+
+	"dotFun/internal/ast"
+)
+
+type ResolveError struct {
+	Message string
+	Span    ast.Span
+}
+
+func (e *ResolveError) Error() string {
+	return e.Message
+}
+
+func (e *ResolveError) Position() (int, int) {
+	return e.Span.StartLine, e.Span.StartCol
+}
+
+func (e *ResolveError) Offsets() (int, int) {
+	return e.Span.StartOffset, e.Span.EndOffset
+}
+
+type functionKind int
+
+const (
+	functionNone functionKind = iota
+	functionPlain
+	functionLambda
+	functionMethod
+	functionConstructor
+)
+
+// Resolver performs the static checks that need scope information:
+// redeclarations, reads in initializers, writes to vals and misplaced
+// return/break/continue/this.
+type Resolver struct {
+	globals   *Scope
+	scopes    []*Scope
+	function  functionKind
+	loopDepth int
+	inClass   bool
+	errors    []error
+	// span is the node being resolved, used to position errors.
+	span ast.Span
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		globals: NewScope(),
+	}
+}
+
+// Resolve checks statements and returns every problem found, including
+// the constructs the back ends cannot run yet. The global scope is kept
+// between calls so the REPL can resolve line by line.
+func (r *Resolver) Resolve(statements []ast.Stmt) []error {
+	r.errors = nil
+	r.resolveStmts(statements)
+	r.checkSupported(statements)
+	return r.errors
+}
+
+func (r *Resolver) errorf(format string, args ...any) {
+	r.errors = append(r.errors, &ResolveError{Message: fmt.Sprintf(format, args...), Span: r.span})
+}
+
+func (r *Resolver) resolveStmts(statements []ast.Stmt) {
+	for _, stmt := range statements {
+		r.resolveStmt(stmt)
+	}
+}
+
+func (r *Resolver) resolveStmt(stmt ast.Stmt) {
+	if stmt != nil {
+		enclosing := r.span
+		r.span = stmt.SourceSpan()
+		ast.VisitStmt[struct{}](r, stmt)
+		r.span = enclosing
+	}
+}
+
+func (r *Resolver) resolveExpr(expr ast.Expr) {
+	if expr != nil {
+		enclosing := r.span
+		r.span = expr.SourceSpan()
+		ast.VisitExpr[struct{}](r, expr)
+		r.span = enclosing
+	}
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, NewScope())
+}
+
+func (r *Resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *Resolver) currentScope() *Scope {
+	if len(r.scopes) == 0 {
+		return r.globals
+	}
+	return r.scopes[len(r.scopes)-1]
+}
+
+func (r *Resolver) declare(name string, constant bool) *Symbol {
+	scope := r.currentScope()
+	if _, ok := scope.Lookup(name); ok && scope != r.globals {
+		r.errorf("Variable '%s' is already declared in this scope", name)
+	}
+	return scope.Declare(name, constant)
+}
+
+func (r *Resolver) lookup(name string) (*Symbol, bool) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if sym, ok := r.scopes[i].Lookup(name); ok {
+			return sym, true
+		}
+	}
+	return r.globals.Lookup(name)
+}
+
+func (r *Resolver) resolveVar(name string, declaredConstant bool, initializer ast.Expr) {
+	sym := r.declare(name, declaredConstant)
+	r.resolveExpr(initializer)
+	sym.Defined = true
+}
+
+func (r *Resolver) resolveFunction(params []ast.Parameter, body *ast.BlockStmt, kind functionKind) {
+	enclosingFunction, enclosingLoop := r.function, r.loopDepth
+	r.function, r.loopDepth = kind, 0
+
+	r.beginScope()
+	for _, param := range params {
+		r.declare(param.Name, false).Defined = true
+	}
+	r.resolveStmts(body.Statements)
+	r.endScope()
+
+	r.function, r.loopDepth = enclosingFunction, enclosingLoop
+}
+
+func (r *Resolver) checkAssignable(name string) {
+	if sym, ok := r.lookup(name); ok && sym.Constant {
+		r.errorf("Cannot assign to val '%s'", name)
+	}
+}
+
+func (r *Resolver) VisitBlockStmt(stmt *ast.BlockStmt) struct{} {
+	r.beginScope()
+	r.resolveStmts(stmt.Statements)
+	r.endScope()
+	return struct{}{}
+}
+
+func (r *Resolver) VisitBreakStmt(stmt *ast.BreakStmt) struct{} {
+	if r.loopDepth == 0 {
+		r.errorf("Cannot use 'break' outside of a loop")
+	}
+	return struct{}{}
+}
+
+func (r *Resolver) VisitContinueStmt(stmt *ast.ContinueStmt) struct{} {
+	if r.loopDepth == 0 {
+		r.errorf("Cannot use 'continue' outside of a loop")
+	}
+	return struct{}{}
+}
+
+func (r *Resolver) VisitExpressionStmt(stmt *ast.ExpressionStmt) struct{} {
+	r.resolveExpr(stmt.Expression)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitReturnStmt(stmt *ast.ReturnStmt) struct{} {
+	if r.function == functionNone {
+		r.errorf("Cannot return from top-level code")
+	}
+	if r.function == functionConstructor && stmt.Value != nil {
+		r.errorf("Cannot return a value from a constructor")
+	}
+	r.resolveExpr(stmt.Value)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitThrowStmt(stmt *ast.ThrowStmt) struct{} {
+	r.resolveExpr(stmt.Value)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitTryStmt(stmt *ast.TryStmt) struct{} {
+	r.VisitBlockStmt(stmt.TryBlock)
+	if stmt.CatchBlock != nil {
+		r.beginScope()
+		if stmt.CatchVarName != "" {
+			r.declare(stmt.CatchVarName, false).Defined = true
+		}
+		r.resolveStmts(stmt.CatchBlock.Statements)
+		r.endScope()
+	}
+	if stmt.FinallyBlock != nil {
+		r.VisitBlockStmt(stmt.FinallyBlock)
+	}
+	return struct{}{}
+}
+
+func (r *Resolver) VisitIfStmt(stmt *ast.IfStmt) struct{} {
+	r.resolveExpr(stmt.Condition)
+	r.VisitBlockStmt(stmt.ThenBlock)
+	r.resolveStmt(stmt.ElseBlock)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitWhileStmt(stmt *ast.WhileStmt) struct{} {
+	r.resolveExpr(stmt.Condition)
+	r.loopDepth++
+	r.VisitBlockStmt(stmt.Body)
+	r.loopDepth--
+	return struct{}{}
+}
+
+func (r *Resolver) VisitForStmt(stmt *ast.ForStmt) struct{} {
+	r.beginScope()
+	r.resolveStmt(stmt.Init)
+	r.resolveExpr(stmt.Condition)
+	r.resolveStmt(stmt.Post)
+	r.loopDepth++
+	r.VisitBlockStmt(stmt.Body)
+	r.loopDepth--
+	r.endScope()
+	return struct{}{}
+}
+
+func (r *Resolver) VisitSwitchCase(stmt *ast.SwitchCase) struct{} {
+	for _, expr := range stmt.CaseExprs {
+		r.resolveExpr(expr)
+	}
+	r.VisitBlockStmt(stmt.Body)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitSwitchStmt(stmt *ast.SwitchStmt) struct{} {
+	r.resolveExpr(stmt.Expr)
+	for _, c := range stmt.Cases {
+		r.VisitSwitchCase(c)
+	}
+	if stmt.Default != nil {
+		r.VisitBlockStmt(stmt.Default)
+	}
+	return struct{}{}
+}
+
+func (r *Resolver) VisitValStmt(stmt *ast.ValStmt) struct{} {
+	r.resolveVar(stmt.Name, true, stmt.Initializer)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitLetStmt(stmt *ast.LetStmt) struct{} {
+	r.resolveVar(stmt.Name, false, stmt.Initializer)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitGlobalStmt(stmt *ast.GlobalStmt) struct{} {
+	r.resolveExpr(stmt.Initializer)
+	r.globals.Declare(stmt.Name, false).Defined = true
+	return struct{}{}
+}
+
+func (r *Resolver) VisitFunctionStmt(stmt *ast.FunctionStmt) struct{} {
+	r.declare(stmt.Name, false).Defined = true
+	kind := functionPlain
+	if r.inClass {
+		kind = functionMethod
+	}
+	r.resolveFunction(stmt.Parameters, stmt.Body, kind)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitClassStmt(stmt *ast.ClassStmt) struct{} {
+	r.declare(stmt.Name, false).Defined = true
+	enclosing := r.inClass
+	r.inClass = true
+	r.beginScope()
+	for _, member := range stmt.Members {
+		r.resolveStmt(member)
+	}
+	r.endScope()
+	r.inClass = enclosing
+	return struct{}{}
+}
+
+func (r *Resolver) VisitConstructorStmt(stmt *ast.ConstructorStmt) struct{} {
+	r.resolveFunction(stmt.Parameters, stmt.Body, functionConstructor)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitInterfaceStmt(stmt *ast.InterfaceStmt) struct{} {
+	r.declare(stmt.Name, false).Defined = true
+	return struct{}{}
+}
+
+func (r *Resolver) VisitStructStmt(stmt *ast.StructStmt) struct{} {
+	r.declare(stmt.Name, false).Defined = true
+	return struct{}{}
+}
+
+func (r *Resolver) VisitEnumStmt(stmt *ast.EnumStmt) struct{} {
+	r.declare(stmt.Name, true).Defined = true
+	return struct{}{}
+}
+
+func (r *Resolver) VisitDataStmt(stmt *ast.DataStmt) struct{} {
+	r.declare(stmt.Name, false).Defined = true
+	return struct{}{}
+}
+
+func (r *Resolver) VisitImportStmt(stmt *ast.ImportStmt) struct{} {
+	return struct{}{}
+}
+
+func (r *Resolver) VisitExportStmt(stmt *ast.ExportStmt) struct{} {
+	return struct{}{}
+}
+
+func (r *Resolver) VisitBadStmt(stmt *ast.BadStmt) struct{} {
+	return struct{}{}
+}
+
+func (r *Resolver) VisitIntLiteral(expr *ast.IntLiteral) struct{} {
+	return struct{}{}
+}
+
+func (r *Resolver) VisitFloatLiteral(expr *ast.FloatLiteral) struct{} {
+	return struct{}{}
+}
+
+func (r *Resolver) VisitStringLiteral(expr *ast.StringLiteral) struct{} {
+	return struct{}{}
+}
+
+func (r *Resolver) VisitInterpolatedString(expr *ast.InterpolatedString) struct{} {
+	for _, part := range expr.Parts {
+		r.resolveExpr(part)
+	}
+	return struct{}{}
+}
+
+func (r *Resolver) VisitCharLiteral(expr *ast.CharLiteral) struct{} {
+	return struct{}{}
+}
+
+func (r *Resolver) VisitBoolLiteral(expr *ast.BoolLiteral) struct{} {
+	return struct{}{}
+}
+
+func (r *Resolver) VisitNilLiteral(expr *ast.NilLiteral) struct{} {
+	return struct{}{}
+}
+
+func (r *Resolver) VisitArrayLiteral(expr *ast.ArrayLiteral) struct{} {
+	for _, element := range expr.Elements {
+		r.resolveExpr(element)
+	}
+	return struct{}{}
+}
+
+func (r *Resolver) VisitAssignExpr(expr *ast.AssignExpr) struct{} {
+	r.resolveExpr(expr.Value)
+	r.checkAssignable(expr.Name.Name)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitMemberAssignExpr(expr *ast.MemberAssignExpr) struct{} {
+	r.resolveExpr(expr.Object)
+	r.resolveExpr(expr.Value)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitBinaryExpr(expr *ast.BinaryExpr) struct{} {
+	r.resolveExpr(expr.Left)
+	r.resolveExpr(expr.Right)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitCallExpr(expr *ast.CallExpr) struct{} {
+	r.resolveExpr(expr.Callee)
+	for _, arg := range expr.Arguments {
+		r.resolveExpr(arg)
+	}
+	return struct{}{}
+}
+
+func (r *Resolver) VisitGroupingExpr(expr *ast.GroupingExpr) struct{} {
+	r.resolveExpr(expr.Expression)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitInstanceOfExpr(expr *ast.InstanceOfExpr) struct{} {
+	r.resolveExpr(expr.Object)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitLambdaExpr(expr *ast.LambdaExpr) struct{} {
+	enclosingFunction, enclosingLoop := r.function, r.loopDepth
+	r.function, r.loopDepth = functionLambda, 0
+
+	r.beginScope()
+	for _, param := range expr.Params {
+		r.declare(param, false).Defined = true
+	}
+	r.resolveExpr(expr.Body)
+	r.endScope()
+
+	r.function, r.loopDepth = enclosingFunction, enclosingLoop
+	return struct{}{}
+}
+
+func (r *Resolver) VisitRangeExpr(expr *ast.RangeExpr) struct{} {
+	r.resolveExpr(expr.Start)
+	r.resolveExpr(expr.Stop)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitLogicalExpr(expr *ast.LogicalExpr) struct{} {
+	r.resolveExpr(expr.Left)
+	r.resolveExpr(expr.Right)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitNewExpr(expr *ast.NewExpr) struct{} {
+	for _, arg := range expr.Args {
+		r.resolveExpr(arg)
+	}
+	return struct{}{}
+}
+
+func (r *Resolver) VisitPostfixUnaryExpr(expr *ast.PostfixUnaryExpr) struct{} {
+	r.resolveExpr(expr.Operand)
+	if v, ok := expr.Operand.(*ast.VariableExpr); ok {
+		r.checkAssignable(v.Name.Name)
+	}
+	return struct{}{}
+}
+
+func (r *Resolver) VisitSuperExpr(expr *ast.SuperExpr) struct{} {
+	if !r.inClass {
+		r.errorf("Cannot use 'super' outside of a class")
+	}
+	return struct{}{}
+}
+
+func (r *Resolver) VisitThisExpr(expr *ast.ThisExpr) struct{} {
+	if !r.inClass {
+		r.errorf("Cannot use 'this' outside of a class")
+	}
+	return struct{}{}
+}
+
+func (r *Resolver) VisitUnaryExpr(expr *ast.UnaryExpr) struct{} {
+	r.resolveExpr(expr.Right)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitVariableExpr(expr *ast.VariableExpr) struct{} {
+	if len(r.scopes) > 0 {
+		if sym, ok := r.currentScope().Lookup(expr.Name.Name); ok && !sym.Defined {
+			r.errorf("Cannot read local variable '%s' in its own initializer", expr.Name.Name)
+		}
+	}
+	return struct{}{}
+}
+
+func (r *Resolver) VisitMemberExpr(expr *ast.MemberExpr) struct{} {
+	r.resolveExpr(expr.Object)
+	return struct{}{}
+}
+
+func (r *Resolver) VisitIdentifier(expr *ast.Identifier) struct{} {
+	return struct{}{}
+}
+
+func (r *Resolver) VisitBadExpr(expr *ast.BadExpr) struct{} {
+	return struct{}{}
+}
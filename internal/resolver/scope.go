@@ -1 +1,26 @@
 package resolver
+
+type Symbol struct {
+	Name     string
+	Constant bool
+	Defined  bool
+}
+
+type Scope struct {
+	symbols map[string]*Symbol
+}
+
+func NewScope() *Scope {
+	return &Scope{symbols: map[string]*Symbol{}}
+}
+
+func (s *Scope) Declare(name string, constant bool) *Symbol {
+	sym := &Symbol{Name: name, Constant: constant}
+	s.symbols[name] = sym
+	return sym
+}
+
+func (s *Scope) Lookup(name string) (*Symbol, bool) {
+	sym, ok := s.symbols[name]
+	return sym, ok
+}
@@ -0,0 +1,52 @@
+package resolver
+
+import "dotFun/internal/ast"
+
+// unsupported names the constructs that parse but that neither the
+// interpreter nor the bytecode VM can run yet.
+func unsupported(node ast.Node) string {
+	switch node.(type) {
+	case *ast.TryStmt:
+		return "try statements"
+	case *ast.SwitchStmt:
+		return "turn statements"
+	case *ast.ClassStmt:
+		return "class declarations"
+	case *ast.ConstructorStmt:
+		return "constructors"
+	case *ast.InterfaceStmt:
+		return "interface declarations"
+	case *ast.StructStmt:
+		return "struct declarations"
+	case *ast.EnumStmt:
+		return "enum declarations"
+	case *ast.DataStmt:
+		return "data declarations"
+	case *ast.ImportStmt:
+		return "imports"
+	case *ast.ExportStmt:
+		return "exports"
+	case *ast.NewExpr:
+		return "new expressions"
+	}
+	return ""
+}
+
+// checkSupported reports each construct in statements that the back ends
+// cannot run, so a program that passes the checks runs the same way on
+// both. Nothing inside an unsupported construct is reported.
+func (r *Resolver) checkSupported(statements []ast.Stmt) {
+	for _, stmt := range statements {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if n == nil {
+				return false
+			}
+			what := unsupported(n)
+			if what == "" {
+				return true
+			}
+			r.errors = append(r.errors, &ResolveError{Message: what + " are not supported yet", Span: n.SourceSpan()})
+			return false
+		})
+	}
+}
@@ -1 +1,24 @@
 package runtime
+
+type Callable interface {
+	Value
+	Arity() int
+}
+
+// Variadic is returned by Arity for callables that accept any number of
+// arguments.
+const Variadic = -1
+
+type NativeFunction struct {
+	Name   string
+	Params int
+	Fn     func(args []Value) (Value, error)
+}
+
+func (n *NativeFunction) Type() string   { return "Function" }
+func (n *NativeFunction) String() string { return "<native fun " + n.Name + ">" }
+func (n *NativeFunction) Arity() int     { return n.Params }
+
+func (n *NativeFunction) Call(args []Value) (Value, error) {
+	return n.Fn(args)
+}
@@ -1 +1,50 @@
 package runtime
+
+import "fmt"
+
+type binding struct {
+	value    Value
+	constant bool
+}
+
+type Environment struct {
+	values    map[string]*binding
+	enclosing *Environment
+}
+
+func NewEnvironment(enclosing *Environment) *Environment {
+	return &Environment{
+		values:    map[string]*binding{},
+		enclosing: enclosing,
+	}
+}
+
+func (e *Environment) Enclosing() *Environment {
+	return e.enclosing
+}
+
+func (e *Environment) Define(name string, value Value, constant bool) {
+	e.values[name] = &binding{value: value, constant: constant}
+}
+
+func (e *Environment) Get(name string) (Value, error) {
+	for env := e; env != nil; env = env.enclosing {
+		if b, ok := env.values[name]; ok {
+			return b.value, nil
+		}
+	}
+	return nil, fmt.Errorf("Undefined variable '%s'", name)
+}
+
+func (e *Environment) Assign(name string, value Value) error {
+	for env := e; env != nil; env = env.enclosing {
+		if b, ok := env.values[name]; ok {
+			if b.constant {
+				return fmt.Errorf("Cannot assign to constant '%s'", name)
+			}
+			b.value = value
+			return nil
+		}
+	}
+	return fmt.Errorf("Undefined variable '%s'", name)
+}
@@ -0,0 +1,21 @@
+package runtime
+
+type Error struct {
+	Message     string
+	Line        int
+	Column      int
+	StartOffset int
+	EndOffset   int
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Position() (int, int) {
+	return e.Line, e.Column
+}
+
+func (e *Error) Offsets() (int, int) {
+	return e.StartOffset, e.EndOffset
+}
@@ -0,0 +1,315 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+func Add(a, b Value) (Value, error) {
+	switch a := a.(type) {
+	case Int:
+		switch b := b.(type) {
+		case Int:
+			return a + b, nil
+		case Float:
+			return Float(a) + b, nil
+		}
+	case Float:
+		switch b := b.(type) {
+		case Int:
+			return a + Float(b), nil
+		case Float:
+			return a + b, nil
+		}
+	case Char:
+		if b, ok := b.(Int); ok {
+			return shiftChar(a, int64(b))
+		}
+	case String:
+		return a + String(b.String()), nil
+	case *Array:
+		if b, ok := b.(*Array); ok {
+			elements := make([]Value, 0, len(a.Elements)+len(b.Elements))
+			elements = append(elements, a.Elements...)
+			elements = append(elements, b.Elements...)
+			return &Array{Elements: elements}, nil
+		}
+	}
+	if s, ok := b.(String); ok {
+		return String(a.String()) + s, nil
+	}
+	return nil, operandError("+", a, b)
+}
+
+func Arithmetic(op string, a, b Value) (Value, error) {
+	if op == "+" {
+		return Add(a, b)
+	}
+
+	if ac, ok := a.(Char); ok && op == "-" {
+		switch b := b.(type) {
+		case Char:
+			return Int(ac - b), nil
+		case Int:
+			return shiftChar(ac, -int64(b))
+		}
+	}
+
+	if ai, ok := a.(Int); ok {
+		if bi, ok := b.(Int); ok {
+			switch op {
+			case "-":
+				return ai - bi, nil
+			case "*":
+				return ai * bi, nil
+			case "/":
+				if bi == 0 {
+					return nil, fmt.Errorf("Division by zero")
+				}
+				return ai / bi, nil
+			case "%":
+				if bi == 0 {
+					return nil, fmt.Errorf("Division by zero")
+				}
+				return ai % bi, nil
+			}
+		}
+	}
+
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if !aok || !bok {
+		return nil, operandError(op, a, b)
+	}
+	switch op {
+	case "-":
+		return af - bf, nil
+	case "*":
+		return af * bf, nil
+	case "/":
+		return af / bf, nil
+	case "%":
+		return Float(math.Mod(float64(af), float64(bf))), nil
+	}
+	return nil, fmt.Errorf("Unknown operator '%s'", op)
+}
+
+func Bitwise(op string, a, b Value) (Value, error) {
+	ai, aok := a.(Int)
+	bi, bok := b.(Int)
+	if !aok || !bok {
+		return nil, operandError(op, a, b)
+	}
+	switch op {
+	case "&":
+		return ai & bi, nil
+	case "|":
+		return ai | bi, nil
+	case "^":
+		return ai ^ bi, nil
+	case "<<":
+		if bi < 0 {
+			return nil, fmt.Errorf("Negative shift count %d", bi)
+		}
+		return ai << uint64(bi), nil
+	case ">>":
+		if bi < 0 {
+			return nil, fmt.Errorf("Negative shift count %d", bi)
+		}
+		return ai >> uint64(bi), nil
+	}
+	return nil, fmt.Errorf("Unknown operator '%s'", op)
+}
+
+func Compare(op string, a, b Value) (Value, error) {
+	if ac, ok := a.(Char); ok {
+		if bc, ok := b.(Char); ok {
+			a, b = Int(ac), Int(bc)
+		}
+	}
+	if as, ok := a.(String); ok {
+		if bs, ok := b.(String); ok {
+			switch op {
+			case "<":
+				return Bool(as < bs), nil
+			case "<=":
+				return Bool(as <= bs), nil
+			case ">":
+				return Bool(as > bs), nil
+			case ">=":
+				return Bool(as >= bs), nil
+			}
+		}
+	}
+
+	if ai, ok := a.(Int); ok {
+		if bi, ok := b.(Int); ok {
+			switch op {
+			case "<":
+				return Bool(ai < bi), nil
+			case "<=":
+				return Bool(ai <= bi), nil
+			case ">":
+				return Bool(ai > bi), nil
+			case ">=":
+				return Bool(ai >= bi), nil
+			}
+		}
+	}
+
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if !aok || !bok {
+		return nil, operandError(op, a, b)
+	}
+	switch op {
+	case "<":
+		return Bool(af < bf), nil
+	case "<=":
+		return Bool(af <= bf), nil
+	case ">":
+		return Bool(af > bf), nil
+	case ">=":
+		return Bool(af >= bf), nil
+	}
+	return nil, fmt.Errorf("Unknown operator '%s'", op)
+}
+
+// Binary evaluates every non short-circuiting binary operator.
+func Binary(op string, a, b Value) (Value, error) {
+	switch op {
+	case "+", "-", "*", "/", "%":
+		return Arithmetic(op, a, b)
+	case "**":
+		return Power(a, b)
+	case "&", "|", "^", "<<", ">>":
+		return Bitwise(op, a, b)
+	case "<", "<=", ">", ">=":
+		return Compare(op, a, b)
+	case "==":
+		return Bool(Equal(a, b)), nil
+	case "!=":
+		return Bool(!Equal(a, b)), nil
+	}
+	return nil, fmt.Errorf("Unknown operator '%s'", op)
+}
+
+// Power raises a to b. Two Ints give an Int unless the exponent is
+// negative; anything else is computed in floating point.
+func Power(a, b Value) (Value, error) {
+	if base, ok := a.(Int); ok {
+		if exp, ok := b.(Int); ok && exp >= 0 {
+			result := Int(1)
+			for ; exp > 0; exp >>= 1 {
+				if exp&1 == 1 {
+					result *= base
+				}
+				base *= base
+			}
+			return result, nil
+		}
+	}
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if !aok || !bok {
+		return nil, operandError("**", a, b)
+	}
+	return Float(math.Pow(float64(af), float64(bf))), nil
+}
+
+// maxRangeLength bounds the arrays built by Range.
+const maxRangeLength = 1 << 24
+
+// Range builds the array start..end, or start..<end when inclusive is
+// false. Both ends must be Ints or both Chars.
+func Range(start, end Value, inclusive bool) (Value, error) {
+	op := "..<"
+	if inclusive {
+		op = ".."
+	}
+	var from, to int64
+	switch s := start.(type) {
+	case Int:
+		e, ok := end.(Int)
+		if !ok {
+			return nil, operandError(op, start, end)
+		}
+		from, to = int64(s), int64(e)
+	case Char:
+		e, ok := end.(Char)
+		if !ok {
+			return nil, operandError(op, start, end)
+		}
+		from, to = int64(s), int64(e)
+	default:
+		return nil, operandError(op, start, end)
+	}
+	if !inclusive {
+		to--
+	}
+	if to < from {
+		return &Array{Elements: []Value{}}, nil
+	}
+	if uint64(to-from) >= maxRangeLength {
+		return nil, fmt.Errorf("Range %s%s%s is too large", start, op, end)
+	}
+
+	elements := make([]Value, 0, to-from+1)
+	_, isChar := start.(Char)
+	for n := from; ; n++ {
+		if isChar {
+			elements = append(elements, Char(n))
+		} else {
+			elements = append(elements, Int(n))
+		}
+		if n == to {
+			break
+		}
+	}
+	return &Array{Elements: elements}, nil
+}
+
+func Negate(v Value) (Value, error) {
+	switch v := v.(type) {
+	case Int:
+		return -v, nil
+	case Float:
+		return -v, nil
+	}
+	return nil, fmt.Errorf("Operand of '-' must be a number, got %s", v.Type())
+}
+
+func Not(v Value) Value {
+	return Bool(!Truthy(v))
+}
+
+func BitNot(v Value) (Value, error) {
+	if i, ok := v.(Int); ok {
+		return ^i, nil
+	}
+	return nil, fmt.Errorf("Operand of '~' must be an Int, got %s", v.Type())
+}
+
+// shiftChar offsets a code point, as in 'a' + 1.
+func shiftChar(c Char, delta int64) (Value, error) {
+	r := int64(c) + delta
+	if r < 0 || r > utf8.MaxRune || !utf8.ValidRune(rune(r)) {
+		return nil, fmt.Errorf("Code point %d is not a valid Char", r)
+	}
+	return Char(r), nil
+}
+
+func toFloat(v Value) (Float, bool) {
+	switch v := v.(type) {
+	case Int:
+		return Float(v), true
+	case Float:
+		return v, true
+	}
+	return 0, false
+}
+
+func operandError(op string, a, b Value) error {
+	return fmt.Errorf("Unsupported operand types for '%s': %s and %s", op, a.Type(), b.Type())
+}
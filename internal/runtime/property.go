@@ -0,0 +1,50 @@
+package runtime
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Property reads a built-in property of a value, such as "abc".length.
+func Property(object Value, name string) (Value, error) {
+	switch object := object.(type) {
+	case String:
+		if name == "length" {
+			return Int(utf8.RuneCountInString(string(object))), nil
+		}
+	case *Array:
+		if name == "length" {
+			return Int(len(object.Elements)), nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no property '%s'", object.Type(), name)
+}
+
+// SetProperty assigns a built-in property of a value. Only the length of
+// an array can be assigned: it drops the elements past the new length, or
+// adds nils up to it.
+func SetProperty(object Value, name string, value Value) error {
+	if _, err := Property(object, name); err != nil {
+		return err
+	}
+	array, ok := object.(*Array)
+	if !ok {
+		return fmt.Errorf("%s property '%s' is read-only", object.Type(), name)
+	}
+	length, ok := value.(Int)
+	if !ok {
+		return fmt.Errorf("Array length must be an Int, got %s", value.Type())
+	}
+	if length < 0 {
+		return fmt.Errorf("Negative array length %d", length)
+	}
+	if int(length) <= len(array.Elements) {
+		clear(array.Elements[length:])
+		array.Elements = array.Elements[:length]
+		return nil
+	}
+	for len(array.Elements) < int(length) {
+		array.Elements = append(array.Elements, NilValue)
+	}
+	return nil
+}
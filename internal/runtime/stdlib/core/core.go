@@ -0,0 +1,13 @@
+package core
+
+import "dotFun/internal/runtime"
+
+// Natives returns the builtin functions every dotFun program starts with.
+func Natives() []*runtime.NativeFunction {
+	var natives []*runtime.NativeFunction
+	natives = append(natives, printNatives()...)
+	natives = append(natives, mathNatives()...)
+	natives = append(natives, timeNatives()...)
+	natives = append(natives, systemNatives()...)
+	return natives
+}
@@ -1 +1,63 @@
 package core
+
+import (
+	"fmt"
+	"math"
+
+	"dotFun/internal/runtime"
+)
+
+func mathNatives() []*runtime.NativeFunction {
+	return []*runtime.NativeFunction{
+		{Name: "abs", Params: 1, Fn: nativeAbs},
+		{Name: "sqrt", Params: 1, Fn: floatFunc("sqrt", math.Sqrt)},
+		{Name: "floor", Params: 1, Fn: floatFunc("floor", math.Floor)},
+		{Name: "ceil", Params: 1, Fn: floatFunc("ceil", math.Ceil)},
+		{Name: "pow", Params: 2, Fn: nativePow},
+	}
+}
+
+func toFloat(name string, v runtime.Value) (float64, error) {
+	switch v := v.(type) {
+	case runtime.Int:
+		return float64(v), nil
+	case runtime.Float:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("%s expects a number, got %s", name, v.Type())
+}
+
+func floatFunc(name string, fn func(float64) float64) func(args []runtime.Value) (runtime.Value, error) {
+	return func(args []runtime.Value) (runtime.Value, error) {
+		f, err := toFloat(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return runtime.Float(fn(f)), nil
+	}
+}
+
+func nativeAbs(args []runtime.Value) (runtime.Value, error) {
+	switch v := args[0].(type) {
+	case runtime.Int:
+		if v < 0 {
+			return -v, nil
+		}
+		return v, nil
+	case runtime.Float:
+		return runtime.Float(math.Abs(float64(v))), nil
+	}
+	return nil, fmt.Errorf("abs expects a number, got %s", args[0].Type())
+}
+
+func nativePow(args []runtime.Value) (runtime.Value, error) {
+	x, err := toFloat("pow", args[0])
+	if err != nil {
+		return nil, err
+	}
+	y, err := toFloat("pow", args[1])
+	if err != nil {
+		return nil, err
+	}
+	return runtime.Float(math.Pow(x, y)), nil
+}
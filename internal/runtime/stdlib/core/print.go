@@ -1 +1,41 @@
 package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"dotFun/internal/runtime"
+)
+
+var Stdout io.Writer = os.Stdout
+
+func printNatives() []*runtime.NativeFunction {
+	return []*runtime.NativeFunction{
+		{Name: "print", Params: runtime.Variadic, Fn: nativePrint(false)},
+		{Name: "println", Params: runtime.Variadic, Fn: nativePrint(true)},
+		{Name: "str", Params: 1, Fn: nativeStr},
+	}
+}
+
+func nativePrint(newline bool) func(args []runtime.Value) (runtime.Value, error) {
+	return func(args []runtime.Value) (runtime.Value, error) {
+		parts := make([]string, len(args))
+		for i, arg := range args {
+			parts[i] = arg.String()
+		}
+		text := strings.Join(parts, " ")
+		if newline {
+			text += "\n"
+		}
+		if _, err := fmt.Fprint(Stdout, text); err != nil {
+			return nil, err
+		}
+		return runtime.NilValue, nil
+	}
+}
+
+func nativeStr(args []runtime.Value) (runtime.Value, error) {
+	return runtime.String(args[0].String()), nil
+}
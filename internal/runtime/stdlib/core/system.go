@@ -1 +1,65 @@
 package core
+
+import (
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"dotFun/internal/runtime"
+)
+
+// Exit is called by the exit native; embedders that must not terminate the
+// process can replace it.
+var Exit = os.Exit
+
+func systemNatives() []*runtime.NativeFunction {
+	return []*runtime.NativeFunction{
+		{Name: "exit", Params: 1, Fn: nativeExit},
+		{Name: "typeof", Params: 1, Fn: nativeTypeof},
+		{Name: "len", Params: 1, Fn: nativeLen},
+		{Name: "ord", Params: 1, Fn: nativeOrd},
+		{Name: "chr", Params: 1, Fn: nativeChr},
+	}
+}
+
+func nativeExit(args []runtime.Value) (runtime.Value, error) {
+	code, ok := args[0].(runtime.Int)
+	if !ok {
+		return nil, fmt.Errorf("exit expects an Int, got %s", args[0].Type())
+	}
+	Exit(int(code))
+	return runtime.NilValue, nil
+}
+
+func nativeTypeof(args []runtime.Value) (runtime.Value, error) {
+	return runtime.String(args[0].Type()), nil
+}
+
+func nativeLen(args []runtime.Value) (runtime.Value, error) {
+	switch v := args[0].(type) {
+	case runtime.String:
+		return runtime.Int(len([]rune(string(v)))), nil
+	case *runtime.Array:
+		return runtime.Int(len(v.Elements)), nil
+	}
+	return nil, fmt.Errorf("len expects a String or Array, got %s", args[0].Type())
+}
+
+func nativeOrd(args []runtime.Value) (runtime.Value, error) {
+	c, ok := args[0].(runtime.Char)
+	if !ok {
+		return nil, fmt.Errorf("ord expects a Char, got %s", args[0].Type())
+	}
+	return runtime.Int(c), nil
+}
+
+func nativeChr(args []runtime.Value) (runtime.Value, error) {
+	code, ok := args[0].(runtime.Int)
+	if !ok {
+		return nil, fmt.Errorf("chr expects an Int, got %s", args[0].Type())
+	}
+	if code < 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+		return nil, fmt.Errorf("chr: %d is not a valid code point", code)
+	}
+	return runtime.Char(code), nil
+}
@@ -1 +1,24 @@
 package core
+
+import (
+	"time"
+
+	"dotFun/internal/runtime"
+)
+
+var startTime = time.Now()
+
+func timeNatives() []*runtime.NativeFunction {
+	return []*runtime.NativeFunction{
+		{Name: "clock", Params: 0, Fn: nativeClock},
+		{Name: "now", Params: 0, Fn: nativeNow},
+	}
+}
+
+func nativeClock(args []runtime.Value) (runtime.Value, error) {
+	return runtime.Float(time.Since(startTime).Seconds()), nil
+}
+
+func nativeNow(args []runtime.Value) (runtime.Value, error) {
+	return runtime.Int(time.Now().UnixMilli()), nil
+}
@@ -1 +1,124 @@
 package runtime
+
+import (
+	"strconv"
+	"strings"
+)
+
+type Value interface {
+	Type() string
+	String() string
+}
+
+type Nil struct{}
+
+func (Nil) Type() string   { return "Nil" }
+func (Nil) String() string { return "nil" }
+
+var NilValue Value = Nil{}
+
+type Int int64
+
+func (Int) Type() string     { return "Int" }
+func (i Int) String() string { return strconv.FormatInt(int64(i), 10) }
+
+type Float float64
+
+func (Float) Type() string { return "Float" }
+func (f Float) String() string {
+	s := strconv.FormatFloat(float64(f), 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eEn") {
+		s += ".0"
+	}
+	return s
+}
+
+type String string
+
+func (String) Type() string     { return "String" }
+func (s String) String() string { return string(s) }
+
+// Char is a single Unicode code point.
+type Char rune
+
+func (Char) Type() string     { return "Char" }
+func (c Char) String() string { return string(rune(c)) }
+
+type Bool bool
+
+func (Bool) Type() string { return "Bool" }
+func (b Bool) String() string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+type Array struct {
+	Elements []Value
+}
+
+func (a *Array) Type() string { return "Array" }
+func (a *Array) String() string {
+	parts := make([]string, len(a.Elements))
+	for i, e := range a.Elements {
+		parts[i] = Inspect(e)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// Inspect renders v the way it would be written in source, quoting strings.
+func Inspect(v Value) string {
+	switch v := v.(type) {
+	case String:
+		return strconv.Quote(string(v))
+	case Char:
+		return strconv.QuoteRune(rune(v))
+	}
+	return v.String()
+}
+
+func Truthy(v Value) bool {
+	switch v := v.(type) {
+	case nil, Nil:
+		return false
+	case Bool:
+		return bool(v)
+	default:
+		return true
+	}
+}
+
+func Equal(a, b Value) bool {
+	switch a := a.(type) {
+	case Int:
+		switch b := b.(type) {
+		case Int:
+			return a == b
+		case Float:
+			return Float(a) == b
+		}
+		return false
+	case Float:
+		switch b := b.(type) {
+		case Int:
+			return a == Float(b)
+		case Float:
+			return a == b
+		}
+		return false
+	case String, Char, Bool, Nil:
+		return a == b
+	default:
+		return a == b
+	}
+}
+
+// IsInstance reports whether v satisfies the named type, as used by
+// instanceof.
+func IsInstance(v Value, typeName string) bool {
+	if typeName == "Any" {
+		return true
+	}
+	return v.Type() == typeName
+}
@@ -0,0 +1,70 @@
+package vm
+
+import (
+	"dotFun/internal/bytecode"
+	"dotFun/internal/runtime"
+)
+
+// Closure is a function together with the variables it captured from the
+// functions enclosing it.
+type Closure struct {
+	Function *bytecode.Function
+	Upvalues []*Upvalue
+}
+
+func (c *Closure) Type() string   { return "Function" }
+func (c *Closure) String() string { return c.Function.String() }
+func (c *Closure) Arity() int     { return c.Function.Arity() }
+
+// Upvalue is a captured variable. While it is open the variable is still
+// in its stack slot; once that goes out of scope the upvalue is closed and
+// holds the value itself.
+type Upvalue struct {
+	slot   int
+	open   bool
+	closed runtime.Value
+}
+
+func (u *Upvalue) get(stack *Stack) runtime.Value {
+	if u.open {
+		return stack.Get(u.slot)
+	}
+	return u.closed
+}
+
+func (u *Upvalue) set(stack *Stack, v runtime.Value) {
+	if u.open {
+		stack.Set(u.slot, v)
+		return
+	}
+	u.closed = v
+}
+
+// captureUpvalue returns the open upvalue for slot, creating it unless a
+// closure has already captured the slot, so closures share the variable.
+func (vm *VM) captureUpvalue(slot int) *Upvalue {
+	for _, u := range vm.openUpvalues {
+		if u.slot == slot {
+			return u
+		}
+	}
+	u := &Upvalue{slot: slot, open: true}
+	vm.openUpvalues = append(vm.openUpvalues, u)
+	return u
+}
+
+// closeUpvalues closes the open upvalues for slot from and those above it,
+// copying each variable off the stack before the slots are popped.
+func (vm *VM) closeUpvalues(from int) {
+	open := vm.openUpvalues[:0]
+	for _, u := range vm.openUpvalues {
+		if u.slot < from {
+			open = append(open, u)
+			continue
+		}
+		u.closed = vm.stack.Get(u.slot)
+		u.open = false
+	}
+	clear(vm.openUpvalues[len(open):])
+	vm.openUpvalues = open
+}
@@ -0,0 +1,120 @@
+package vm_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"dotFun/internal/bytecode"
+	"dotFun/internal/vm"
+)
+
+// TestClosures checks that functions capturing the locals of enclosing
+// functions behave the same on both back ends.
+func TestClosures(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"counter", `fun counter() {
+  let n = 0
+  return () -> n += 1
+}
+let a = counter()
+let b = counter()
+a()
+println(a(), b(), a())`, "2 1 3"},
+		{"shared", `let get = nil
+let set = nil
+fun pair() {
+  let x = 1
+  get = () -> x
+  set = v -> x = v
+  x = 2
+}
+pair()
+println(get())
+set(5)
+println(get())`, "2\n5"},
+		{"nested", `fun outer() {
+  let a = 10
+  fun middle() {
+    fun inner() { return a + 1 }
+    return inner
+  }
+  return middle()
+}
+println(outer()())`, "11"},
+		{"block", `let f = nil
+{
+  let x = "block"
+  f = () -> x
+}
+println(f())`, "block"},
+		{"recursive", `{
+  fun fact(n) {
+    if n <= 1 { return 1 }
+    return n * fact(n - 1)
+  }
+  println(fact(5))
+}`, "120"},
+		{"loop body", `let first = nil
+let last = nil
+for let i = 0; i < 3; i++ {
+  let j = i
+  if i == 0 { first = () -> j }
+  last = () -> j
+}
+println(first(), last())`, "0 2"},
+		{"break", `let f = nil
+while true {
+  let k = 7
+  f = () -> k
+  break
+}
+println(f())`, "7"},
+		{"parameter", `fun adder(n) {
+  return x -> x + n
+}
+println(adder(2)(3))`, "5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkOutput(t, tt.src, tt.want)
+		})
+	}
+}
+
+func TestThrow(t *testing.T) {
+	checkFailure(t, `fun f(x) {
+  throw "bad " + x
+}
+f(1)`, "Uncaught exception: bad 1")
+}
+
+// TestEncodeClosure checks that captured variables survive a round trip
+// through the compiled file format.
+func TestEncodeClosure(t *testing.T) {
+	src := `fun counter() {
+  let n = 0
+  return () -> n += 1
+}
+let c = counter()
+c()
+println(c())`
+	fn, err := bytecode.Compile(parse(t, src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := bytecode.Encode(&buf, fn); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := bytecode.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := output(t, func() error { return vm.NewVM().Run(decoded) })
+	if strings.TrimSuffix(got, "\n") != "2" {
+		t.Errorf("printed %q, want %q", got, "2")
+	}
+}
@@ -0,0 +1,33 @@
+package vm_test
+
+import (
+	"testing"
+
+	"dotFun/internal/bytecode"
+	"dotFun/internal/lexer"
+	"dotFun/internal/parser"
+)
+
+// TestCompileErrorPosition compiles a program the resolver would reject,
+// so the compiler reports the error itself.
+func TestCompileErrorPosition(t *testing.T) {
+	tokens, err := lexer.NewLexer("{\n  let a = 1\n  let a = 2\n}").Lex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements, err := parser.NewParser(tokens).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = bytecode.Compile(statements)
+	compileErr, ok := err.(*bytecode.CompileError)
+	if !ok {
+		t.Fatalf("Compile returned %v, want a CompileError", err)
+	}
+	if line, column := compileErr.Position(); line != 3 || column != 3 {
+		t.Errorf("error %q at %d:%d, want 3:3", compileErr, line, column)
+	}
+	if start, end := compileErr.Offsets(); start != 16 || end != 25 {
+		t.Errorf("error %q spans %d-%d, want 16-25", compileErr, start, end)
+	}
+}
@@ -1 +1,29 @@
 package vm
+
+import "dotFun/internal/bytecode"
+
+type Frame struct {
+	closure  *Closure
+	function *bytecode.Function
+	ip       int
+	base     int
+}
+
+func (f *Frame) readByte() byte {
+	b := f.function.Chunk.Code[f.ip]
+	f.ip++
+	return b
+}
+
+func (f *Frame) readShort() int {
+	v := f.function.Chunk.ReadShort(f.ip)
+	f.ip += 2
+	return v
+}
+
+func (f *Frame) line() int {
+	if f.ip == 0 {
+		return 0
+	}
+	return f.function.Chunk.Lines[f.ip-1]
+}
@@ -0,0 +1,215 @@
+package vm_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"dotFun/internal/ast"
+	"dotFun/internal/bytecode"
+	"dotFun/internal/lexer"
+	"dotFun/internal/parser"
+	"dotFun/internal/resolver"
+	"dotFun/internal/runtime/stdlib/core"
+	"dotFun/internal/vm"
+)
+
+func parse(t *testing.T, src string) []ast.Stmt {
+	t.Helper()
+	tokens, err := lexer.NewLexer(src).Lex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements, err := parser.NewParser(tokens).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := resolver.NewResolver().Resolve(statements); len(errs) > 0 {
+		t.Fatal(errs[0])
+	}
+	return statements
+}
+
+// output runs fn with core.Stdout captured and returns what it printed.
+func output(t *testing.T, fn func() error) string {
+	t.Helper()
+	var buf bytes.Buffer
+	saved := core.Stdout
+	core.Stdout = &buf
+	defer func() { core.Stdout = saved }()
+	if err := fn(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// checkOutput runs src on the interpreter and on the VM, which must both
+// print want.
+func checkOutput(t *testing.T, src, want string) {
+	t.Helper()
+	interpreted := output(t, func() error {
+		return resolver.NewInterpreter().Interpret(parse(t, src))
+	})
+	compiled := output(t, func() error {
+		fn, err := bytecode.Compile(parse(t, src))
+		if err != nil {
+			return err
+		}
+		return vm.NewVM().Run(fn)
+	})
+	if interpreted != want+"\n" {
+		t.Errorf("interpreter printed %q, want %q", strings.TrimSuffix(interpreted, "\n"), want)
+	}
+	if compiled != want+"\n" {
+		t.Errorf("VM printed %q, want %q", strings.TrimSuffix(compiled, "\n"), want)
+	}
+}
+
+// checkFailure runs src on the interpreter and on the VM, which must both
+// fail with the message want.
+func checkFailure(t *testing.T, src, want string) {
+	t.Helper()
+	interpreted := resolver.NewInterpreter().Interpret(parse(t, src))
+	fn, err := bytecode.Compile(parse(t, src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compiled := vm.NewVM().Run(fn)
+	for _, err := range []error{interpreted, compiled} {
+		if err == nil || err.Error() != want {
+			t.Errorf("%q failed with %v, want %q", src, err, want)
+		}
+	}
+}
+
+// TestOperators runs each program on the interpreter and on the VM, which
+// must both print want.
+func TestOperators(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"!=", `println(1 != 2, 2 != 2, "a" != "b", nil != nil, 1 != "1")`,
+			"true false true false true"},
+		{"**", `println(2 ** 10, 2 ** -1, -2 ** 2, 2 ** 3 ** 2, 1.5 ** 2, (-2) ** 3)`,
+			"1024 0.5 -4 512 2.25 -8"},
+		{"~", `println(~5, ~-1, ~0)`,
+			"-6 0 -1"},
+		{"^", `println(6 ^ 3, 5 ^ 5, 1 | 6 ^ 3 & 5)`,
+			"5 0 7"},
+		{"+=", `let a = 10
+a += 5
+let s = "x"
+s += "y"
+println(a, s)`,
+			"15 xy"},
+		{"-=", `let a = 10
+a -= 15
+println(a)`,
+			"-5"},
+		{"*=", `let a = 3
+a *= a
+println(a)`,
+			"9"},
+		{"/=", `let a = 12
+a /= 4
+println(a)`,
+			"3"},
+		{"%=", `let a = 17
+a %= 5
+println(a)`,
+			"2"},
+		{"&=", `let a = 12
+a &= 10
+println(a)`,
+			"8"},
+		{"|=", `let a = 12
+a |= 5
+println(a)`,
+			"13"},
+		{"<<=", `let a = 3
+a <<= 2
+println(a)`,
+			"12"},
+		{">>=", `let a = 52
+a >>= 3
+println(a)`,
+			"6"},
+		{"compound assignment in a function", `fun f(b) {
+  let c = b
+  c += 1
+  c *= 2
+  b -= 1
+  return c + b
+}
+println(f(3))`,
+			"10"},
+		{"compound assignment value", `let a = 1
+println(a += 2, a)`,
+			"3 3"},
+		{"?.", `let n = nil
+let s = "abc"
+println(n?.length, s?.length, [1, 2]?.length)`,
+			"nil 3 2"},
+		{"??", `fun side() {
+  println("evaluated")
+  return 2
+}
+println(nil ?? 7, 3 ?? side(), false ?? 1, nil ?? nil ?? 1)`,
+			"7 3 false 1"},
+		{"..", `println(1..4, 3..3, 0..-1)`,
+			"[1, 2, 3, 4] [3] []"},
+		{"..<", `println(1..<4, 3..<3)`,
+			"[1, 2, 3] []"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkOutput(t, tt.src, tt.want)
+		})
+	}
+}
+
+// TestMemberAssignment checks that both back ends resize an array when its
+// length is assigned.
+func TestMemberAssignment(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"truncate", `let a = [1, 2, 3]
+a.length = 1
+println(a, a.length)`, "[1] 1"},
+		{"extend", `let a = [1]
+println(a.length += 2, a)`, "3 [1, nil, nil]"},
+		{"clear", `let a = [1, 2]
+let b = a
+println(a.length = 0, b)`, "0 []"},
+		{"shrink then grow", `let a = [1, 2, 3]
+a.length -= 2
+a.length = 2
+println(a)`, "[1, nil]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkOutput(t, tt.src, tt.want)
+		})
+	}
+}
+
+// TestMemberAssignmentErrors checks that both back ends refuse the
+// assignments SetProperty cannot make.
+func TestMemberAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{`let s = "abc"
+s.length = 1`, "String property 'length' is read-only"},
+		{`let a = [1]
+a.size = 1`, "Array has no property 'size'"},
+		{`let a = [1]
+a.length = "2"`, "Array length must be an Int, got String"},
+		{`let a = [1]
+a.length -= 2`, "Negative array length -1"},
+	}
+	for _, tt := range tests {
+		checkFailure(t, tt.src, tt.want)
+	}
+}
@@ -1 +1,47 @@
 package vm
+
+import "dotFun/internal/runtime"
+
+type Stack struct {
+	values []runtime.Value
+}
+
+func NewStack() *Stack {
+	return &Stack{values: make([]runtime.Value, 0, 256)}
+}
+
+func (s *Stack) Push(v runtime.Value) {
+	s.values = append(s.values, v)
+}
+
+func (s *Stack) Pop() runtime.Value {
+	v := s.values[len(s.values)-1]
+	s.values = s.values[:len(s.values)-1]
+	return v
+}
+
+// Peek returns the value distance slots below the top without removing it.
+func (s *Stack) Peek(distance int) runtime.Value {
+	return s.values[len(s.values)-1-distance]
+}
+
+func (s *Stack) Get(index int) runtime.Value {
+	return s.values[index]
+}
+
+func (s *Stack) Set(index int, v runtime.Value) {
+	s.values[index] = v
+}
+
+func (s *Stack) Len() int {
+	return len(s.values)
+}
+
+func (s *Stack) Truncate(n int) {
+	s.values = s.values[:n]
+}
+
+// Slice returns the values from index to the top.
+func (s *Stack) Slice(from int) []runtime.Value {
+	return s.values[from:]
+}
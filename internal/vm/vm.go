@@ -1 +1,262 @@
 package vm
+
+import (
+	"fmt"
+	"strings"
+
+	"dotFun/internal/bytecode"
+	"dotFun/internal/runtime"
+	"dotFun/internal/runtime/stdlib/core"
+)
+
+const maxFrames = 1024
+
+type VM struct {
+	stack   *Stack
+	frames  []*Frame
+	globals map[string]runtime.Value
+	// openUpvalues are the captured variables still on the stack.
+	openUpvalues []*Upvalue
+}
+
+func NewVM() *VM {
+	globals := map[string]runtime.Value{}
+	for _, native := range core.Natives() {
+		globals[native.Name] = native
+	}
+	return &VM{
+		stack:   NewStack(),
+		globals: globals,
+	}
+}
+
+func (vm *VM) Run(fn *bytecode.Function) (err error) {
+	vm.stack.Truncate(0)
+	vm.frames = vm.frames[:0]
+	vm.openUpvalues = nil
+
+	defer func() {
+		if r := recover(); r != nil {
+			runtimeErr, ok := r.(*runtime.Error)
+			if !ok {
+				panic(r)
+			}
+			err = runtimeErr
+		}
+	}()
+
+	script := &Closure{Function: fn}
+	vm.stack.Push(script)
+	vm.callValue(script, 0)
+	vm.execute()
+	return nil
+}
+
+func (vm *VM) fail(format string, args ...any) {
+	line := 0
+	if len(vm.frames) > 0 {
+		line = vm.frames[len(vm.frames)-1].line()
+	}
+	panic(&runtime.Error{Message: fmt.Sprintf(format, args...), Line: line})
+}
+
+func (vm *VM) check(err error) {
+	if err != nil {
+		vm.fail("%s", err.Error())
+	}
+}
+
+func (vm *VM) execute() {
+	frame := vm.frames[len(vm.frames)-1]
+	chunk := frame.function.Chunk
+
+	for {
+		op := bytecode.OpCode(frame.readByte())
+
+		switch op {
+		case bytecode.OpConstant:
+			vm.stack.Push(chunk.Constants[frame.readShort()])
+		case bytecode.OpNil:
+			vm.stack.Push(runtime.NilValue)
+		case bytecode.OpTrue:
+			vm.stack.Push(runtime.Bool(true))
+		case bytecode.OpFalse:
+			vm.stack.Push(runtime.Bool(false))
+		case bytecode.OpPop:
+			vm.stack.Pop()
+		case bytecode.OpDup:
+			vm.stack.Push(vm.stack.Peek(0))
+
+		case bytecode.OpGetLocal:
+			vm.stack.Push(vm.stack.Get(frame.base + int(frame.readByte())))
+		case bytecode.OpSetLocal:
+			vm.stack.Set(frame.base+int(frame.readByte()), vm.stack.Peek(0))
+
+		case bytecode.OpDefineGlobal:
+			name := string(chunk.Constants[frame.readShort()].(runtime.String))
+			vm.globals[name] = vm.stack.Pop()
+		case bytecode.OpGetGlobal:
+			name := string(chunk.Constants[frame.readShort()].(runtime.String))
+			value, ok := vm.globals[name]
+			if !ok {
+				vm.fail("Undefined variable '%s'", name)
+			}
+			vm.stack.Push(value)
+		case bytecode.OpSetGlobal:
+			name := string(chunk.Constants[frame.readShort()].(runtime.String))
+			if _, ok := vm.globals[name]; !ok {
+				vm.fail("Undefined variable '%s'", name)
+			}
+			vm.globals[name] = vm.stack.Peek(0)
+		case bytecode.OpGetUpvalue:
+			vm.stack.Push(frame.closure.Upvalues[frame.readByte()].get(vm.stack))
+		case bytecode.OpSetUpvalue:
+			frame.closure.Upvalues[frame.readByte()].set(vm.stack, vm.stack.Peek(0))
+		case bytecode.OpCloseUpvalue:
+			vm.closeUpvalues(vm.stack.Len() - 1)
+			vm.stack.Pop()
+
+		case bytecode.OpAdd, bytecode.OpSubtract, bytecode.OpMultiply, bytecode.OpDivide, bytecode.OpModulo,
+			bytecode.OpPower, bytecode.OpBitAnd, bytecode.OpBitOr, bytecode.OpBitXor, bytecode.OpShiftLeft,
+			bytecode.OpShiftRight, bytecode.OpEqual, bytecode.OpNotEqual, bytecode.OpGreater, bytecode.OpGreaterEqual, bytecode.OpLess, bytecode.OpLessEqual:
+			b := vm.stack.Pop()
+			a := vm.stack.Pop()
+			result, err := runtime.Binary(bytecode.BinaryOperators[op], a, b)
+			vm.check(err)
+			vm.stack.Push(result)
+
+		case bytecode.OpNot:
+			vm.stack.Push(runtime.Not(vm.stack.Pop()))
+		case bytecode.OpNegate:
+			result, err := runtime.Negate(vm.stack.Pop())
+			vm.check(err)
+			vm.stack.Push(result)
+		case bytecode.OpBitNot:
+			result, err := runtime.BitNot(vm.stack.Pop())
+			vm.check(err)
+			vm.stack.Push(result)
+		case bytecode.OpInstanceOf:
+			typeName := string(chunk.Constants[frame.readShort()].(runtime.String))
+			vm.stack.Push(runtime.Bool(runtime.IsInstance(vm.stack.Pop(), typeName)))
+
+		case bytecode.OpJump:
+			offset := frame.readShort()
+			frame.ip += offset
+		case bytecode.OpJumpIfFalse:
+			offset := frame.readShort()
+			if !runtime.Truthy(vm.stack.Peek(0)) {
+				frame.ip += offset
+			}
+		case bytecode.OpJumpIfNil, bytecode.OpJumpIfNotNil:
+			offset := frame.readShort()
+			_, isNil := vm.stack.Peek(0).(runtime.Nil)
+			if isNil == (op == bytecode.OpJumpIfNil) {
+				frame.ip += offset
+			}
+		case bytecode.OpLoop:
+			offset := frame.readShort()
+			frame.ip -= offset
+
+		case bytecode.OpCall:
+			argCount := int(frame.readByte())
+			vm.callValue(vm.stack.Peek(argCount), argCount)
+			frame = vm.frames[len(vm.frames)-1]
+			chunk = frame.function.Chunk
+
+		case bytecode.OpClosure:
+			fn := chunk.Constants[frame.readShort()].(*bytecode.Function)
+			closure := &Closure{Function: fn, Upvalues: make([]*Upvalue, fn.Upvalues)}
+			for i := range closure.Upvalues {
+				isLocal := frame.readByte() == 1
+				index := int(frame.readByte())
+				if isLocal {
+					closure.Upvalues[i] = vm.captureUpvalue(frame.base + index)
+				} else {
+					closure.Upvalues[i] = frame.closure.Upvalues[index]
+				}
+			}
+			vm.stack.Push(closure)
+
+		case bytecode.OpReturn:
+			result := vm.stack.Pop()
+			vm.closeUpvalues(frame.base)
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			vm.stack.Truncate(frame.base)
+			if len(vm.frames) == 0 {
+				return
+			}
+			vm.stack.Push(result)
+			frame = vm.frames[len(vm.frames)-1]
+			chunk = frame.function.Chunk
+		case bytecode.OpThrow:
+			vm.fail("Uncaught exception: %s", vm.stack.Pop().String())
+
+		case bytecode.OpArray:
+			count := frame.readShort()
+			elements := make([]runtime.Value, count)
+			copy(elements, vm.stack.Slice(vm.stack.Len()-count))
+			vm.stack.Truncate(vm.stack.Len() - count)
+			vm.stack.Push(&runtime.Array{Elements: elements})
+
+		case bytecode.OpInterpolate:
+			count := frame.readShort()
+			var sb strings.Builder
+			for _, part := range vm.stack.Slice(vm.stack.Len() - count) {
+				sb.WriteString(part.String())
+			}
+			vm.stack.Truncate(vm.stack.Len() - count)
+			vm.stack.Push(runtime.String(sb.String()))
+
+		case bytecode.OpGetProperty:
+			name := string(chunk.Constants[frame.readShort()].(runtime.String))
+			value, err := runtime.Property(vm.stack.Pop(), name)
+			vm.check(err)
+			vm.stack.Push(value)
+		case bytecode.OpSetProperty:
+			name := string(chunk.Constants[frame.readShort()].(runtime.String))
+			value := vm.stack.Pop()
+			vm.check(runtime.SetProperty(vm.stack.Pop(), name, value))
+			vm.stack.Push(value)
+		case bytecode.OpRange:
+			inclusive := frame.readByte() == 1
+			end := vm.stack.Pop()
+			result, err := runtime.Range(vm.stack.Pop(), end, inclusive)
+			vm.check(err)
+			vm.stack.Push(result)
+
+		default:
+			vm.fail("Unknown opcode %d", op)
+		}
+	}
+}
+
+func (vm *VM) callValue(callee runtime.Value, argCount int) {
+	fn, ok := callee.(runtime.Callable)
+	if !ok {
+		vm.fail("Can only call functions, got %s", callee.Type())
+	}
+	if arity := fn.Arity(); arity != runtime.Variadic && arity != argCount {
+		vm.fail("Expected %d arguments but got %d", arity, argCount)
+	}
+
+	switch fn := fn.(type) {
+	case *Closure:
+		if len(vm.frames) == maxFrames {
+			vm.fail("Stack overflow")
+		}
+		vm.frames = append(vm.frames, &Frame{
+			closure:  fn,
+			function: fn.Function,
+			base:     vm.stack.Len() - argCount - 1,
+		})
+	case *runtime.NativeFunction:
+		args := make([]runtime.Value, argCount)
+		copy(args, vm.stack.Slice(vm.stack.Len()-argCount))
+		result, err := fn.Call(args)
+		vm.check(err)
+		vm.stack.Truncate(vm.stack.Len() - argCount - 1)
+		vm.stack.Push(result)
+	default:
+		vm.fail("Cannot call %s", callee.Type())
+	}
+}